@@ -79,3 +79,30 @@ func NewKeyedTransactor(key *ecdsa.PrivateKey) *TransactOpts {
 		},
 	}
 }
+
+// NewKeyStoreProviderSigner is a utility method to easily create a provider signer
+// from an decrypted key from a keystore, to be set as TransactOpts.ProviderSigner.
+func NewKeyStoreProviderSigner(keystore *keystore.KeyStore, account accounts.Account) SignerFn {
+	return func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != account.Address {
+			return nil, errors.New("not authorized to sign as this provider")
+		}
+		signature, err := keystore.SignHash(account, signer.ProviderHash(tx).Bytes())
+		if err != nil {
+			return nil, err
+		}
+		return tx.WithProviderSignature(signer, signature)
+	}
+}
+
+// NewKeyedProviderSigner is a utility method to easily create a provider signer
+// from a single private key, to be set as TransactOpts.ProviderSigner.
+func NewKeyedProviderSigner(key *ecdsa.PrivateKey) SignerFn {
+	keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+	return func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != keyAddr {
+			return nil, errors.New("not authorized to sign as this provider")
+		}
+		return types.ProviderSignTx(tx, signer, key)
+	}
+}
@@ -247,10 +247,17 @@ func (b *SimulatedBackend) PendingNonceAt(ctx context.Context, account common.Ad
 	return b.pendingState.GetOrNewStateObject(account).Nonce(), nil
 }
 
-// SuggestGasPrice implements ContractTransactor.SuggestGasPrice. Since the simulated
-// chain doesn't have miners, we just return a gas price of 1 for any call.
+// SuggestGasPrice implements ContractTransactor.SuggestGasPrice, returning the gas
+// price scheduled for the pending block in the state of the simulated chain.
 func (b *SimulatedBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
-	return b.config.GasPrice, nil
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statedb, err := b.blockchain.State()
+	if err != nil {
+		return nil, err
+	}
+	return core.GasPriceAt(statedb, b.config, b.pendingBlock.NumberU64()), nil
 }
 
 // EstimateGas executes the requested code against the currently pending block/state and
@@ -332,7 +339,8 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallM
 }
 
 // SendTransaction updates the pending block to include the given transaction.
-// It panics if the transaction is invalid.
+// It panics if the transaction is invalid and returns an error if it breaks the
+// provider rules of enterprise contracts.
 func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -345,6 +353,9 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	if tx.Nonce() != nonce {
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
+	if err := b.validateProvider(tx); err != nil {
+		return err
+	}
 
 	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
@@ -359,6 +370,29 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	return nil
 }
 
+// validateProvider enforces the provider rules of the transaction pool: transactions
+// to enterprise contracts must be co-signed by one of their providers, which pays
+// the fee, and other transactions must not carry a provider signature.
+func (b *SimulatedBackend) validateProvider(tx *types.Transaction) error {
+	provider, err := types.Provider(types.HomesteadSigner{}, tx)
+	if err != nil {
+		return core.ErrInvalidProvider
+	}
+	var providers []*common.Address
+	if to := tx.To(); to != nil {
+		providers = b.pendingState.GetProviders(*to)
+	}
+	switch {
+	case len(providers) > 0 && (provider == nil || !provider.InList(providers)):
+		return core.ErrInvalidProvider
+	case len(providers) == 0 && provider != nil:
+		return core.ErrRedundantProvider
+	case provider != nil && b.pendingState.GetBalance(*provider).Cmp(tx.TransactionFee()) < 0:
+		return core.ErrProviderInsufficientFunds
+	}
+	return nil
+}
+
 // FilterLogs executes a log filter operation, blocking during execution and
 // returning all the results in one batch.
 //
@@ -470,17 +504,20 @@ type callmsg struct {
 	ethereum.CallMsg
 }
 
-func (m callmsg) GasPayer() common.Address  { return m.CallMsg.From }
-func (m callmsg) Owner() *common.Address    { return nil }
-func (m callmsg) Provider() *common.Address { return nil }
-func (m callmsg) From() common.Address      { return m.CallMsg.From }
-func (m callmsg) Nonce() uint64             { return 0 }
-func (m callmsg) CheckNonce() bool          { return false }
-func (m callmsg) To() *common.Address       { return m.CallMsg.To }
-func (m callmsg) GasPrice() *big.Int        { return m.CallMsg.GasPrice }
-func (m callmsg) Gas() uint64               { return m.CallMsg.Gas }
-func (m callmsg) Value() *big.Int           { return m.CallMsg.Value }
-func (m callmsg) Data() []byte              { return m.CallMsg.Data }
+
+func (m callmsg) GasPayer() common.Address      { return m.CallMsg.From }
+func (m callmsg) Owner() *common.Address        { return nil }
+func (m callmsg) Provider() *common.Address     { return nil }
+func (m callmsg) From() common.Address          { return m.CallMsg.From }
+func (m callmsg) Nonce() uint64                 { return 0 }
+func (m callmsg) CheckNonce() bool              { return false }
+func (m callmsg) Calls() []types.BatchCall      { return nil }
+func (m callmsg) SenderAuth() *types.SenderAuth { return nil }
+func (m callmsg) To() *common.Address           { return m.CallMsg.To }
+func (m callmsg) GasPrice() *big.Int            { return m.CallMsg.GasPrice }
+func (m callmsg) Gas() uint64                   { return m.CallMsg.Gas }
+func (m callmsg) Value() *big.Int               { return m.CallMsg.Value }
+func (m callmsg) Data() []byte                  { return m.CallMsg.Data }
 
 // filterBackend implements filters.Backend to support filtering for logs without
 // taking bloom-bits acceleration structures into account.
@@ -543,7 +580,8 @@ func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscr
 	return fb.bc.SubscribeLogsEvent(ch)
 }
 
-func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
+func (fb *filterBackend) BloomStatus() (uint64, uint64)    { return 4096, 0 }
+func (fb *filterBackend) LogIndexStatus() (uint64, uint64) { return 4096, 0 }
 func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
 	panic("not supported")
 }
@@ -19,9 +19,11 @@ package backends_test
 import (
 	"context"
 	"math/big"
+	"strings"
 	"testing"
 
 	ethereum "github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
 	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind"
 	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind/backends"
 	"github.com/Evrynetlabs/evrynet-node/common"
@@ -80,3 +82,68 @@ func TestSimulatedBackend(t *testing.T) {
 	}
 
 }
+
+func TestSimulatedBackendProvider(t *testing.T) {
+	var (
+		ownerKey, _    = crypto.GenerateKey() // nolint: gosec
+		providerKey, _ = crypto.GenerateKey() // nolint: gosec
+		senderKey, _   = crypto.GenerateKey() // nolint: gosec
+		owner          = bind.NewKeyedTransactor(ownerKey)
+		sender         = bind.NewKeyedTransactor(senderKey)
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		balance        = big.NewInt(1000000000)
+	)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		owner.From: {Balance: balance},
+		provider:   {Balance: balance},
+	}, 8000029)
+
+	// deploy an enterprise contract owned by owner and sponsored by provider
+	parsed, err := abi.JSON(strings.NewReader("[]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner.Enterprise = &types.CreateAccountOption{OwnerAddress: &owner.From, ProviderAddress: &provider}
+	addr, _, contract, err := bind.DeployContract(owner, parsed, common.FromHex(`6060604052600a8060106000396000f360606040526008565b00`), sim)
+	if err != nil {
+		t.Fatalf("failed to deploy enterprise contract: %v", err)
+	}
+	sim.Commit()
+	providers, err := sim.CodeAt(context.Background(), addr, nil)
+	if err != nil || len(providers) == 0 {
+		t.Fatalf("enterprise contract not deployed: %v", err)
+	}
+
+	// the sender has no funds, the transaction must be co-signed by the provider
+	sender.GasLimit = 100000
+	if _, err := contract.Transfer(sender); err != core.ErrInvalidProvider {
+		t.Fatalf("unsponsored transaction error mismatch: have %v, want %v", err, core.ErrInvalidProvider)
+	}
+	// a valid co-signature of an account which isn't a provider of the contract
+	sender.Provider, sender.ProviderSigner = owner.From, bind.NewKeyedProviderSigner(ownerKey)
+	if _, err := contract.Transfer(sender); err != core.ErrInvalidProvider {
+		t.Fatalf("unauthorized provider error mismatch: have %v, want %v", err, core.ErrInvalidProvider)
+	}
+	sender.Provider, sender.ProviderSigner = provider, bind.NewKeyedProviderSigner(providerKey)
+	tx, err := contract.Transfer(sender)
+	if err != nil {
+		t.Fatalf("failed to send sponsored transaction: %v", err)
+	}
+	sim.Commit()
+
+	receipt, err := sim.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to get receipt: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatal("sponsored transaction failed")
+	}
+	paid, err := sim.BalanceAt(context.Background(), provider, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), tx.GasPrice())
+	if want := new(big.Int).Sub(balance, fee); paid.Cmp(want) != 0 {
+		t.Fatalf("provider balance mismatch: have %v, want %v", paid, want)
+	}
+}
@@ -49,6 +49,9 @@ type TransactOpts struct {
 	Nonce  *big.Int       // Nonce to use for the transaction execution (nil = use pending state)
 	Signer SignerFn       // Method to use for signing the transaction (mandatory)
 
+	Provider       common.Address // Provider paying the fee of a transaction to an enterprise contract (optional)
+	ProviderSigner SignerFn       // Method to use for co-signing the transaction as Provider (nil = not sponsored)
+
 	Value    *big.Int // Funds to transfer along along the transaction (nil = 0 = no funds)
 	GasPrice *big.Int // Gas price to use for the transaction execution (nil = gas price oracle)
 	GasLimit uint64   // Gas limit to set for the transaction execution (0 = estimate)
@@ -245,6 +248,12 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 	if err != nil {
 		return nil, err
 	}
+	// Enterprise contracts require one of their providers to co-sign the transaction
+	if opts.ProviderSigner != nil {
+		if signedTx, err = opts.ProviderSigner(types.HomesteadSigner{}, opts.Provider, signedTx); err != nil {
+			return nil, err
+		}
+	}
 	if err := c.transactor.SendTransaction(ensureContext(opts.Context), signedTx); err != nil {
 		return nil, err
 	}
@@ -721,7 +721,7 @@ func (w *Wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID
 // the account in a keystore).
 func (w *Wallet) ProviderSignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
 	signer := types.NewEIP155Signer(chainID)
-	hash := signer.Hash(tx)
+	hash := signer.ProviderHash(tx)
 	sig, err := w.signHash(account, hash[:])
 	if err != nil {
 		return nil, err
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -48,6 +49,7 @@ var (
 		ArgsUsage: "<genesisPath>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -64,16 +66,20 @@ It expects the genesis file as argument.`,
 		ArgsUsage: "<filename> (<filename 2> ... <filename N>) ",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.PruneRecentFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 The import command imports blocks from an RLP-encoded form. The form can be one file
-with several RLP-encoded blocks, or several files can be used.
+with several RLP-encoded blocks, or several files can be used. Files ending with .era
+are history archives written by "history export", restoring the expired bodies and
+receipts of the blocks already in the chain.
 
 If only one file is used, import error will result in failure. If several files are used,
 processing will proceed even if an individual RLP-file import failure occurs.`,
@@ -85,6 +91,7 @@ processing will proceed even if an individual RLP-file import failure occurs.`,
 		ArgsUsage: "<filename> [<blockNumFirst> <blockNumLast>]",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 		},
@@ -103,6 +110,7 @@ be gzipped.`,
 		ArgsUsage: "<datafile>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 		},
@@ -117,6 +125,7 @@ be gzipped.`,
 		ArgsUsage: "<dumpfile>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 		},
@@ -131,6 +140,7 @@ The export-preimages command export hash preimages to an RLP encoded stream`,
 		ArgsUsage: "<sourceChaindataDir>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 			utils.FakePoWFlag,
@@ -160,6 +170,7 @@ Remove blockchain and state databases`,
 		ArgsUsage: "[<blockHash> | <blockNum>]...",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 		},
@@ -175,6 +186,7 @@ Use "ethereum dump 0" to dump the genesis block.`,
 		ArgsUsage: " ",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.TestnetFlag,
@@ -189,11 +201,82 @@ Use "ethereum dump 0" to dump the genesis block.`,
 			utils.TendermintTimeoutPrecommitFlag,
 			utils.TendermintTimeoutPrecommitDeltaFlag,
 			utils.TendermintTimeoutCommitFlag,
+			utils.TendermintBlockBuildRatioFlag,
 			utils.TendermintFaultyModeFlag,
 			utils.TendermintSCUseEVMCallerFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 	}
+	pruneBloomSizeFlag = cli.Uint64Flag{
+		Name:  "bloomfilter.size",
+		Usage: "Megabytes of memory allocated to the bloom filter marking the retained state",
+		Value: 2048,
+	}
+	pruneStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(pruneState),
+		Name:      "prune-state",
+		Usage:     "Delete the stale state from the database",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.DBEngineFlag,
+			utils.AncientFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			pruneBloomSizeFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The prune-state command deletes the trie nodes and contract codes of all the
+states but the ones of the head block, the blocks committed with it at shutdown,
+and the last two epoch checkpoints, which are needed to reward the next epoch.
+The node must be stopped. Running it with --gcmode=prune afterwards keeps the
+database pruned.`,
+	}
+	historyCommand = cli.Command{
+		Name:     "history",
+		Usage:    "Manage the expired chain history",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(exportHistory),
+				Name:      "export",
+				Usage:     "Export the chain history into archive files",
+				ArgsUsage: "<dir> [<blockNumFirst> <blockNumLast>]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.DBEngineFlag,
+					utils.AncientFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					utils.HistoryRetentionFlag,
+				},
+				Description: `
+The history export command writes the headers, bodies and receipts of a range of
+blocks into archive files in the given directory, one per era of 8192 blocks. The
+range defaults to the blocks whose history is expired by --history.retention, so
+running it before enabling the expiry keeps that history available offline. The
+archives are imported back with "import".`,
+			},
+		},
+	}
+	migratedbCommand = cli.Command{
+		Action:    utils.MigrateFlags(migrateDB),
+		Name:      "migratedb",
+		Usage:     "Migrate the LevelDB databases to another database engine",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.DBEngineFlag,
+			utils.CacheFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The migratedb command copies the LevelDB chain databases into new databases
+backed by the engine given with --db.engine, which are used from then on. The
+node must be stopped. The ancient chain segments are kept as they are, and the
+LevelDB files can be deleted once the migrated databases have been checked.`,
+	}
 )
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -261,13 +344,19 @@ func importChain(ctx *cli.Context) error {
 	// Import the chain
 	start := time.Now()
 
+	importFile := func(fn string) error {
+		if strings.HasSuffix(fn, ".era") {
+			return utils.ImportHistory(chain, db, fn)
+		}
+		return utils.ImportChain(chain, fn)
+	}
 	if len(ctx.Args()) == 1 {
-		if err := utils.ImportChain(chain, ctx.Args().First()); err != nil {
+		if err := importFile(ctx.Args().First()); err != nil {
 			log.Error("Import error", "err", err)
 		}
 	} else {
 		for _, arg := range ctx.Args() {
-			if err := utils.ImportChain(chain, arg); err != nil {
+			if err := importFile(arg); err != nil {
 				log.Error("Import error", "file", arg, "err", err)
 			}
 		}
@@ -357,6 +446,49 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
+// exportHistory exports the chain history into archive files, by default the
+// history expired with the configured retention.
+func exportHistory(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 && len(ctx.Args()) != 3 {
+		utils.Fatalf("This command requires a directory and optionally a block range.")
+	}
+	stack := makeFullNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack)
+	defer db.Close()
+
+	var first, last uint64
+	if len(ctx.Args()) == 3 {
+		var ferr, lerr error
+		first, ferr = strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		last, lerr = strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+		if ferr != nil || lerr != nil {
+			utils.Fatalf("Export error in parsing parameters: block number not an integer")
+		}
+	} else {
+		retention := ctx.GlobalUint64(utils.HistoryRetentionFlag.Name)
+		if retention == 0 {
+			utils.Fatalf("Either a block range or --%s is required", utils.HistoryRetentionFlag.Name)
+		}
+		head := chain.CurrentBlock().NumberU64()
+		if head <= retention {
+			utils.Fatalf("No history to export: chain head #%d within the retention of %d blocks", head, retention)
+		}
+		last = head - retention - 1
+	}
+	if head := chain.CurrentBlock().NumberU64(); last > head {
+		utils.Fatalf("Export error: last block #%d after chain head #%d", last, head)
+	}
+	start := time.Now()
+	network := fmt.Sprintf("evrynet-%d", chain.Config().ChainID)
+	if err := utils.ExportHistory(db, ctx.Args().First(), network, first, last); err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
 // importPreimages imports preimage data from the specified file.
 func importPreimages(ctx *cli.Context) error {
 	if len(ctx.Args()) < 1 {
@@ -415,7 +547,7 @@ func copyDb(ctx *cli.Context) error {
 	dl := downloader.New(0, chainDb, syncBloom, new(event.TypeMux), chain, nil, nil)
 
 	// Create a source peer to satisfy downloader requests from
-	db, err := rawdb.NewLevelDBDatabaseWithFreezer(ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name)/2, 256, ctx.Args().Get(1), "")
+	db, err := rawdb.NewDatabaseWithEngineAndFreezer("", ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name)/2, 256, ctx.Args().Get(1), "")
 	if err != nil {
 		return err
 	}
@@ -547,6 +679,47 @@ func inspect(ctx *cli.Context) error {
 	return rawdb.InspectDatabase(chainDb)
 }
 
+// pruneState deletes the stale state from the database.
+func pruneState(ctx *cli.Context) error {
+	node, _ := makeConfigNode(ctx)
+	defer node.Close()
+
+	chain, chainDb := utils.MakeChain(ctx, node)
+	defer chainDb.Close()
+
+	start := time.Now()
+	if err := utils.PruneState(chain, chainDb, ctx.Uint64(pruneBloomSizeFlag.Name)); err != nil {
+		utils.Fatalf("Prune error: %v\n", err)
+	}
+	fmt.Printf("Pruning done in %v\n", time.Since(start))
+	return nil
+}
+
+// migrateDB copies the LevelDB chain databases into databases backed by another
+// engine.
+func migrateDB(ctx *cli.Context) error {
+	node, config := makeConfigNode(ctx)
+	defer node.Close()
+
+	engine := config.Node.DBEngine
+	if engine == "" || engine == rawdb.LevelDBEngine {
+		utils.Fatalf("Target database engine missing, set it with --%s", utils.DBEngineFlag.Name)
+	}
+	for _, name := range []string{"chaindata", "lightchaindata"} {
+		path := node.ResolvePath(name)
+		if rawdb.DatabaseEngine(path) != rawdb.LevelDBEngine {
+			log.Info("No LevelDB database to migrate", "path", path)
+			continue
+		}
+		start := time.Now()
+		if err := rawdb.MigrateDatabase(path, engine, config.Eth.DatabaseCache, config.Eth.DatabaseHandles); err != nil {
+			utils.Fatalf("Failed to migrate database %s: %v", path, err)
+		}
+		log.Info("Database successfully migrated", "path", path, "engine", engine, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
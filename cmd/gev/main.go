@@ -20,7 +20,6 @@ package main
 import (
 	"fmt"
 	"math"
-	"math/big"
 	"os"
 	godebug "runtime/debug"
 	"sort"
@@ -67,6 +66,7 @@ var (
 		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
 		utils.AncientFlag,
+		utils.DBEngineFlag,
 		utils.KeyStoreDirFlag,
 		utils.ExternalSignerFlag,
 		utils.NoUSBFlag,
@@ -82,6 +82,7 @@ var (
 		utils.EthashDatasetsInMemoryFlag,
 		utils.EthashDatasetsOnDiskFlag,
 		utils.TxPoolLocalsFlag,
+		utils.TxPoolPriorityFlag,
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
@@ -91,7 +92,11 @@ var (
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolProviderSlotsFlag,
+		utils.TxPoolContractSenderSlotsFlag,
+		utils.TxPoolGlobalContractSenderSlotsFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolScheduleHorizonFlag,
 		utils.ULCModeConfigFlag,
 		utils.OnlyAnnounceModeFlag,
 		utils.ULCTrustedNodesFlag,
@@ -99,16 +104,24 @@ var (
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
+		utils.PruneRecentFlag,
+		utils.HistoryRetentionFlag,
+		utils.TxLookupLimitFlag,
+		utils.StateReexecFlag,
+		utils.LogIndexFlag,
+		utils.AccountIndexFlag,
 		utils.LightServFlag,
 		utils.LightBandwidthInFlag,
 		utils.LightBandwidthOutFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
 		utils.WhitelistFlag,
+		utils.SyncCheckpointFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
 		utils.CacheGCFlag,
+		utils.CacheSnapshotFlag,
 		utils.CacheNoPrefetchFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
@@ -126,6 +139,7 @@ var (
 		utils.MinerLegacyExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerMaxAccountTxsFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -158,6 +172,7 @@ var (
 		utils.TendermintTimeoutPrecommitFlag,
 		utils.TendermintTimeoutPrecommitDeltaFlag,
 		utils.TendermintTimeoutCommitFlag,
+		utils.TendermintBlockBuildRatioFlag,
 		utils.TendermintSCUseEVMCallerFlag,
 	}
 
@@ -219,6 +234,10 @@ func init() {
 		removedbCommand,
 		dumpCommand,
 		inspectCommand,
+		pruneStateCommand,
+		historyCommand,
+		migratedbCommand,
+		snapshotCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -424,9 +443,8 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		if err := stack.Service(&evrynet); err != nil {
 			utils.Fatalf("Evrynet service not running: %v", err)
 		}
-		// Set the gas price to the limits from the CLI and start mining
-		gasPrice := new(big.Int).Set(evrynet.BlockChain().Config().GasPrice)
-		evrynet.TxPool().SetGasPrice(gasPrice)
+		// Set the gas price to the one scheduled in the chain head state and start mining
+		evrynet.TxPool().SetGasPrice(evrynet.TxPool().ScheduledGasPrice())
 
 		threads := ctx.GlobalInt(utils.MinerLegacyThreadsFlag.Name)
 		if ctx.GlobalIsSet(utils.MinerThreadsFlag.Name) {
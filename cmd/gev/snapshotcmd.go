@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/cmd/utils"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/urfave/cli"
+)
+
+var (
+	snapshotBlockFlag = cli.Uint64Flag{
+		Name:  "block",
+		Usage: "Number of the block to export the state of (defaults to the head block, or the last epoch block on staking chains)",
+	}
+	snapshotHashFlag = cli.StringFlag{
+		Name:  "hash",
+		Usage: "Trusted hash of the snapshot block, or of the epoch header committing it",
+	}
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Export and import state snapshots",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Export the state of a committed block into a snapshot file, or bootstrap a new
+node from such a file instead of syncing the chain from genesis.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the state of a block into a snapshot file",
+				Action:    utils.MigrateFlags(exportSnapshot),
+				ArgsUsage: "<filename>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					snapshotBlockFlag,
+				},
+				Description: `
+The export command writes the full account and storage tries of a block, along
+with the block itself, into a chunked and checksummed file. If the file ends with
+.gz, the output will be gzipped. The state of the block must be available, which
+is only the case for recent blocks unless the node runs with --gcmode=archive.
+On Tendermint chains with staking validators, the block must be an epoch block.`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Bootstrap an empty database from a snapshot file",
+				Action:    utils.MigrateFlags(importSnapshot),
+				ArgsUsage: "<filename>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					snapshotHashFlag,
+				},
+				Description: `
+The import command rebuilds the state of the snapshot block into a database only
+holding the genesis block, verifying the snapshot checksums and state root, and
+sets the block as the head of the chain. The node then resumes full sync from it.
+The blocks before the snapshot block are not available.
+
+The snapshot block must have the hash given with --hash. On Tendermint chains, the
+hash may also be the one of the epoch header whose validators committed the block,
+which can be omitted if the epoch header is the genesis.`,
+			},
+		},
+	}
+)
+
+// exportSnapshot exports the state of a block into a snapshot file.
+func exportSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack := makeFullNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack)
+	defer db.Close()
+
+	number := chain.CurrentBlock().NumberU64()
+	if config := chain.Config().Tendermint; config != nil && len(config.FixedValidators) == 0 {
+		number -= number % config.Epoch
+	}
+	if ctx.IsSet(snapshotBlockFlag.Name) {
+		number = ctx.Uint64(snapshotBlockFlag.Name)
+	}
+	start := time.Now()
+	if err := utils.ExportSnapshot(chain, ctx.Args().First(), number); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// importSnapshot bootstraps an empty database from a snapshot file.
+func importSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	var trusted common.Hash
+	if ctx.IsSet(snapshotHashFlag.Name) {
+		if err := trusted.UnmarshalText([]byte(ctx.String(snapshotHashFlag.Name))); err != nil {
+			utils.Fatalf("Invalid trusted hash: %v", err)
+		}
+	}
+	stack := makeFullNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	start := time.Now()
+	block, err := utils.ImportSnapshot(db, ctx.Args().First(), trusted)
+	if err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	fmt.Printf("Imported state of block #%d [%x] in %v\n", block.NumberU64(), block.Hash(), time.Since(start))
+	return nil
+}
@@ -70,6 +70,7 @@ var AppHelpFlagGroups = []flagGroup{
 			configFileFlag,
 			utils.DataDirFlag,
 			utils.AncientFlag,
+			utils.DBEngineFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
 			utils.SmartCardDaemonPathFlag,
@@ -80,6 +81,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.SyncModeFlag,
 			utils.ExitWhenSyncedFlag,
 			utils.GCModeFlag,
+			utils.PruneRecentFlag,
+			utils.HistoryRetentionFlag,
+			utils.TxLookupLimitFlag,
+			utils.StateReexecFlag,
+			utils.LogIndexFlag,
+			utils.AccountIndexFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
@@ -88,6 +95,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.LightPeersFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
+			utils.SyncCheckpointFlag,
 		},
 	},
 	{
@@ -122,6 +130,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "TRANSACTION POOL",
 		Flags: []cli.Flag{
 			utils.TxPoolLocalsFlag,
+			utils.TxPoolPriorityFlag,
 			utils.TxPoolNoLocalsFlag,
 			utils.TxPoolJournalFlag,
 			utils.TxPoolRejournalFlag,
@@ -131,7 +140,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolProviderSlotsFlag,
+			utils.TxPoolContractSenderSlotsFlag,
+			utils.TxPoolGlobalContractSenderSlotsFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolScheduleHorizonFlag,
 		},
 	},
 	{
@@ -141,6 +154,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheDatabaseFlag,
 			utils.CacheTrieFlag,
 			utils.CacheGCFlag,
+			utils.CacheSnapshotFlag,
 			utils.CacheNoPrefetchFlag,
 		},
 	},
@@ -205,6 +219,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerfiyFlag,
+			utils.MinerMaxAccountTxsFlag,
 		},
 	},
 	{
@@ -257,6 +272,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TendermintTimeoutPrecommitFlag,
 			utils.TendermintTimeoutPrecommitDeltaFlag,
 			utils.TendermintTimeoutCommitFlag,
+			utils.TendermintBlockBuildRatioFlag,
 			utils.TendermintFaultyModeFlag,
 			utils.TendermintSCUseEVMCallerFlag,
 		},
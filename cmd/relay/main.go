@@ -0,0 +1,91 @@
+// relay is a gas sponsoring service for the providers of enterprise contracts.
+// Users POST their sender-signed transactions, which the relay checks against
+// the configured rules, co-signs with the provider key and submits.
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/keystore"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+var (
+	rpcFlag     = flag.String("rpc", "http://localhost:8545", "Evrynet node endpoint to submit the sponsored transactions to")
+	apiPortFlag = flag.Int("apiport", 8090, "Listener port for the HTTP API connection")
+
+	accJSONFlag = flag.String("account.json", "", "Key json file of the provider sponsoring user requests")
+	accPassFlag = flag.String("account.pass", "", "Decryption password to access the provider key")
+
+	rulesFlag   = flag.String("rules", "", "JSON file with the sponsored targets, methods, gas cap and rate limit")
+	rulesJSFlag = flag.String("rules.js", "", "Javascript file defining ApproveSponsorship to check every request with")
+
+	auditFlag   = flag.String("audit", "", "File to append the audit log of every request to")
+	metricsFlag = flag.Bool("metrics", false, "Serves the relay metrics on /metrics")
+	logFlag     = flag.Int("loglevel", 3, "Log level to use for the relay")
+)
+
+func main() {
+	// Parse the flags and set up the logger to print everything requested
+	flag.Parse()
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(*logFlag), log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+
+	// Load the sponsorship rules before touching any keys
+	rules, err := loadRules(*rulesFlag, *rulesJSFlag)
+	if err != nil {
+		log.Crit("Failed to load sponsorship rules", "err", err)
+	}
+	// Load up the provider key and decrypt its password
+	blob, err := ioutil.ReadFile(*accPassFlag)
+	if err != nil {
+		log.Crit("Failed to read account password contents", "file", *accPassFlag, "err", err)
+	}
+	// Delete trailing newline in password
+	pass := strings.TrimSuffix(string(blob), "\n")
+
+	ks := keystore.NewKeyStore(filepath.Join(os.Getenv("HOME"), ".relay", "keys"), keystore.StandardScryptN, keystore.StandardScryptP)
+	if blob, err = ioutil.ReadFile(*accJSONFlag); err != nil {
+		log.Crit("Failed to read account key contents", "file", *accJSONFlag, "err", err)
+	}
+	acc, err := ks.Import(blob, pass, pass)
+	if err != nil {
+		log.Crit("Failed to import provider account", "err", err)
+	}
+	if err := ks.Unlock(acc, pass); err != nil {
+		log.Crit("Failed to unlock provider account", "err", err)
+	}
+	// Connect to the node the sponsored transactions are submitted to
+	client, err := evrclient.Dial(*rpcFlag)
+	if err != nil {
+		log.Crit("Failed to connect to Evrynet node", "endpoint", *rpcFlag, "err", err)
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		log.Crit("Failed to retrieve the chain id", "err", err)
+	}
+	// Open the audit log, appending to any previous runs
+	var audit io.Writer
+	if *auditFlag != "" {
+		file, err := os.OpenFile(*auditFlag, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			log.Crit("Failed to open audit log", "file", *auditFlag, "err", err)
+		}
+		defer file.Close()
+		audit = file
+	}
+	log.Info("Starting sponsorship relay", "provider", acc.Address, "chainid", chainID, "port", *apiPortFlag)
+
+	relay := newRelay(client, ks, acc, chainID, rules, audit)
+	if err := relay.listenAndServe(*apiPortFlag, *metricsFlag); err != nil {
+		log.Crit("Failed to launch relay API", "err", err)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+	"github.com/Evrynetlabs/evrynet-node/metrics/prometheus"
+)
+
+// maxRequestSize is the maximum size of a relay request body.
+const maxRequestSize = 128 * 1024
+
+var (
+	errAlreadySponsored = errors.New("transaction is already co-signed by a provider")
+	errNotProvider      = errors.New("relay account is not a provider of the target contract")
+)
+
+var (
+	requestCounter   = metrics.NewRegisteredCounterForced("relay/requests", nil)
+	sponsoredCounter = metrics.NewRegisteredCounterForced("relay/sponsored", nil)
+	rejectedCounter  = metrics.NewRegisteredCounterForced("relay/rejected", nil)
+	failedCounter    = metrics.NewRegisteredCounterForced("relay/failed", nil)
+	sponsoredGas     = metrics.NewRegisteredCounterForced("relay/gas", nil)
+)
+
+// backend is the part of the Evrynet client the relay needs.
+type backend interface {
+	ProvidersAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]common.Address, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// providerSigner co-signs transactions with the provider key.
+type providerSigner interface {
+	ProviderSignTx(a accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// Statuses of the relay requests in the audit log.
+const (
+	statusSponsored = "sponsored"
+	statusRejected  = "rejected"
+	statusFailed    = "failed"
+)
+
+// auditEntry is a single line of the relay audit log.
+type auditEntry struct {
+	Time   time.Time       `json:"time"`
+	Hash   *common.Hash    `json:"hash,omitempty"`
+	From   *common.Address `json:"from,omitempty"`
+	To     *common.Address `json:"to,omitempty"`
+	Gas    uint64          `json:"gas,omitempty"`
+	Status string          `json:"status"`
+	Reason string          `json:"reason,omitempty"`
+}
+
+// relay co-signs sender-signed transactions with the provider key and submits
+// them, paying the gas of the users of the sponsored enterprise contracts.
+type relay struct {
+	client  backend          // Client connection to the Evrynet chain
+	keys    providerSigner   // Keystore containing the provider key
+	account accounts.Account // Provider account sponsoring user transactions
+	signer  types.Signer     // Signer to verify and co-sign transactions with
+	chainID *big.Int         // Chain id to co-sign transactions with
+	rules   *rules           // Rules deciding which transactions are sponsored
+
+	audit     io.Writer  // Audit log of every relay request
+	auditLock sync.Mutex // Lock serialising the audit log writes
+
+	lock sync.Mutex // Lock serialising the rule checks and submissions
+}
+
+func newRelay(client backend, keys providerSigner, account accounts.Account, chainID *big.Int, rules *rules, audit io.Writer) *relay {
+	if audit == nil {
+		audit = ioutil.Discard
+	}
+	return &relay{
+		client:  client,
+		keys:    keys,
+		account: account,
+		signer:  types.NewEIP155Signer(chainID),
+		chainID: chainID,
+		rules:   rules,
+		audit:   audit,
+	}
+}
+
+// listenAndServe registers the HTTP handlers of the relay and boots it up for
+// serving sponsorship requests.
+func (r *relay) listenAndServe(port int, withMetrics bool) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/relay", r.relayHandler)
+	if withMetrics {
+		mux.Handle("/metrics", prometheus.Handler(metrics.DefaultRegistry))
+	}
+	return http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+}
+
+// relayHandler handles sponsorship requests, which are POSTed JSON objects
+// carrying the binary encoded sender-signed transaction as {"tx": "0x..."}.
+func (r *relay) relayHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	requestCounter.Inc(1)
+
+	var msg struct {
+		Tx hexutil.Bytes `json:"tx"`
+	}
+	tx := new(types.Transaction)
+	err := json.NewDecoder(io.LimitReader(req.Body, maxRequestSize)).Decode(&msg)
+	if err == nil {
+		err = tx.UnmarshalBinary(msg.Tx)
+	}
+	if err != nil {
+		r.log(&auditEntry{Status: statusRejected, Reason: err.Error()})
+		rejectedCounter.Inc(1)
+		reply(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	signed, entry := r.sponsor(req.Context(), tx)
+	r.log(entry)
+
+	switch entry.Status {
+	case statusSponsored:
+		sponsoredCounter.Inc(1)
+		sponsoredGas.Inc(int64(signed.Gas()))
+		reply(w, http.StatusOK, map[string]string{"hash": signed.Hash().Hex()})
+	case statusRejected:
+		rejectedCounter.Inc(1)
+		reply(w, http.StatusForbidden, map[string]string{"error": entry.Reason})
+	default:
+		failedCounter.Inc(1)
+		reply(w, http.StatusBadGateway, map[string]string{"error": entry.Reason})
+	}
+}
+
+// sponsor checks the transaction against the rules, co-signs it with the
+// provider key and submits it, returning the co-signed transaction and the
+// audit log entry of the request.
+func (r *relay) sponsor(ctx context.Context, tx *types.Transaction) (*types.Transaction, *auditEntry) {
+	hash := tx.Hash()
+	entry := &auditEntry{Hash: &hash, To: tx.To(), Gas: tx.Gas()}
+
+	reject := func(status string, err error) (*types.Transaction, *auditEntry) {
+		entry.Status, entry.Reason = status, err.Error()
+		return nil, entry
+	}
+	from, err := types.Sender(r.signer, tx)
+	if err != nil {
+		return reject(statusRejected, err)
+	}
+	entry.From = &from
+
+	// The V value of typed transactions is zero for half of the signatures
+	if _, pr, ps := tx.RawProviderSignatureValues(); (pr != nil && pr.Sign() != 0) || (ps != nil && ps.Sign() != 0) {
+		return reject(statusRejected, errAlreadySponsored)
+	}
+	req := &sponsorRequest{
+		Hash:     hash,
+		From:     from,
+		To:       tx.To(),
+		Data:     tx.Data(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+	}
+	if len(req.Data) >= 4 {
+		req.Method = req.Data[:4]
+	}
+	// Serialise the requests so the rate limits can not be raced
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.rules.check(req); err != nil {
+		return reject(statusRejected, err)
+	}
+	providers, err := r.client.ProvidersAt(ctx, *tx.To(), nil)
+	if err != nil {
+		return reject(statusFailed, err)
+	}
+	if !containsAddress(providers, r.account.Address) {
+		return reject(statusRejected, errNotProvider)
+	}
+	signed, err := r.keys.ProviderSignTx(r.account, tx, r.chainID)
+	if err != nil {
+		return reject(statusFailed, err)
+	}
+	if err := r.client.SendTransaction(ctx, signed); err != nil {
+		return reject(statusFailed, err)
+	}
+	r.rules.record(from)
+
+	entry.Status = statusSponsored
+	return signed, entry
+}
+
+// log writes an entry into the audit log.
+func (r *relay) log(entry *auditEntry) {
+	entry.Time = time.Now()
+
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		log.Error("Failed to encode audit entry", "err", err)
+		return
+	}
+	r.auditLock.Lock()
+	defer r.auditLock.Unlock()
+
+	if _, err := r.audit.Write(append(blob, '\n')); err != nil {
+		log.Error("Failed to write audit entry", "err", err)
+	}
+	switch entry.Status {
+	case statusSponsored:
+		log.Info("Sponsored transaction", "hash", entry.Hash, "from", entry.From, "to", entry.To, "gas", entry.Gas)
+	default:
+		log.Warn("Refused to sponsor transaction", "status", entry.Status, "hash", entry.Hash, "from", entry.From, "reason", entry.Reason)
+	}
+}
+
+// reply writes a JSON response to the client.
+func reply(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Warn("Failed to send relay response", "err", err)
+	}
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
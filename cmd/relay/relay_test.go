@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+type testBackend struct {
+	providers map[common.Address][]common.Address
+	sent      []*types.Transaction
+}
+
+func (b *testBackend) ProvidersAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]common.Address, error) {
+	return b.providers[account], nil
+}
+
+func (b *testBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+type testKeys struct {
+	key *ecdsa.PrivateKey
+}
+
+func (k *testKeys) ProviderSignTx(a accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.ProviderSignTx(tx, types.NewEIP155Signer(chainID), k.key)
+}
+
+func TestRules(t *testing.T) {
+	var (
+		target = common.HexToAddress("0x1000")
+		other  = common.HexToAddress("0x2000")
+		from   = common.HexToAddress("0x3000")
+		method = hexutil.Bytes{0xa9, 0x05, 0x9c, 0xbb}
+	)
+	r, err := newRules(&ruleConfig{
+		Targets: map[common.Address][]hexutil.Bytes{target: {method}, other: nil},
+		GasCap:  100000,
+		Rate:    2,
+		Period:  "1h",
+	}, `function ApproveSponsorship(req) { return req.value == "0x0" ? "Approve" : "Reject" }`)
+	if err != nil {
+		t.Fatalf("failed to create rules: %v", err)
+	}
+	tests := []struct {
+		req *sponsorRequest
+		err error
+	}{
+		{&sponsorRequest{From: from}, errContractCreation},
+		{&sponsorRequest{From: from, To: &from}, errTargetNotAllowed},
+		{&sponsorRequest{From: from, To: &target, Method: hexutil.Bytes{1, 2, 3, 4}}, errMethodNotAllowed},
+		{&sponsorRequest{From: from, To: &target, Method: method, Gas: 100001}, errGasCapExceeded},
+		{&sponsorRequest{From: from, To: &other, Value: (*hexutil.Big)(big.NewInt(1))}, errRejectedByScript},
+		{&sponsorRequest{From: from, To: &other, Value: (*hexutil.Big)(new(big.Int))}, nil},
+		{&sponsorRequest{From: from, To: &target, Method: method, Gas: 100000, Value: (*hexutil.Big)(new(big.Int))}, nil},
+	}
+	for i, tt := range tests {
+		if err := r.check(tt.req); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Exhaust the rate limit of the sender, other senders are unaffected
+	req := &sponsorRequest{From: from, To: &other, Value: (*hexutil.Big)(new(big.Int))}
+	r.record(from)
+	r.record(from)
+	if err := r.check(req); err != errRateExceeded {
+		t.Fatalf("rate limit error mismatch: have %v, want %v", err, errRateExceeded)
+	}
+	req.From = other
+	if err := r.check(req); err != nil {
+		t.Fatalf("unexpected error for another sender: %v", err)
+	}
+	// Expire the history, the sender may be sponsored again
+	r.history[from] = []time.Time{time.Now().Add(-2 * time.Hour), time.Now().Add(-time.Hour)}
+	req.From = from
+	if err := r.check(req); err != nil {
+		t.Fatalf("unexpected error after the period: %v", err)
+	}
+	if _, err := newRules(nil, "function ApproveSponsorship("); err == nil {
+		t.Fatal("expected error for invalid javascript rules")
+	}
+}
+
+func TestRelaySponsor(t *testing.T) {
+	var (
+		providerKey, _ = crypto.GenerateKey()
+		senderKey, _   = crypto.GenerateKey()
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		sender         = crypto.PubkeyToAddress(senderKey.PublicKey)
+		chainID        = big.NewInt(15)
+		signer         = types.NewEIP155Signer(chainID)
+		sponsored      = common.HexToAddress("0x1000")
+		unsponsored    = common.HexToAddress("0x2000")
+	)
+	rules, err := newRules(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &testBackend{providers: map[common.Address][]common.Address{sponsored: {provider}}}
+	audit := new(bytes.Buffer)
+	relay := newRelay(backend, &testKeys{providerKey}, accounts.Account{Address: provider}, chainID, rules, audit)
+
+	// Transactions to contracts the relay account is not a provider of are rejected
+	tx, _ := types.SignTx(types.NewTransaction(0, unsponsored, new(big.Int), 50000, big.NewInt(1), nil), signer, senderKey)
+	if _, entry := relay.sponsor(context.Background(), tx); entry.Status != statusRejected || entry.Reason != errNotProvider.Error() {
+		t.Fatalf("unsponsored target: have %s (%s), want rejected", entry.Status, entry.Reason)
+	}
+	tx, _ = types.SignTx(types.NewTransaction(0, sponsored, new(big.Int), 50000, big.NewInt(1), nil), signer, senderKey)
+	signed, entry := relay.sponsor(context.Background(), tx)
+	if entry.Status != statusSponsored {
+		t.Fatalf("sponsored target: have %s (%s), want sponsored", entry.Status, entry.Reason)
+	}
+	if have, err := types.Provider(signer, signed); err != nil || have == nil || *have != provider {
+		t.Fatalf("provider mismatch: have %v (%v), want %x", have, err, provider)
+	}
+	if from, _ := types.Sender(signer, signed); from != sender {
+		t.Fatalf("sender mismatch: have %x, want %x", from, sender)
+	}
+	if len(backend.sent) != 1 || backend.sent[0] != signed {
+		t.Fatalf("co-signed transaction not submitted")
+	}
+	// Transactions already co-signed are not signed again
+	if _, entry := relay.sponsor(context.Background(), signed); entry.Status != statusRejected || entry.Reason != errAlreadySponsored.Error() {
+		t.Fatalf("co-signed transaction: have %s (%s), want rejected", entry.Status, entry.Reason)
+	}
+	relay.log(entry)
+	if !bytes.Contains(audit.Bytes(), []byte(`"status":"sponsored"`)) {
+		t.Fatalf("audit log missing sponsored entry: %s", audit)
+	}
+	// Typed transactions are detected as co-signed whatever their V value
+	for nonce := uint64(1); ; nonce++ {
+		tx, _ := types.SignTx(types.NewSponsoredTransaction(chainID, nonce, sponsored, provider, new(big.Int), 50000, big.NewInt(1), nil), signer, senderKey)
+		signed, entry := relay.sponsor(context.Background(), tx)
+		if entry.Status != statusSponsored {
+			t.Fatalf("typed transaction: have %s (%s), want sponsored", entry.Status, entry.Reason)
+		}
+		if v, _, _ := signed.RawProviderSignatureValues(); v.Sign() != 0 {
+			continue
+		}
+		if _, entry := relay.sponsor(context.Background(), signed); entry.Status != statusRejected || entry.Reason != errAlreadySponsored.Error() {
+			t.Fatalf("co-signed typed transaction: have %s (%s), want rejected", entry.Status, entry.Reason)
+		}
+		break
+	}
+}
+
+// Tests that the relay decodes the canonical encoding of typed transactions.
+func TestRelayHandlerTyped(t *testing.T) {
+	var (
+		providerKey, _ = crypto.GenerateKey()
+		senderKey, _   = crypto.GenerateKey()
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		chainID        = big.NewInt(15)
+		sponsored      = common.HexToAddress("0x1000")
+	)
+	rules, err := newRules(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &testBackend{providers: map[common.Address][]common.Address{sponsored: {provider}}}
+	relay := newRelay(backend, &testKeys{providerKey}, accounts.Account{Address: provider}, chainID, rules, new(bytes.Buffer))
+
+	tx, _ := types.SignTx(types.NewSponsoredTransaction(chainID, 0, sponsored, provider, new(big.Int), 50000, big.NewInt(1), nil), types.NewEIP155Signer(chainID), senderKey)
+	blob, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(map[string]hexutil.Bytes{"tx": blob})
+	rec := httptest.NewRecorder()
+	relay.relayHandler(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status mismatch: have %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if len(backend.sent) != 1 || backend.sent[0].Type() != types.SponsoredTxType {
+		t.Fatalf("typed transaction not submitted")
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/robertkrimen/otto"
+)
+
+var (
+	errContractCreation = errors.New("contract creation is not sponsored")
+	errTargetNotAllowed = errors.New("target contract is not sponsored")
+	errMethodNotAllowed = errors.New("method is not sponsored")
+	errGasCapExceeded   = errors.New("gas limit exceeds the sponsored cap")
+	errRateExceeded     = errors.New("too many sponsored transactions, try again later")
+	errRejectedByScript = errors.New("rejected by sponsorship rules")
+)
+
+// ruleConfig is the JSON configuration of the built-in sponsorship rules.
+type ruleConfig struct {
+	// Targets maps every sponsored contract to its sponsored method selectors,
+	// an empty list sponsors every method of the contract.
+	Targets map[common.Address][]hexutil.Bytes `json:"targets"`
+	GasCap  uint64                             `json:"gasCap"` // Maximum gas limit of a sponsored transaction, 0 for no cap
+	Rate    int                                `json:"rate"`   // Maximum sponsored transactions per sender and period, 0 for no limit
+	Period  string                             `json:"period"` // Rate limiting period, e.g. "1h"
+}
+
+// sponsorRequest is the view of a transaction the sponsorship rules decide on.
+type sponsorRequest struct {
+	Hash     common.Hash     `json:"hash"`
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Method   hexutil.Bytes   `json:"method"`
+	Data     hexutil.Bytes   `json:"data"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+}
+
+// rules decides which transactions the relay co-signs. Requests are checked
+// against the built-in rules first and against the javascript rules, if any,
+// afterwards. The javascript rules must define a function ApproveSponsorship
+// taking the request and returning either "Approve" or "Reject".
+type rules struct {
+	targets map[common.Address]map[string]struct{} // Sponsored contracts and methods, nil sponsors everything
+	gasCap  uint64                                 // Maximum gas limit of a sponsored transaction
+	rate    int                                    // Maximum sponsored transactions per sender and period
+	period  time.Duration                          // Rate limiting period
+	jsRules string                                 // Javascript rules to evaluate for every request
+
+	history map[common.Address][]time.Time // Recently sponsored transactions of every sender
+	lock    sync.Mutex                     // Lock protecting the rate limiting history
+}
+
+// newRules creates the rule set from the given configuration and javascript rules.
+func newRules(config *ruleConfig, jsRules string) (*rules, error) {
+	r := &rules{
+		jsRules: jsRules,
+		history: make(map[common.Address][]time.Time),
+	}
+	if jsRules != "" {
+		// Fail early on scripts which can not even be parsed
+		if _, err := otto.New().Compile("rules.js", jsRules); err != nil {
+			return nil, fmt.Errorf("invalid javascript rules: %v", err)
+		}
+	}
+	if config == nil {
+		return r, nil
+	}
+	if config.Targets != nil {
+		r.targets = make(map[common.Address]map[string]struct{})
+		for target, methods := range config.Targets {
+			r.targets[target] = make(map[string]struct{})
+			for _, method := range methods {
+				if len(method) != 4 {
+					return nil, fmt.Errorf("invalid method selector %s of target %s", method, target.Hex())
+				}
+				r.targets[target][string(method)] = struct{}{}
+			}
+		}
+	}
+	r.gasCap, r.rate = config.GasCap, config.Rate
+	if r.rate > 0 {
+		period, err := time.ParseDuration(config.Period)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limiting period: %v", err)
+		}
+		if period <= 0 {
+			return nil, errors.New("rate limiting period must be positive")
+		}
+		r.period = period
+	}
+	return r, nil
+}
+
+// loadRules creates the rule set from the given configuration and javascript
+// files, either of which may be empty.
+func loadRules(configFile, jsFile string) (*rules, error) {
+	var config *ruleConfig
+	if configFile != "" {
+		blob, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		config = new(ruleConfig)
+		if err := json.Unmarshal(blob, config); err != nil {
+			return nil, fmt.Errorf("invalid rules configuration: %v", err)
+		}
+	}
+	var jsRules string
+	if jsFile != "" {
+		blob, err := ioutil.ReadFile(jsFile)
+		if err != nil {
+			return nil, err
+		}
+		jsRules = string(blob)
+	}
+	return newRules(config, jsRules)
+}
+
+// check returns an error if the request must not be sponsored.
+func (r *rules) check(req *sponsorRequest) error {
+	if req.To == nil {
+		return errContractCreation
+	}
+	if r.targets != nil {
+		methods, ok := r.targets[*req.To]
+		if !ok {
+			return errTargetNotAllowed
+		}
+		if len(methods) > 0 {
+			if _, ok := methods[string(req.Method)]; !ok {
+				return errMethodNotAllowed
+			}
+		}
+	}
+	if r.gasCap > 0 && uint64(req.Gas) > r.gasCap {
+		return errGasCapExceeded
+	}
+	if r.rate > 0 {
+		r.lock.Lock()
+		recent := r.recent(req.From, time.Now())
+		r.lock.Unlock()
+
+		if recent >= r.rate {
+			return errRateExceeded
+		}
+	}
+	if r.jsRules != "" {
+		return r.approve(req)
+	}
+	return nil
+}
+
+// record adds a sponsored transaction of from to the rate limiting history.
+func (r *rules) record(from common.Address) {
+	if r.rate == 0 {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	r.recent(from, now)
+	r.history[from] = append(r.history[from], now)
+}
+
+// recent drops the expired history of from and returns the number of transactions
+// sponsored within the current period. The caller must hold the lock.
+func (r *rules) recent(from common.Address, now time.Time) int {
+	history := r.history[from]
+	for len(history) > 0 && now.Sub(history[0]) >= r.period {
+		history = history[1:]
+	}
+	if len(history) == 0 {
+		delete(r.history, from)
+	} else {
+		r.history[from] = history
+	}
+	return len(history)
+}
+
+// approve evaluates the javascript rules for the request in a fresh vm.
+func (r *rules) approve(req *sponsorRequest) error {
+	vm := otto.New()
+	if _, err := vm.Run(r.jsRules); err != nil {
+		return fmt.Errorf("javascript rules failed: %v", err)
+	}
+	// Pass the request as JSON to insulate the rules from the go types
+	blob, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	v, err := vm.Run(fmt.Sprintf("ApproveSponsorship(JSON.parse(%q))", string(blob)))
+	if err != nil {
+		return fmt.Errorf("javascript rules failed: %v", err)
+	}
+	result, err := v.ToString()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case "Approve":
+		return nil
+	case "Reject":
+		return errRejectedByScript
+	}
+	return fmt.Errorf("unknown javascript rules response %q", result)
+}
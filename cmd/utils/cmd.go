@@ -18,22 +18,33 @@
 package utils
 
 import (
+	"bufio"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintUtils "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/pruner"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/evrdb"
 	"github.com/Evrynetlabs/evrynet-node/internal/debug"
+	"github.com/Evrynetlabs/evrynet-node/internal/era"
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/node"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
@@ -312,3 +323,399 @@ func ExportPreimages(db evrdb.Database, fn string) error {
 	log.Info("Exported preimages", "file", fn)
 	return nil
 }
+
+// stateSnapshotVersion is the version of the state snapshot format.
+const stateSnapshotVersion = 1
+
+// stateSnapshotHeader is the first item of a state snapshot, followed by the state
+// chunks of its block.
+type stateSnapshotHeader struct {
+	Version uint64
+	Block   *types.Block
+	TD      *big.Int
+	Epoch   *types.Header `rlp:"nil"` // Epoch header holding the validators of the block on Tendermint chains
+}
+
+// ExportSnapshot exports the state of a block into the specified file, truncating
+// any data already present in the file. On Tendermint chains with staking validators
+// the block must be an epoch block, see ImportSnapshot.
+func ExportSnapshot(blockchain *core.BlockChain, fn string, number uint64) error {
+	block := blockchain.GetBlockByNumber(number)
+	if block == nil {
+		return fmt.Errorf("block #%d not found", number)
+	}
+	if !blockchain.HasState(block.Root()) {
+		return fmt.Errorf("state of block #%d not available", number)
+	}
+	header := &stateSnapshotHeader{
+		Version: stateSnapshotVersion,
+		Block:   block,
+		TD:      blockchain.GetTd(block.Hash(), number),
+	}
+	if config := blockchain.Config().Tendermint; config != nil && number > 0 {
+		if len(config.FixedValidators) == 0 && number%config.Epoch != 0 {
+			return fmt.Errorf("block #%d is not an epoch block", number)
+		}
+		header.Epoch = blockchain.GetHeaderByNumber(tendermintUtils.GetCheckpointNumber(config.Epoch, number))
+	}
+	log.Info("Exporting state snapshot", "file", fn, "number", number, "hash", block.Hash(), "root", block.Root())
+
+	// Open the file handle and potentially wrap with a gzip stream
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	if err := rlp.Encode(writer, header); err != nil {
+		return err
+	}
+	if err := state.ExportState(blockchain.StateCache(), block.Root(), writer); err != nil {
+		return err
+	}
+	log.Info("Exported state snapshot", "file", fn)
+	return nil
+}
+
+// PruneState deletes the stale state from the database of a stopped node. The
+// states of the blocks committed at shutdown and the state snapshot base are
+// kept, along with the last two epoch checkpoint states on Tendermint chains,
+// as the rewards of an epoch are computed from the state of the previous one.
+func PruneState(blockchain *core.BlockChain, db evrdb.Database, bloomSize uint64) error {
+	head := blockchain.CurrentBlock()
+	if !blockchain.HasState(head.Root()) {
+		return fmt.Errorf("state of head block #%d not available", head.NumberU64())
+	}
+	number := head.NumberU64()
+	numbers := []uint64{number}
+	for _, offset := range []uint64{1, core.TriesInMemory - 1} {
+		if number >= offset {
+			numbers = append(numbers, number-offset)
+		}
+	}
+	if config := blockchain.Config().Tendermint; config != nil && config.Epoch > 0 {
+		last := number - number%config.Epoch
+		numbers = append(numbers, last)
+		if last >= config.Epoch {
+			numbers = append(numbers, last-config.Epoch)
+		}
+	}
+	var roots []common.Hash
+	for _, n := range numbers {
+		header := blockchain.GetHeaderByNumber(n)
+		if header == nil || !blockchain.HasState(header.Root) {
+			log.Warn("State not available, skipping", "number", n)
+			continue
+		}
+		roots = append(roots, header.Root)
+	}
+	if root := rawdb.ReadSnapshotRoot(db); root != (common.Hash{}) && blockchain.HasState(root) {
+		roots = append(roots, root)
+	}
+	log.Info("Pruning state", "head", number, "hash", head.Hash(), "retained", len(roots))
+	if err := pruner.New(db, blockchain.StateCache(), bloomSize).Prune(roots, nil); err != nil {
+		return err
+	}
+	log.Info("Compacting database")
+	return db.Compact(nil, nil)
+}
+
+// ImportSnapshot rebuilds the state of a block from a snapshot file into an empty
+// database, and makes the block the head of the chain so that syncing resumes
+// from it. The block must either have the trusted hash, or on Tendermint chains be
+// committed by trusted validators: the fixed ones, or those of an epoch header with
+// the trusted hash or already known locally. Its state is checked to hash to its root.
+//
+// On Tendermint chains with staking validators the block must be an epoch block, as
+// the rewards of the next epoch block are computed from the headers of the epoch and
+// the state of its first block, which the snapshot holds no other way.
+func ImportSnapshot(db evrdb.Database, fn string, trusted common.Hash) (*types.Block, error) {
+	log.Info("Importing state snapshot", "file", fn)
+
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return nil, errors.New("database not initialized with a genesis block")
+	}
+	if head := rawdb.ReadHeadBlockHash(db); head != genesis {
+		return nil, errors.New("database already contains blocks")
+	}
+	config := rawdb.ReadChainConfig(db, genesis)
+	if config == nil {
+		return nil, errors.New("chain config not found")
+	}
+	// Open the file handle and potentially unwrap the gzip stream
+	fh, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = fh
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return nil, err
+		}
+	}
+	stream := rlp.NewStream(reader, 0)
+
+	var header stateSnapshotHeader
+	if err := stream.Decode(&header); err != nil {
+		return nil, err
+	}
+	if header.Version != stateSnapshotVersion {
+		return nil, fmt.Errorf("unsupported state snapshot version %d", header.Version)
+	}
+	block := header.Block
+	if types.DeriveSha(block.Transactions()) != block.TxHash() || types.CalcUncleHash(block.Uncles()) != block.UncleHash() {
+		return nil, errors.New("state snapshot block body mismatch")
+	}
+	if config.Tendermint == nil && block.Hash() != trusted {
+		return nil, fmt.Errorf("state snapshot block %x is not the trusted one", block.Hash())
+	}
+	if config.Tendermint != nil {
+		epoch := header.Epoch
+		if epoch == nil || epoch.Number.Uint64() != tendermintUtils.GetCheckpointNumber(config.Tendermint.Epoch, block.NumberU64()) {
+			return nil, errors.New("state snapshot without epoch header")
+		}
+		validators := config.Tendermint.FixedValidators
+		if len(validators) == 0 && block.NumberU64()%config.Tendermint.Epoch != 0 {
+			return nil, fmt.Errorf("state snapshot block #%d is not an epoch block", block.NumberU64())
+		}
+		if len(validators) == 0 {
+			// The validators come from the snapshot, which is only trusted if it
+			// leads to a trusted hash or to an epoch header known locally
+			known := rawdb.ReadCanonicalHash(db, epoch.Number.Uint64()) == epoch.Hash()
+			if block.Hash() != trusted && epoch.Hash() != trusted && !known {
+				return nil, fmt.Errorf("state snapshot block %x and epoch header %x are not trusted", block.Hash(), epoch.Hash())
+			}
+			if validators, err = tendermintUtils.GetValSetAddresses(epoch); err != nil {
+				return nil, err
+			}
+		}
+		valSet := validator.NewSet(validators, tendermint.ProposerPolicy(config.Tendermint.ProposerPolicy), block.Number().Int64())
+		if err := tendermintUtils.VerifyCommittedSeals(block.Header(), valSet); err != nil {
+			return nil, fmt.Errorf("state snapshot block not committed: %v", err)
+		}
+	}
+	if err := state.ImportState(state.NewDatabase(db), block.Root(), stream); err != nil {
+		return nil, err
+	}
+	// Make the block the head of the chain, along with the epoch header the
+	// consensus needs to verify the next blocks
+	batch := db.NewBatch()
+	if epoch := header.Epoch; epoch != nil && epoch.Number.Uint64() > 0 {
+		rawdb.WriteHeader(batch, epoch)
+		rawdb.WriteCanonicalHash(batch, epoch.Hash(), epoch.Number.Uint64())
+	}
+	rawdb.WriteBlock(batch, block)
+	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), nil)
+	rawdb.WriteTd(batch, block.Hash(), block.NumberU64(), header.TD)
+	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+	rawdb.WriteHeadHeaderHash(batch, block.Hash())
+	rawdb.WriteHeadFastBlockHash(batch, block.Hash())
+	rawdb.WriteHeadBlockHash(batch, block.Hash())
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	log.Info("Imported state snapshot", "number", block.Number(), "hash", block.Hash(), "root", block.Root())
+	return block, nil
+}
+
+// ExportHistory exports the blocks in the given range into archives in the given
+// directory, one per era of era.MaxEraBlocks blocks, named after the network. The
+// bodies and receipts of the blocks must not have been expired yet.
+func ExportHistory(db evrdb.Reader, dir string, network string, first uint64, last uint64) error {
+	if first > last {
+		return fmt.Errorf("invalid range: first block %d after last block %d", first, last)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	log.Info("Exporting history", "dir", dir, "first", first, "last", last)
+
+	start := time.Now()
+	for number := first; number <= last; {
+		end := number - number%era.MaxEraBlocks + era.MaxEraBlocks - 1
+		if end > last {
+			end = last
+		}
+		fn, err := exportEra(db, dir, network, number, end)
+		if err != nil {
+			return err
+		}
+		log.Info("Exported history archive", "file", fn, "first", number, "last", end, "elapsed", common.PrettyDuration(time.Since(start)))
+		number = end + 1
+	}
+	return nil
+}
+
+// exportEra writes the blocks in the given range of a single era into an archive
+// in the given directory, returning its name.
+func exportEra(db evrdb.Reader, dir string, network string, first uint64, last uint64) (string, error) {
+	// Write into a temporary file, as the name depends on the last block hash
+	tmp := filepath.Join(dir, fmt.Sprintf("%s-%05d.era.tmp", network, first/era.MaxEraBlocks))
+	fh, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+	defer fh.Close()
+
+	var (
+		writer  = bufio.NewWriter(fh)
+		builder = era.NewBuilder(writer)
+		hash    common.Hash
+	)
+	for number := first; number <= last; number++ {
+		if hash = rawdb.ReadCanonicalHash(db, number); hash == (common.Hash{}) {
+			return "", fmt.Errorf("block #%d not found", number)
+		}
+		block := &era.Block{
+			Number:   number,
+			Header:   rawdb.ReadHeaderRLP(db, hash, number),
+			Body:     rawdb.ReadBodyRLP(db, hash, number),
+			Receipts: rawdb.ReadReceiptsRLP(db, hash, number),
+			Td:       rawdb.ReadTdRLP(db, hash, number),
+		}
+		if len(block.Header) == 0 || len(block.Td) == 0 {
+			return "", fmt.Errorf("header of block #%d not found", number)
+		}
+		if len(block.Body) == 0 || len(block.Receipts) == 0 {
+			return "", fmt.Errorf("history of block #%d expired", number)
+		}
+		if err := builder.Add(block); err != nil {
+			return "", err
+		}
+	}
+	if err := builder.Finalize(); err != nil {
+		return "", err
+	}
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+	if err := fh.Close(); err != nil {
+		return "", err
+	}
+	fn := filepath.Join(dir, era.Filename(network, first/era.MaxEraBlocks, hash))
+	return fn, os.Rename(tmp, fn)
+}
+
+// ImportHistory imports the blocks of an archive. The bodies and receipts of the
+// blocks already in the canonical chain are restored, after being checked against
+// their headers, which keeps serving the history expired from the ancient store.
+// The blocks after the chain head are inserted into the chain.
+func ImportHistory(chain *core.BlockChain, db evrdb.Database, fn string) error {
+	// Watch for Ctrl-C while the import is running.
+	// If a signal is received, the import will stop at the next block.
+	interrupt := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during import, stopping at next block")
+		}
+		close(stop)
+	}()
+	checkInterrupt := func() bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return false
+		}
+	}
+
+	log.Info("Importing history", "file", fn)
+
+	archive, err := era.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	var (
+		head     = chain.CurrentBlock().NumberU64()
+		batch    = db.NewBatch()
+		blocks   types.Blocks
+		restored int
+		inserted int
+	)
+	for number := archive.Start(); number < archive.Start()+archive.Count(); number++ {
+		if checkInterrupt() {
+			return fmt.Errorf("interrupted")
+		}
+		raw, err := archive.Block(number)
+		if err != nil {
+			return err
+		}
+		header, body := new(types.Header), new(types.Body)
+		if err := rlp.DecodeBytes(raw.Header, header); err != nil {
+			return fmt.Errorf("block %d: invalid header: %v", number, err)
+		}
+		if err := rlp.DecodeBytes(raw.Body, body); err != nil {
+			return fmt.Errorf("block %d: invalid body: %v", number, err)
+		}
+		if header.Number.Uint64() != number {
+			return fmt.Errorf("block %d: header number mismatch: %d", number, header.Number)
+		}
+		if types.DeriveSha(types.Transactions(body.Transactions)) != header.TxHash || types.CalcUncleHash(body.Uncles) != header.UncleHash {
+			return fmt.Errorf("block %d: body mismatch", number)
+		}
+		if number > head {
+			blocks = append(blocks, types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles))
+			inserted++
+			if len(blocks) == importBatchSize {
+				if _, err := chain.InsertChain(blocks); err != nil {
+					return fmt.Errorf("invalid block %d: %v", blocks[0].NumberU64(), err)
+				}
+				blocks = blocks[:0]
+			}
+			continue
+		}
+		hash := header.Hash()
+		if rawdb.ReadCanonicalHash(db, number) != hash {
+			return fmt.Errorf("block %d: not in the canonical chain", number)
+		}
+		var stored []*types.ReceiptForStorage
+		if err := rlp.DecodeBytes(raw.Receipts, &stored); err != nil {
+			return fmt.Errorf("block %d: invalid receipts: %v", number, err)
+		}
+		receipts := make(types.Receipts, len(stored))
+		for i, receipt := range stored {
+			receipts[i] = (*types.Receipt)(receipt)
+		}
+		if types.DeriveSha(receipts) != header.ReceiptHash {
+			return fmt.Errorf("block %d: receipts mismatch", number)
+		}
+		if rawdb.HasBody(db, hash, number) && rawdb.HasReceipts(db, hash, number) {
+			continue
+		}
+		rawdb.WriteBodyRLP(batch, hash, number, raw.Body)
+		rawdb.WriteReceiptsRLP(batch, hash, number, raw.Receipts)
+		restored++
+
+		if batch.ValueSize() >= evrdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	if len(blocks) > 0 {
+		if _, err := chain.InsertChain(blocks); err != nil {
+			return fmt.Errorf("invalid block %d: %v", blocks[0].NumberU64(), err)
+		}
+	}
+	log.Info("Imported history", "file", fn, "restored", restored, "inserted", inserted)
+	return nil
+}
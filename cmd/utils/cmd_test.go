@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintBackend "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/backend"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	tendermintUtils "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestHistoryExportImport(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 20, func(i int, gen *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(params.GasPriceConfig), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ExportHistory(db, dir, "test", 1, 15); err != nil {
+		t.Fatalf("failed to export history: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.era"))
+	if len(files) != 1 {
+		t.Fatalf("archive count mismatch: have %d, want 1", len(files))
+	}
+	// Drop the history of some blocks, which the archive restores
+	for _, block := range blocks[4:10] {
+		rawdb.DeleteBody(db, block.Hash(), block.NumberU64())
+		rawdb.DeleteReceipts(db, block.Hash(), block.NumberU64())
+	}
+	if err := ExportHistory(db, dir, "test", 1, 15); err == nil {
+		t.Fatalf("expired history exported")
+	}
+	if err := ImportHistory(chain, db, files[0]); err != nil {
+		t.Fatalf("failed to import history: %v", err)
+	}
+	for _, block := range blocks[:15] {
+		hash, number := block.Hash(), block.NumberU64()
+		if body := rawdb.ReadBody(db, hash, number); body == nil || types.DeriveSha(types.Transactions(body.Transactions)) != block.TxHash() {
+			t.Errorf("block %d: body not restored", number)
+		}
+		if receipts := rawdb.ReadReceipts(db, hash, number, gspec.Config); types.DeriveSha(receipts) != block.ReceiptHash() {
+			t.Errorf("block %d: receipts not restored", number)
+		}
+	}
+}
+
+func TestSnapshotTrustedHash(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 4, func(i int, gen *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(params.GasPriceConfig), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "snapshot.rlp")
+	if err := ExportSnapshot(chain, fn, 4); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	// Snapshots not leading to the trusted hash are refused
+	for _, trusted := range []common.Hash{{}, blocks[2].Hash()} {
+		diskdb := rawdb.NewMemoryDatabase()
+		gspec.MustCommit(diskdb)
+		if _, err := ImportSnapshot(diskdb, fn, trusted); err == nil {
+			t.Errorf("snapshot imported with trusted hash %x", trusted)
+		}
+	}
+	diskdb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(diskdb)
+	block, err := ImportSnapshot(diskdb, fn, blocks[3].Hash())
+	if err != nil {
+		t.Fatalf("failed to import snapshot: %v", err)
+	}
+	if head := rawdb.ReadHeadBlockHash(diskdb); head != block.Hash() || head != blocks[3].Hash() {
+		t.Errorf("head mismatch: have %x, want %x", head, blocks[3].Hash())
+	}
+}
+
+// sealTendermintChain links generated blocks to their sealed parents, committing
+// them by all the given validators and writing them into the epoch blocks.
+func sealTendermintChain(t *testing.T, parent *types.Block, blocks []*types.Block, epoch uint64, keys []*ecdsa.PrivateKey) {
+	validators := make([]common.Address, len(keys))
+	for i, key := range keys {
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	hash := parent.Hash()
+	for i, block := range blocks {
+		header := block.Header()
+		header.ParentHash = hash
+		extra, err := tests_utils.PrepareExtra(header)
+		if err != nil {
+			t.Fatalf("failed to prepare extra: %v", err)
+		}
+		header.Extra = extra
+		if header.Number.Uint64()%epoch == 0 {
+			if err := tendermintUtils.WriteValSet(header, validators); err != nil {
+				t.Fatalf("failed to write validators: %v", err)
+			}
+		}
+		tests_utils.AppendSealByPkKey(header, keys[0])
+		tests_utils.AppendCommitedSealByPkKeys(header, keys)
+		hash = header.Hash()
+		blocks[i] = block.WithSeal(header)
+	}
+}
+
+func TestSnapshotEpochBlock(t *testing.T) {
+	data, err := ioutil.ReadFile("../../consensus/tendermint/tests/genesis_staking_sc.json")
+	if err != nil {
+		t.Fatalf("failed to read genesis: %v", err)
+	}
+	gspec := new(core.Genesis)
+	if err := json.Unmarshal(data, gspec); err != nil {
+		t.Fatalf("failed to decode genesis: %v", err)
+	}
+	var keys []*ecdsa.PrivateKey
+	for _, hex := range []string{
+		"ce900e4057ef7253ce737dccf3979ec4e74a19d595e8cc30c6c5ea92dfdd37f1",
+		"e74f3525fb69f193b51d33f4baf602c4572d81ede57907c61a62eaf9ed95374a",
+		"276cd299f350174a6005525a523b59fccd4c536771e4876164adb9f1459b79e4",
+	} {
+		key, _ := crypto.HexToECDSA(hex)
+		keys = append(keys, key)
+	}
+	var (
+		epoch  = gspec.Config.Tendermint.Epoch
+		config = *tendermint.DefaultConfig
+	)
+	config.Epoch = epoch
+	config.ProposerPolicy = tendermint.ProposerPolicy(gspec.Config.Tendermint.ProposerPolicy)
+	config.StakingSCAddress = gspec.Config.Tendermint.StakingSCAddress
+	engine := tendermintBackend.New(&config, keys[0])
+
+	// Build two epochs, the rewards of the second one need the first epoch block state
+	db := rawdb.NewMemoryDatabase()
+	genesis := gspec.MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil)
+	defer chain.Stop()
+
+	generate := func(i int, gen *core.BlockGen) { gen.SetCoinbase(crypto.PubkeyToAddress(keys[0].PublicKey)) }
+	first, _ := core.GenerateChain(gspec.Config, genesis, engine, db, int(epoch), generate)
+	sealTendermintChain(t, genesis, first, epoch, keys)
+	if _, err := chain.InsertChain(first); err != nil {
+		t.Fatalf("failed to insert first epoch: %v", err)
+	}
+	second, _ := core.GenerateChain(gspec.Config, first[epoch-1], engine, db, int(epoch), generate)
+	sealTendermintChain(t, first[epoch-1], second, epoch, keys)
+	if _, err := chain.InsertChain(second); err != nil {
+		t.Fatalf("failed to insert second epoch: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Snapshots taken mid-epoch are neither exported nor imported
+	fn := filepath.Join(dir, "snapshot.rlp")
+	if err := ExportSnapshot(chain, fn, epoch+5); err == nil {
+		t.Fatalf("mid-epoch snapshot exported")
+	}
+	middle := second[4]
+	fh, err := os.Create(fn)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	header := &stateSnapshotHeader{
+		Version: stateSnapshotVersion,
+		Block:   middle,
+		TD:      chain.GetTd(middle.Hash(), middle.NumberU64()),
+		Epoch:   first[epoch-1].Header(),
+	}
+	if err := rlp.Encode(fh, header); err != nil {
+		t.Fatalf("failed to write snapshot header: %v", err)
+	}
+	if err := state.ExportState(chain.StateCache(), middle.Root(), fh); err != nil {
+		t.Fatalf("failed to write snapshot state: %v", err)
+	}
+	fh.Close()
+
+	diskdb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(diskdb)
+	if _, err := ImportSnapshot(diskdb, fn, middle.Hash()); err == nil {
+		t.Fatalf("mid-epoch snapshot imported")
+	}
+	// A snapshot of the epoch block syncs across the next epoch block
+	if err := ExportSnapshot(chain, fn, epoch); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	if _, err := ImportSnapshot(diskdb, fn, first[epoch-1].Hash()); err != nil {
+		t.Fatalf("failed to import snapshot: %v", err)
+	}
+	imported, _ := core.NewBlockChain(diskdb, nil, gspec.Config, engine, vm.Config{}, nil)
+	defer imported.Stop()
+
+	if _, err := imported.InsertChain(second); err != nil {
+		t.Fatalf("failed to insert next epoch: %v", err)
+	}
+	if head := imported.CurrentBlock(); head.Hash() != second[epoch-1].Hash() || head.Root() != second[epoch-1].Root() {
+		t.Errorf("head mismatch: have #%d %x, want #%d %x", head.NumberU64(), head.Hash(), 2*epoch, second[epoch-1].Hash())
+	}
+}
@@ -125,6 +125,10 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: `Database engine of new databases ("leveldb" or "bolt", default = leveldb, existing databases keep theirs)`,
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -199,14 +203,40 @@ var (
 	defaultSyncMode = evr.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "checkpoint")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
 		Name:  "gcmode",
-		Usage: `Blockchain garbage collection mode ("full", "archive")`,
+		Usage: `Blockchain garbage collection mode ("full", "archive", "prune")`,
 		Value: "full",
 	}
+	HistoryRetentionFlag = cli.Uint64Flag{
+		Name:  "history.retention",
+		Usage: "Number of recent blocks to keep the bodies and receipts of, older ancient ones are expired (0 = keep all)",
+	}
+	TxLookupLimitFlag = cli.Uint64Flag{
+		Name:  "txlookup.limit",
+		Usage: "Number of recent blocks to keep the transaction index of, older ones are unindexed (0 = index all)",
+	}
+	StateReexecFlag = cli.Uint64Flag{
+		Name:  "state.reexec",
+		Usage: "Number of blocks to re-execute at most to regenerate a historical state missing from the database (0 = persisted states only)",
+		Value: evr.DefaultConfig.StateReexec,
+	}
+	LogIndexFlag = cli.BoolFlag{
+		Name:  "logindex",
+		Usage: "Maintain an index of the logs by address and topic, speeding up log filtering over long ranges",
+	}
+	AccountIndexFlag = cli.BoolFlag{
+		Name:  "accountindex",
+		Usage: "Maintain an index of the transactions each account is involved in, including internal transfers (requires --gcmode=archive)",
+	}
+	PruneRecentFlag = cli.IntFlag{
+		Name:  "prune.recent",
+		Usage: "Number of recently committed states kept on disk in prune mode, besides the epoch checkpoint states",
+		Value: 4,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (multi-threaded processing allows values over 100)",
@@ -235,6 +265,10 @@ var (
 		Name:  "whitelist",
 		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
 	}
+	SyncCheckpointFlag = cli.StringFlag{
+		Name:  "synccheckpoint",
+		Usage: "Trusted epoch header checkpoint sync starts from (<number>=<hash>, defaults to the genesis)",
+	}
 	// Dashboard settings
 	DashboardEnabledFlag = cli.BoolFlag{
 		Name:  "dashboard",
@@ -290,6 +324,10 @@ var (
 		Name:  "txpool.locals",
 		Usage: "Comma separated accounts to treat as locals (no flush, priority inclusion)",
 	}
+	TxPoolPriorityFlag = cli.StringFlag{
+		Name:  "txpool.priority",
+		Usage: "Comma separated accounts exempt from fair share eviction and mined before remotes",
+	}
 	TxPoolNoLocalsFlag = cli.BoolFlag{
 		Name:  "txpool.nolocals",
 		Usage: "Disables price exemptions for locally submitted transactions",
@@ -334,11 +372,31 @@ var (
 		Usage: "Maximum number of non-executable transaction slots for all accounts",
 		Value: evr.DefaultConfig.TxPool.GlobalQueue,
 	}
+	TxPoolProviderSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.providerslots",
+		Usage: "Maximum number of transaction slots sponsored by a single provider",
+		Value: evr.DefaultConfig.TxPool.ProviderSlots,
+	}
+	TxPoolContractSenderSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.contractsenderslots",
+		Usage: "Maximum number of transaction slots of a single contract sender",
+		Value: evr.DefaultConfig.TxPool.ContractSenderSlots,
+	}
+	TxPoolGlobalContractSenderSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.globalcontractsenderslots",
+		Usage: "Maximum number of transaction slots of all contract senders",
+		Value: evr.DefaultConfig.TxPool.GlobalContractSenderSlots,
+	}
 	TxPoolLifetimeFlag = cli.DurationFlag{
 		Name:  "txpool.lifetime",
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: evr.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolScheduleHorizonFlag = cli.Uint64Flag{
+		Name:  "txpool.schedulehorizon",
+		Usage: "Maximum number of blocks the window of a scheduled transaction may start in the future",
+		Value: evr.DefaultConfig.TxPool.ScheduleHorizon,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -360,6 +418,11 @@ var (
 		Usage: "Percentage of cache memory allowance to use for trie pruning (default = 25% full mode, 0% archive mode)",
 		Value: 25,
 	}
+	CacheSnapshotFlag = cli.IntFlag{
+		Name:  "cache.snapshot",
+		Usage: "Percentage of cache memory allowance to use for state snapshot caching (0 disables the snapshot)",
+		Value: 25,
+	}
 	CacheNoPrefetchFlag = cli.BoolFlag{
 		Name:  "cache.noprefetch",
 		Usage: "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
@@ -425,6 +488,11 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerMaxAccountTxsFlag = cli.IntFlag{
+		Name:  "miner.maxaccounttxs",
+		Usage: "Maximum number of transactions of a single remote account in a block (0 = unlimited)",
+		Value: evr.DefaultConfig.Miner.MaxAccountTxs,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -705,6 +773,11 @@ var (
 		Usage: "Duration waiting to start round with new height",
 		Value: evr.DefaultConfig.Tendermint.TimeoutCommit,
 	}
+	TendermintBlockBuildRatioFlag = cli.Float64Flag{
+		Name:  "tendermint.block-build-ratio",
+		Usage: "Fraction of the propose timeout the proposer may spend adding transactions to its block (0 = no deadline)",
+		Value: evr.DefaultConfig.Tendermint.BlockBuildRatio,
+	}
 	TendermintSCUseEVMCallerFlag = cli.BoolFlag{
 		Name:  "tendermint.use-evm-caller",
 		Usage: "The flag allowance reading data from stateDB or EVM",
@@ -1182,6 +1255,10 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		cfg.DBEngine = ctx.GlobalString(DBEngineFlag.Name)
+	}
+
 	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
 	}
@@ -1255,6 +1332,16 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 			}
 		}
 	}
+	if ctx.GlobalIsSet(TxPoolPriorityFlag.Name) {
+		priority := strings.Split(ctx.GlobalString(TxPoolPriorityFlag.Name), ",")
+		for _, account := range priority {
+			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid account in --txpool.priority: %s", trimmed)
+			} else {
+				cfg.Priority = append(cfg.Priority, common.HexToAddress(account))
+			}
+		}
+	}
 	if ctx.GlobalIsSet(TxPoolNoLocalsFlag.Name) {
 		cfg.NoLocals = ctx.GlobalBool(TxPoolNoLocalsFlag.Name)
 	}
@@ -1282,9 +1369,21 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.GlobalUint64(TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolProviderSlotsFlag.Name) {
+		cfg.ProviderSlots = ctx.GlobalUint64(TxPoolProviderSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolContractSenderSlotsFlag.Name) {
+		cfg.ContractSenderSlots = ctx.GlobalUint64(TxPoolContractSenderSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolGlobalContractSenderSlotsFlag.Name) {
+		cfg.GlobalContractSenderSlots = ctx.GlobalUint64(TxPoolGlobalContractSenderSlotsFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolScheduleHorizonFlag.Name) {
+		cfg.ScheduleHorizon = ctx.GlobalUint64(TxPoolScheduleHorizonFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *evr.Config) {
@@ -1333,6 +1432,9 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerMaxAccountTxsFlag.Name) {
+		cfg.MaxAccountTxs = ctx.GlobalInt(MinerMaxAccountTxsFlag.Name)
+	}
 }
 
 func setWhitelist(ctx *cli.Context, cfg *evr.Config) {
@@ -1358,6 +1460,28 @@ func setWhitelist(ctx *cli.Context, cfg *evr.Config) {
 	}
 }
 
+// setSyncCheckpoint creates the trusted checkpoint of checkpoint sync from the
+// command line flags.
+func setSyncCheckpoint(ctx *cli.Context, cfg *evr.Config) {
+	checkpoint := ctx.GlobalString(SyncCheckpointFlag.Name)
+	if checkpoint == "" {
+		return
+	}
+	parts := strings.Split(checkpoint, "=")
+	if len(parts) != 2 {
+		Fatalf("Invalid sync checkpoint: %s", checkpoint)
+	}
+	number, err := strconv.ParseUint(parts[0], 0, 64)
+	if err != nil {
+		Fatalf("Invalid sync checkpoint block number %s: %v", parts[0], err)
+	}
+	var hash common.Hash
+	if err = hash.UnmarshalText([]byte(parts[1])); err != nil {
+		Fatalf("Invalid sync checkpoint hash %s: %v", parts[1], err)
+	}
+	cfg.SyncCheckpoint = &downloader.SyncCheckpoint{Number: number, Hash: hash}
+}
+
 // setTendermint will use params from CLI for tendermint config
 // NOTE: ProposerPolicy, Epoch are used for chain, so they not allowed to inject. They will be got from genesis
 func setTendermint(ctx *cli.Context, cfg *tendermint.Config) {
@@ -1393,6 +1517,9 @@ func setTendermint(ctx *cli.Context, cfg *tendermint.Config) {
 	if ctx.GlobalIsSet(TendermintTimeoutCommitFlag.Name) {
 		cfg.TimeoutCommit = ctx.GlobalDuration(TendermintTimeoutCommitFlag.Name)
 	}
+	if ctx.GlobalIsSet(TendermintBlockBuildRatioFlag.Name) {
+		cfg.BlockBuildRatio = ctx.GlobalFloat64(TendermintBlockBuildRatioFlag.Name)
+	}
 
 	if ctx.IsSet(TendermintSCUseEVMCallerFlag.Name) {
 		cfg.UseEVMCaller = true
@@ -1425,6 +1552,9 @@ func setTendermint(ctx *cli.Context, cfg *tendermint.Config) {
 	if ctx.IsSet(TendermintTimeoutCommitFlag.Name) {
 		cfg.TimeoutCommit = ctx.Duration(TendermintTimeoutCommitFlag.Name)
 	}
+	if ctx.IsSet(TendermintBlockBuildRatioFlag.Name) {
+		cfg.BlockBuildRatio = ctx.Float64(TendermintBlockBuildRatioFlag.Name)
+	}
 }
 
 // checkExclusive verifies that only a single instance of the provided flags was
@@ -1498,6 +1628,7 @@ func SetEvrConfig(ctx *cli.Context, stack *node.Node, cfg *evr.Config) {
 	setEthash(ctx, cfg)
 	setMiner(ctx, &cfg.Miner)
 	setWhitelist(ctx, cfg)
+	setSyncCheckpoint(ctx, cfg)
 	setTendermint(ctx, &cfg.Tendermint)
 
 	if ctx.GlobalIsSet(SyncModeFlag.Name) {
@@ -1525,10 +1656,34 @@ func SetEvrConfig(ctx *cli.Context, stack *node.Node, cfg *evr.Config) {
 		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
 	}
 
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "prune" {
+		Fatalf("--%s must be either 'full', 'archive' or 'prune'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	if ctx.GlobalString(GCModeFlag.Name) == "prune" {
+		cfg.PruneRecent = ctx.GlobalInt(PruneRecentFlag.Name)
+		if cfg.PruneRecent < 1 {
+			Fatalf("--%s must be positive", PruneRecentFlag.Name)
+		}
+	}
+	if ctx.GlobalIsSet(HistoryRetentionFlag.Name) {
+		cfg.HistoryRetention = ctx.GlobalUint64(HistoryRetentionFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
+		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(StateReexecFlag.Name) {
+		cfg.StateReexec = ctx.GlobalUint64(StateReexecFlag.Name)
+	}
+	if ctx.GlobalIsSet(LogIndexFlag.Name) {
+		cfg.LogIndex = ctx.GlobalBool(LogIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(AccountIndexFlag.Name) {
+		cfg.AccountIndex = ctx.GlobalBool(AccountIndexFlag.Name)
+		if cfg.AccountIndex && !cfg.NoPruning {
+			Fatalf("--%s requires --%s=archive", AccountIndexFlag.Name, GCModeFlag.Name)
+		}
+	}
 	cfg.NoPrefetch = ctx.GlobalBool(CacheNoPrefetchFlag.Name)
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
@@ -1537,6 +1692,9 @@ func SetEvrConfig(ctx *cli.Context, stack *node.Node, cfg *evr.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieDirtyCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheSnapshotFlag.Name) {
+		cfg.SnapshotCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheSnapshotFlag.Name) / 100
+	}
 	if ctx.GlobalIsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.GlobalString(DocRootFlag.Name)
 	}
@@ -1771,8 +1929,8 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 			}, nil, false)
 		}
 	}
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "prune" {
+		Fatalf("--%s must be either 'full', 'archive' or 'prune'", GCModeFlag.Name)
 	}
 	cache := &core.CacheConfig{
 		TrieCleanLimit:      evr.DefaultConfig.TrieCleanCache,
@@ -1781,6 +1939,9 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		TrieDirtyDisabled:   ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieTimeLimit:       evr.DefaultConfig.TrieTimeout,
 	}
+	if ctx.GlobalString(GCModeFlag.Name) == "prune" {
+		cache.TriePruneRecent = ctx.GlobalInt(PruneRecentFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cache.TrieCleanLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -19,6 +19,7 @@ package consensus
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
@@ -167,6 +168,10 @@ type Tendermint interface {
 
 	//Address return the coinbase of the engine
 	Address() common.Address
+
+	// BlockBuildDeadline returns how long the miner may keep adding transactions to a block
+	// after it starts building it. Zero means no deadline.
+	BlockBuildDeadline() time.Duration
 }
 
 // Handler should be implemented is the consensus needs to handle and send peer's message
@@ -137,6 +137,11 @@ func (sb *Backend) Address() common.Address {
 	return sb.address
 }
 
+// BlockBuildDeadline implements consensus.Tendermint.BlockBuildDeadline
+func (sb *Backend) BlockBuildDeadline() time.Duration {
+	return sb.config.BlockBuildDeadline()
+}
+
 // Broadcast implements tendermint.Backend.Broadcast
 // It sends message to its validator by calling gossiping, and send message to itself by eventMux
 func (sb *Backend) Broadcast(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
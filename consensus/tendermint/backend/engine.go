@@ -532,42 +532,7 @@ func (sb *Backend) verifyProposalSeal(header *types.Header, valSet tendermint.Va
 
 // verifyCommittedSeals checks whether every committed seal is signed by one of the parent's validators
 func (sb *Backend) verifyCommittedSeals(header *types.Header, valSet tendermint.ValidatorSet) error {
-	extra, err := types.ExtractTendermintExtra(header)
-	if err != nil {
-		return err
-	}
-	// The length of Committed seals should be larger than 0
-	if len(extra.CommittedSeal) == 0 {
-		return tendermint.ErrEmptyCommittedSeals
-	}
-
-	vals := valSet.Copy()
-	// Check whether the committed seals are generated by parent's validators
-	validSeal := 0
-	proposalSeal := utils.PrepareCommittedSeal(header.Hash())
-	// 1. Get committed seals from current header
-	for _, seal := range extra.CommittedSeal {
-		// 2. Get the original address by seal and parent block hash
-		addr, err := utils.GetSignatureAddress(proposalSeal, seal)
-		if err != nil {
-			log.Error("not a valid address", "err", err)
-			return tendermint.ErrInvalidSignature
-		}
-		// Every validator can have only one seal. If more than one seals are signed by a
-		// validator, the validator cannot be found and errInvalidCommittedSeals is returned.
-		if vals.RemoveValidator(addr) {
-			validSeal++
-		} else {
-			return tendermint.ErrInvalidCommittedSeals
-		}
-	}
-
-	// The length of validSeal should be larger or equal than min majority (num validator - maximum faulty)
-	if validSeal < valSet.MinMajority() {
-		return tendermint.ErrInvalidCommittedSeals
-	}
-
-	return nil
+	return utils.VerifyCommittedSeals(header, valSet)
 }
 
 // blockProposer extracts the Evrynet account address from a signed header.
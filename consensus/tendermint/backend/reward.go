@@ -8,6 +8,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
@@ -37,7 +38,7 @@ func (sb *Backend) accumulateRewards(chainReader consensus.FullChainReader, stat
 		return nil
 	}
 
-	validatorsRewards := calculateTotalValidatorsRewards(chainReader, epoch, header)
+	validatorsRewards := calculateTotalValidatorsRewards(chainReader, state, epoch, header)
 	transitionHeader := chainReader.GetHeaderByNumber(currentBlock - epoch)
 	validatorAdds, err := utils.GetValSetAddresses(transitionHeader)
 	if err != nil {
@@ -63,7 +64,8 @@ func (sb *Backend) accumulateRewards(chainReader consensus.FullChainReader, stat
 
 // calculateTotalValidatorsRewards gets reward from chainReader and current header (from finalize)
 // reward includes block rewards and tx fee from block number currentBlock - epoch +1
-func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, epoch uint64, header *types.Header) map[common.Address]*big.Int {
+// tx fees are paid at the gas price scheduled for each block, as recorded in the state of the current block
+func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, state *state.StateDB, epoch uint64, header *types.Header) map[common.Address]*big.Int {
 	var currentBlock = header.Number.Uint64()
 	validatorsRewards := make(map[common.Address]*big.Int)
 	for i := currentBlock - epoch + 1; i <= currentBlock; i++ {
@@ -73,7 +75,8 @@ func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, epoch ui
 		} else {
 			currentHeader = header
 		}
-		txFee := new(big.Int).Mul(big.NewInt(int64(currentHeader.GasUsed)), chainReader.Config().GasPrice)
+		gasPrice := core.GasPriceAt(state, chainReader.Config(), i)
+		txFee := new(big.Int).Mul(big.NewInt(int64(currentHeader.GasUsed)), gasPrice)
 		reward := new(big.Int).Add(chainReader.Config().Tendermint.BlockReward, txFee)
 		if current, ok := validatorsRewards[currentHeader.Coinbase]; ok {
 			validatorsRewards[currentHeader.Coinbase] = new(big.Int).Add(current, reward)
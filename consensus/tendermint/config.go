@@ -44,6 +44,7 @@ type Config struct {
 	TimeoutPrecommit      time.Duration    //Duration waiting for more precommit after 2/3 received
 	TimeoutPrecommitDelta time.Duration    //Duration waiting to increase if precommit wait expired to reach eventually synchronous
 	TimeoutCommit         time.Duration    //Duration waiting to start round with new height
+	BlockBuildRatio       float64          //Fraction of TimeoutPropose the proposer may spend adding txs to its block
 	FixedValidators       []common.Address // The fixed validators
 	BlockReward           *big.Int         //BlockReward for accumulating reward
 
@@ -65,6 +66,7 @@ var DefaultConfig = &Config{
 	TimeoutPrecommit:      1000 * time.Millisecond,
 	TimeoutPrecommitDelta: 500 * time.Millisecond,
 	TimeoutCommit:         1000 * time.Millisecond,
+	BlockBuildRatio:       0.5,
 	FaultyMode:            Disabled.Uint64(),
 	UseEVMCaller:          false,
 	IndexStateVariables:   staking.DefaultConfig,
@@ -112,3 +114,18 @@ func (cfg *Config) PrecommitCatchupTimeout(round int64) time.Duration {
 func (cfg *Config) Commit(t time.Time) time.Time {
 	return t.Add(cfg.TimeoutCommit)
 }
+
+// BlockBuildDeadline returns the amount of time the miner may spend adding transactions to a block,
+// measured from the start of the build: BlockBuildRatio of the first round's propose timeout. The engine
+// doesn't build blocks itself, the proposer proposes the block the miner delivered, so the deadline only
+// bounds how long filling it takes. A non-positive BlockBuildRatio disables the deadline and 0 is returned.
+func (cfg *Config) BlockBuildDeadline() time.Duration {
+	if cfg.BlockBuildRatio <= 0 {
+		return 0
+	}
+	ratio := cfg.BlockBuildRatio
+	if ratio > 1 {
+		ratio = 1
+	}
+	return time.Duration(float64(cfg.ProposeTimeout(0).Nanoseconds()) * ratio)
+}
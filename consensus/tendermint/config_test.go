@@ -0,0 +1,24 @@
+package tendermint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockBuildDeadline(t *testing.T) {
+	cfg := &Config{
+		TimeoutPropose:      2 * time.Second,
+		TimeoutProposeDelta: 500 * time.Millisecond,
+		TimeoutCommit:       time.Second,
+		BlockBuildRatio:     0.5,
+	}
+	assert.Equal(t, time.Second, cfg.BlockBuildDeadline())
+
+	cfg.BlockBuildRatio = 2
+	assert.Equal(t, 2*time.Second, cfg.BlockBuildDeadline())
+
+	cfg.BlockBuildRatio = 0
+	assert.Equal(t, time.Duration(0), cfg.BlockBuildDeadline())
+}
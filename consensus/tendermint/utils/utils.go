@@ -128,6 +128,46 @@ func PrepareCommittedSeal(hash common.Hash) []byte {
 	return buf.Bytes()
 }
 
+// VerifyCommittedSeals checks whether every committed seal of the header is signed by one
+// of the given validators, and whether the seals reach the quorum of the validator set.
+func VerifyCommittedSeals(header *types.Header, valSet tendermint.ValidatorSet) error {
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return err
+	}
+	// The length of Committed seals should be larger than 0
+	if len(extra.CommittedSeal) == 0 {
+		return tendermint.ErrEmptyCommittedSeals
+	}
+
+	vals := valSet.Copy()
+	// Check whether the committed seals are generated by parent's validators
+	validSeal := 0
+	proposalSeal := PrepareCommittedSeal(header.Hash())
+	// 1. Get committed seals from current header
+	for _, seal := range extra.CommittedSeal {
+		// 2. Get the original address by seal and parent block hash
+		addr, err := GetSignatureAddress(proposalSeal, seal)
+		if err != nil {
+			return tendermint.ErrInvalidSignature
+		}
+		// Every validator can have only one seal. If more than one seals are signed by a
+		// validator, the validator cannot be found and errInvalidCommittedSeals is returned.
+		if vals.RemoveValidator(addr) {
+			validSeal++
+		} else {
+			return tendermint.ErrInvalidCommittedSeals
+		}
+	}
+
+	// The length of validSeal should be larger or equal than min majority (num validator - maximum faulty)
+	if validSeal < valSet.MinMajority() {
+		return tendermint.ErrInvalidCommittedSeals
+	}
+
+	return nil
+}
+
 // GetCheckpointNumber returns check-point block where header contains valset of current epoch
 func GetCheckpointNumber(epochDuration uint64, blockNumber uint64) uint64 {
 	if blockNumber == 0 || blockNumber < epochDuration {
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"errors"
+	"math"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+var (
+	// ErrEmptyBatch is returned if a batch transaction carries no calls
+	ErrEmptyBatch = errors.New("batch transaction without calls")
+
+	// ErrBatchTooLarge is returned if a batch transaction carries more than params.MaxBatchCalls calls
+	ErrBatchTooLarge = errors.New("too many calls in batch transaction")
+
+	// ErrBatchSystemCall is returned if a call of a batch transaction targets a system address
+	ErrBatchSystemCall = errors.New("batch transaction calls a system address")
+
+	// ErrBatchSponsoredTargets is returned if the calls of a sponsored batch transaction
+	// target more than one contract
+	ErrBatchSponsoredTargets = errors.New("sponsored batch transaction calls more than one contract")
+)
+
+// validateBatch checks the calls of a batch transaction and whether they can be
+// sponsored by a provider.
+func validateBatch(calls []types.BatchCall, sponsored bool) error {
+	if len(calls) == 0 {
+		return ErrEmptyBatch
+	}
+	if len(calls) > params.MaxBatchCalls {
+		return ErrBatchTooLarge
+	}
+	for _, call := range calls {
+		switch call.To {
+		case types.EnterpriseManagerAddress, types.GasPriceManagerAddress, types.BatchCallAddress:
+			return ErrBatchSystemCall
+		}
+		// Providers pay for the calls to a single enterprise contract, so that its
+		// spending policy covers the whole batch
+		if sponsored && call.To != calls[0].To {
+			return ErrBatchSponsoredTargets
+		}
+	}
+	return nil
+}
+
+// BatchCallsGas computes the gas charged for the calls of a batch transaction on top
+// of its intrinsic gas: TxBatchCallGas and the data gas of every call.
+func BatchCallsGas(calls []types.BatchCall) (uint64, error) {
+	var gas uint64
+	for _, call := range calls {
+		callGas, err := IntrinsicGas(call.Data, false, true)
+		if err != nil {
+			return 0, err
+		}
+		callGas = callGas - params.TxGas + params.TxBatchCallGas
+		if math.MaxUint64-gas < callGas {
+			return 0, vm.ErrOutOfGas
+		}
+		gas += callGas
+	}
+	return gas, nil
+}
+
+// callTargets returns the accounts called by a transaction or message, which are
+// the recipients of the calls of batches. It is empty for contract creations.
+func callTargets(to *common.Address, calls []types.BatchCall) []common.Address {
+	if len(calls) > 0 {
+		targets := make([]common.Address, len(calls))
+		for i, call := range calls {
+			targets[i] = call.To
+		}
+		return targets
+	}
+	if to == nil {
+		return nil
+	}
+	return []common.Address{*to}
+}
+
+// sponsoredCalls returns the enterprise contract a provider pays for and the payloads
+// of the calls to it. The calls of sponsored batches all target the same contract.
+func sponsoredCalls(to common.Address, data []byte, calls []types.BatchCall) (common.Address, [][]byte) {
+	if len(calls) == 0 {
+		return to, [][]byte{data}
+	}
+	payloads := make([][]byte, len(calls))
+	for i, call := range calls {
+		payloads[i] = call.Data
+	}
+	return calls[0].To, payloads
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestApplyBatchTransaction(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		reverter = common.HexToAddress("0x1000")
+		config   = params.TestChainConfig
+		signer   = types.NewEIP155Signer(config.ChainID)
+		header   = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: 10000000, Time: 1}
+		author   = common.HexToAddress("0xc0ffee")
+	)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.SetBalance(sender, big.NewInt(1000000000000000000))
+	statedb.SetCode(reverter, []byte{0x60, 0x00, 0x60, 0x00, 0xfd}) // PUSH1 0 PUSH1 0 REVERT
+
+	apply := func(calls []types.BatchCall) *types.Receipt {
+		tx := types.NewBatchTransaction(config.ChainID, statedb.GetNonce(sender), calls, 200000, big.NewInt(params.GasPriceConfig), nil)
+		tx, err := types.SignTx(tx, signer, key)
+		require.NoError(t, err)
+
+		var usedGas uint64
+		gp := new(GasPool).AddGas(header.GasLimit)
+		receipt, _, err := ApplyTransaction(config, nil, &author, gp, statedb, header, tx, &usedGas, vm.Config{})
+		require.NoError(t, err)
+		return receipt
+	}
+	// All calls of a batch run with a single nonce and signature
+	receipt := apply([]types.BatchCall{
+		{To: common.HexToAddress("0x2001"), Value: big.NewInt(1)},
+		{To: common.HexToAddress("0x2002"), Value: big.NewInt(2)},
+		{To: common.HexToAddress("0x2003"), Value: big.NewInt(3)},
+	})
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, params.TxGas+3*params.TxBatchCallGas, receipt.GasUsed)
+	require.Len(t, receipt.CallResults, 3)
+	for i, result := range receipt.CallResults {
+		assert.Equal(t, types.ReceiptStatusSuccessful, result.Status)
+		assert.Equal(t, big.NewInt(int64(i+1)), statedb.GetBalance(common.BigToAddress(big.NewInt(int64(0x2001+i)))))
+	}
+	assert.Equal(t, uint64(1), statedb.GetNonce(sender))
+
+	// A failing call reverts the calls before it, the results end with it
+	receipt = apply([]types.BatchCall{
+		{To: common.HexToAddress("0x2004"), Value: big.NewInt(4)},
+		{To: reverter, Value: new(big.Int)},
+		{To: common.HexToAddress("0x2005"), Value: big.NewInt(5)},
+	})
+	assert.Equal(t, types.ReceiptStatusFailed, receipt.Status)
+	require.Len(t, receipt.CallResults, 2)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.CallResults[0].Status)
+	assert.Equal(t, types.ReceiptStatusFailed, receipt.CallResults[1].Status)
+	assert.Zero(t, statedb.GetBalance(common.HexToAddress("0x2004")).Sign())
+	assert.Zero(t, statedb.GetBalance(common.HexToAddress("0x2005")).Sign())
+	assert.Equal(t, uint64(2), statedb.GetNonce(sender))
+
+	// Invalid batches fail without running any call
+	receipt = apply([]types.BatchCall{{To: types.EnterpriseManagerAddress, Value: new(big.Int)}})
+	assert.Equal(t, types.ReceiptStatusFailed, receipt.Status)
+	assert.Empty(t, receipt.CallResults)
+	assert.Equal(t, uint64(3), statedb.GetNonce(sender))
+}
+
+func TestBatchFork(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.TestChainConfig
+		signer = types.NewEIP155Signer(config.ChainID)
+		calls  = []types.BatchCall{{To: common.HexToAddress("0x2001"), Value: big.NewInt(1)}}
+	)
+	config.BatchBlock = big.NewInt(2)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.SetBalance(sender, big.NewInt(1000000000000000000))
+
+	tx, err := types.SignTx(types.NewBatchTransaction(config.ChainID, 0, calls, 100000, big.NewInt(params.GasPriceConfig), nil), signer, key)
+	require.NoError(t, err)
+	apply := func(number int64) (*types.Receipt, error) {
+		var usedGas uint64
+		header := &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(1), GasLimit: 10000000, Time: 1}
+		receipt, _, err := ApplyTransaction(&config, nil, &common.Address{}, new(GasPool).AddGas(header.GasLimit), statedb, header, tx, &usedGas, vm.Config{})
+		return receipt, err
+	}
+	_, err = apply(1)
+	assert.Equal(t, types.ErrTxTypeNotSupported, err)
+
+	receipt, err := apply(2)
+	require.NoError(t, err)
+	assert.Len(t, receipt.CallResults, 1)
+	assert.Equal(t, big.NewInt(1), statedb.GetBalance(calls[0].To))
+}
+
+func TestValidateBatch(t *testing.T) {
+	var (
+		a = types.BatchCall{To: common.HexToAddress("0x1000"), Value: new(big.Int)}
+		b = types.BatchCall{To: common.HexToAddress("0x2000"), Value: new(big.Int)}
+	)
+	assert.Equal(t, ErrEmptyBatch, validateBatch(nil, false))
+	assert.Equal(t, ErrBatchTooLarge, validateBatch(make([]types.BatchCall, params.MaxBatchCalls+1), false))
+	assert.Equal(t, ErrBatchSystemCall, validateBatch([]types.BatchCall{a, {To: types.BatchCallAddress, Value: new(big.Int)}}, false))
+	assert.NoError(t, validateBatch([]types.BatchCall{a, b}, false))
+	assert.Equal(t, ErrBatchSponsoredTargets, validateBatch([]types.BatchCall{a, b}, true))
+	assert.NoError(t, validateBatch([]types.BatchCall{a, a}, true))
+
+	gas, err := BatchCallsGas([]types.BatchCall{a, {To: a.To, Value: new(big.Int), Data: []byte{0x00, 0x01}}})
+	require.NoError(t, err)
+	assert.Equal(t, 2*params.TxBatchCallGas+params.TxDataZeroGas+params.TxDataNonZeroGas, gas)
+}
+
+// Tests that the pool accepts batches, including sponsored ones to a single
+// enterprise contract.
+func TestTransactionPoolBatch(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	var (
+		providerKey, _ = crypto.GenerateKey()
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		owner          = common.HexToAddress("0x2000")
+		contract       = common.HexToAddress("0x1000")
+		chainID        = params.TestChainConfig.ChainID
+		signer         = types.NewEIP155Signer(chainID)
+		price          = big.NewInt(params.GasPriceConfig)
+	)
+	pool.currentState.CreateAccount(contract, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+	pool.currentState.SetCode(contract, []byte{0x60, 0x00})
+	pool.currentState.SetBalance(provider, big.NewInt(1000000000000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+
+	transfers := []types.BatchCall{
+		{To: common.HexToAddress("0x3001"), Value: big.NewInt(1)},
+		{To: common.HexToAddress("0x3002"), Value: big.NewInt(2)},
+	}
+	tx, _ := types.SignTx(types.NewBatchTransaction(chainID, 0, transfers, params.TxGas, price, nil), signer, key)
+	assert.Equal(t, ErrIntrinsicGas, pool.AddRemote(tx))
+
+	tx, _ = types.SignTx(types.NewBatchTransaction(chainID, 0, transfers, 100000, price, nil), signer, key)
+	require.NoError(t, pool.AddRemote(tx))
+
+	// Calls to enterprise contracts must be paid by one of their providers
+	calls := []types.BatchCall{{To: contract, Value: new(big.Int)}, {To: contract, Value: new(big.Int)}}
+	tx, _ = types.SignTx(types.NewBatchTransaction(chainID, 1, calls, 100000, price, nil), signer, key)
+	assert.Equal(t, ErrInvalidProvider, pool.AddRemote(tx))
+
+	tx, _ = types.SignTx(types.NewBatchTransaction(chainID, 1, append(calls, transfers[0]), 100000, price, &provider), signer, key)
+	tx, _ = types.ProviderSignTx(tx, signer, providerKey)
+	assert.Equal(t, ErrBatchSponsoredTargets, pool.AddRemote(tx))
+
+	tx, _ = types.SignTx(types.NewBatchTransaction(chainID, 1, calls, 100000, price, &provider), signer, key)
+	tx, _ = types.ProviderSignTx(tx, signer, providerKey)
+	require.NoError(t, pool.AddRemote(tx))
+	assert.Equal(t, tx.TransactionFee(), pool.ProviderSpends()[provider])
+}
@@ -64,18 +64,26 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	if hash := types.DeriveSha(block.Transactions()); hash != header.TxHash {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 	}
-
-	for _, tx := range block.Transactions() {
-		if tx.GasPrice().Cmp(v.config.GasPrice) != 0 {
-			return fmt.Errorf("transaction gas price and chainConfig gas price mismatch: has %s want %s", tx.GasPrice(), v.config.GasPrice)
-		}
-	}
 	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
 		if !v.bc.HasBlock(block.ParentHash(), block.NumberU64()-1) {
 			return consensus.ErrUnknownAncestor
 		}
 		return consensus.ErrPrunedAncestor
 	}
+	// All transactions must pay the gas price scheduled for the block in the parent state
+	if len(block.Transactions()) == 0 {
+		return nil
+	}
+	parent, err := v.bc.StateAt(v.bc.GetHeader(block.ParentHash(), block.NumberU64()-1).Root)
+	if err != nil {
+		return err
+	}
+	gasPrice := GasPriceAt(parent, v.config, block.NumberU64())
+	for _, tx := range block.Transactions() {
+		if tx.GasPrice().Cmp(gasPrice) != 0 {
+			return fmt.Errorf("transaction gas price and scheduled gas price mismatch: has %s want %s", tx.GasPrice(), gasPrice)
+		}
+	}
 	return nil
 }
 
@@ -33,6 +33,8 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/pruner"
+	"github.com/Evrynetlabs/evrynet-node/core/state/snapshot"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/core/vm"
 	"github.com/Evrynetlabs/evrynet-node/event"
@@ -79,6 +81,9 @@ const (
 	maxTimeFutureBlocks = 30
 	badBlockLimit       = 10
 	TriesInMemory       = 128
+	pruneBloomSize      = 256 // Megabytes of the bloom filter marking the retained state when pruning
+
+	historyExpiryInterval = time.Minute // Frequency of expiring the ancient history out of the retention window
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	//
@@ -111,6 +116,10 @@ type CacheConfig struct {
 	TrieDirtyLimit      int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
 	TrieDirtyDisabled   bool          // Whether to disable trie write caching and GC altogether (archive node)
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
+	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory, 0 to disable snapshots
+	TriePruneRecent     int           // Number of recently committed states to keep on disk when pruning, 0 to disable pruning
+	HistoryRetention    uint64        // Number of recent blocks to keep the bodies and receipts of, 0 to keep the entire history
+	TxLookupLimit       uint64        // Number of recent blocks to keep the transaction index of, 0 to index all the blocks
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -151,6 +160,14 @@ type BlockChain struct {
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
 
 	stateCache    state.Database // State database to reuse between imports (contains state cache)
+	snaps         *snapshot.Tree // Snapshot tree for fast trie leaf access
+	pruner        *pruner.Pruner // State pruner deleting the stale state from disk, nil if disabled
+	pruneFrom     uint64         // Number of the head state loaded from disk, only the states committed on top are retained
+	pruneRoots    []common.Hash  // Roots of the recently committed states retained by the pruning
+	pruneFlushed  common.Hash    // Root of the last state flushed to disk, which the states in memory rely on
+	pruneCommits  int            // Number of states flushed since the last pruning started
+	pruneAbort    chan struct{}  // Channel closed to abort the running pruning, nil if none was started
+	pruneDone     chan struct{}  // Channel closed when the running pruning ends
 	bodyCache     *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache  *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
 	receiptsCache *lru.Cache     // Cache for the most recent receipts per block
@@ -209,6 +226,11 @@ func NewBlockChain(db evrdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		vmConfig:       vmConfig,
 		badBlocks:      badBlocks,
 	}
+	// In pruning mode, the nodes flushed while pruning must be retained
+	if cacheConfig.TriePruneRecent > 0 && !cacheConfig.TrieDirtyDisabled {
+		bc.pruner = pruner.New(db, bc.stateCache, pruneBloomSize)
+		bc.stateCache.TrieDB().SetWriteHook(bc.pruner.Keep)
+	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
@@ -280,8 +302,17 @@ func NewBlockChain(db evrdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			}
 		}
 	}
+	// Load any existing snapshot, regenerating it if loading failed
+	if bc.cacheConfig.SnapshotLimit > 0 {
+		bc.snaps = snapshot.New(bc.db, bc.stateCache.TrieDB(), bc.cacheConfig.SnapshotLimit, bc.CurrentBlock().Root())
+	}
+	bc.resetPruning(bc.CurrentBlock().NumberU64())
+
 	// Take ownership of this particular state
 	go bc.update()
+
+	bc.wg.Add(1)
+	go bc.maintainTxIndex()
 	return bc, nil
 }
 
@@ -442,7 +473,15 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.blockCache.Purge()
 	bc.futureBlocks.Purge()
 
-	return bc.loadLastState()
+	if err := bc.loadLastState(); err != nil {
+		return err
+	}
+	// The snapshot diffs can't be rewound, regenerate it at the new head
+	if bc.snaps != nil {
+		bc.snaps.Rebuild(bc.CurrentBlock().Root())
+	}
+	bc.resetPruning(bc.CurrentBlock().NumberU64())
+	return nil
 }
 
 // FastSyncCommitHead sets the current head block to the one defined by the hash
@@ -460,8 +499,14 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	bc.chainmu.Lock()
 	bc.currentBlock.Store(block)
 	headBlockGauge.Update(int64(block.NumberU64()))
+	bc.resetPruning(block.NumberU64())
 	bc.chainmu.Unlock()
 
+	// Generate the snapshot of the synced state
+	if bc.snaps != nil {
+		bc.snaps.Rebuild(block.Root())
+	}
+
 	log.Info("Committed new head block", "number", block.Number(), "hash", hash)
 	return nil
 }
@@ -500,7 +545,7 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, bc.stateCache)
+	return state.NewWithSnapshots(root, bc.stateCache, bc.snaps)
 }
 
 // StateCache returns the caching database underpinning the blockchain instance.
@@ -805,7 +850,16 @@ func (bc *BlockChain) Stop() {
 	atomic.StoreInt32(&bc.procInterrupt, 1)
 
 	bc.wg.Wait()
+	bc.stopPruning()
 
+	// Ensure that the entirety of the state snapshot is journalled to disk.
+	var snapBase common.Hash
+	if bc.snaps != nil {
+		var err error
+		if snapBase, err = bc.snaps.Journal(bc.CurrentBlock().Root()); err != nil {
+			log.Error("Failed to journal state snapshot", "err", err)
+		}
+	}
 	// Ensure the state of a recent block is also stored to disk before exiting.
 	// We're writing three different states to catch different restart scenarios:
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
@@ -824,6 +878,13 @@ func (bc *BlockChain) Stop() {
 				}
 			}
 		}
+		// The snapshot generation resumes from the trie of the snapshot base
+		if snapBase != (common.Hash{}) {
+			log.Info("Writing snapshot state to disk", "root", snapBase)
+			if err := triedb.Commit(snapBase, true); err != nil {
+				log.Error("Failed to commit snapshot state trie", "err", err)
+			}
+		}
 		for !bc.triegc.Empty() {
 			triedb.Dereference(bc.triegc.PopItem().(common.Hash))
 		}
@@ -1161,6 +1222,100 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 
 var lastWrite uint64
 
+// pruneEpoch returns the epoch length of the consensus engine, whose checkpoint
+// states are retained by the pruning, or 0 if the engine has no epochs.
+func (bc *BlockChain) pruneEpoch() uint64 {
+	if bc.chainConfig.Tendermint == nil {
+		return 0
+	}
+	return bc.chainConfig.Tendermint.Epoch
+}
+
+// retainState records a committed state, retaining the most recent ones in the
+// next pruning.
+func (bc *BlockChain) retainState(number uint64, root common.Hash) {
+	if number < bc.pruneFrom {
+		return
+	}
+	bc.pruneRoots = append(bc.pruneRoots, root)
+	if len(bc.pruneRoots) > bc.cacheConfig.TriePruneRecent {
+		bc.pruneRoots = bc.pruneRoots[1:]
+	}
+}
+
+// pruneState records a state flushed to disk, and starts deleting the stale
+// states in the background once enough new states were flushed. Besides the
+// recently committed states and the flushed one the states still in memory rely
+// on, the last two epoch checkpoint states are retained, as the rewards of an
+// epoch are computed from the state of the previous one.
+func (bc *BlockChain) pruneState(number uint64, root common.Hash) {
+	if number < bc.pruneFrom {
+		return
+	}
+	bc.pruneFlushed = root
+	bc.pruneCommits++
+	if bc.pruneCommits < bc.cacheConfig.TriePruneRecent {
+		return
+	}
+	// Skip this round if the previous pruning is still running
+	if bc.pruneDone != nil {
+		select {
+		case <-bc.pruneDone:
+		default:
+			return
+		}
+	}
+	bc.pruneCommits = 0
+
+	if err := bc.pruner.Prepare(); err != nil {
+		log.Error("Failed to prepare state pruning", "err", err)
+		return
+	}
+	roots := append([]common.Hash{bc.pruneFlushed}, bc.pruneRoots...)
+	if epoch := bc.pruneEpoch(); epoch > 0 {
+		last := number - number%epoch
+		for _, checkpoint := range []uint64{last, last - epoch} {
+			if checkpoint > last {
+				break // Underflow, no previous epoch
+			}
+			// Checkpoints committed before the pruning was enabled can't be kept
+			if header := bc.GetHeaderByNumber(checkpoint); header != nil && bc.HasState(header.Root) {
+				roots = append(roots, header.Root)
+			}
+		}
+	}
+	abort, done := make(chan struct{}), make(chan struct{})
+	bc.pruneAbort, bc.pruneDone = abort, done
+
+	go func() {
+		defer close(done)
+		if err := bc.pruner.Prune(roots, abort); err != nil && err != pruner.ErrPruneAborted {
+			log.Error("Failed to prune state", "err", err)
+		}
+	}()
+}
+
+// stopPruning aborts the running pruning, if any, and waits for it to end.
+func (bc *BlockChain) stopPruning() {
+	if bc.pruneAbort == nil {
+		return
+	}
+	close(bc.pruneAbort)
+	<-bc.pruneDone
+	bc.pruneAbort, bc.pruneDone = nil, nil
+}
+
+// resetPruning forgets the states retained by the pruning after the head moved
+// to a state loaded from disk. The pruning is suspended until a state on top of
+// it is committed, which then retains all the nodes the newer states rely on.
+func (bc *BlockChain) resetPruning(head uint64) {
+	if bc.pruner == nil {
+		return
+	}
+	bc.stopPruning()
+	bc.pruneFrom, bc.pruneRoots, bc.pruneFlushed, bc.pruneCommits = head, nil, common.Hash{}, 0
+}
+
 // writeBlockWithoutState writes only the block and its metadata to the database,
 // but does not write any state. This is used to construct competing side forks
 // up to the point where they exceed the canonical total difficulty.
@@ -1233,6 +1388,20 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if err != nil {
 		return NonStatTy, err
 	}
+	// Add the changes to the snapshot tree unless the state is already known (e.g.
+	// a sidechain block reimported), only keeping the diff layers of the tries
+	// still in memory
+	if bc.snaps != nil {
+		parent, destructs, accounts, storage := state.SnapshotChanges()
+		if parent != (common.Hash{}) && parent != root && bc.snaps.Snapshot(root) == nil {
+			if err := bc.snaps.Update(root, parent, destructs, accounts, storage); err != nil {
+				log.Warn("Failed to update snapshot tree", "from", parent, "to", root, "err", err)
+			}
+			if err := bc.snaps.Cap(root, TriesInMemory-1); err != nil {
+				log.Warn("Failed to cap snapshot tree", "root", root, "layers", TriesInMemory-1, "err", err)
+			}
+		}
+	}
 	triedb := bc.stateCache.TrieDB()
 
 	// If we're running an archive node, always flush
@@ -1245,20 +1414,35 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 		bc.triegc.Push(root, -int64(block.NumberU64()))
 
+		if bc.pruner != nil {
+			bc.retainState(block.NumberU64(), root)
+		}
+
 		if current := block.NumberU64(); current > TriesInMemory {
 			// If we exceeded our memory allowance, flush matured singleton nodes to disk
 			var (
 				nodes, imgs = triedb.Size()
 				limit       = common.StorageSize(bc.cacheConfig.TrieDirtyLimit) * 1024 * 1024
 			)
+			// When pruning, only entire tries are flushed: the pruner retains the
+			// committed states, deleting any node flushed on its own
+			var flush bool
 			if nodes > limit || imgs > 4*1024*1024 {
-				triedb.Cap(limit - evrdb.IdealBatchSize)
+				if bc.pruner != nil {
+					flush = true
+				} else {
+					triedb.Cap(limit - evrdb.IdealBatchSize)
+				}
 			}
 			// Find the next state trie we need to commit
 			chosen := current - TriesInMemory
 
+			// The epoch checkpoint states are needed to reward the next epoch
+			if epoch := bc.pruneEpoch(); bc.pruner != nil && epoch > 0 && chosen%epoch == 0 {
+				flush = true
+			}
 			// If we exceeded out time allowance, flush an entire trie to disk
-			if bc.gcproc > bc.cacheConfig.TrieTimeLimit {
+			if flush || bc.gcproc > bc.cacheConfig.TrieTimeLimit {
 				// If the header is missing (canonical chain behind), we're reorging a low
 				// diff sidechain. Suspend committing until this operation is completed.
 				header := bc.GetHeaderByNumber(chosen)
@@ -1274,6 +1458,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 					triedb.Commit(header.Root, true)
 					lastWrite = chosen
 					bc.gcproc = 0
+
+					if bc.pruner != nil {
+						bc.pruneState(chosen, header.Root)
+					}
 				}
 			}
 			// Garbage collect anything below our required write retention
@@ -1546,7 +1734,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, []
 		if parent == nil {
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
 		}
-		statedb, err := state.New(parent.Root, bc.stateCache)
+		statedb, err := state.NewWithSnapshots(parent.Root, bc.stateCache, bc.snaps)
 		if err != nil {
 			return it.index, events, coalescedLogs, err
 		}
@@ -1557,7 +1745,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, []
 		if !bc.cacheConfig.TrieCleanNoPrefetch {
 			if followup, err := it.peek(); followup != nil && err == nil {
 				go func(start time.Time) {
-					throwaway, _ := state.New(parent.Root, bc.stateCache)
+					throwaway, _ := state.NewWithSnapshots(parent.Root, bc.stateCache, bc.snaps)
 					bc.prefetcher.Prefetch(followup, throwaway, bc.vmConfig, &followupInterrupt)
 
 					blockPrefetchExecuteTimer.Update(time.Since(start))
@@ -1964,16 +2152,118 @@ func (bc *BlockChain) PostChainEvents(events []interface{}, logs []*types.Log) {
 func (bc *BlockChain) update() {
 	futureTimer := time.NewTicker(5 * time.Second)
 	defer futureTimer.Stop()
+
+	var expiry <-chan time.Time
+	if bc.cacheConfig.HistoryRetention > 0 {
+		expiryTimer := time.NewTicker(historyExpiryInterval)
+		defer expiryTimer.Stop()
+		expiry = expiryTimer.C
+	}
 	for {
 		select {
 		case <-futureTimer.C:
 			bc.procFutureBlocks()
+		case <-expiry:
+			bc.expireHistory()
 		case <-bc.quit:
 			return
 		}
 	}
 }
 
+// maintainTxIndex keeps the transaction index covering the blocks within the
+// configured limit from the chain head, indexing or unindexing blocks in the
+// background as the chain progresses or the limit changes across restarts.
+func (bc *BlockChain) maintainTxIndex() {
+	defer bc.wg.Done()
+
+	// moveTail indexes or unindexes the blocks up to the tail the limit sets,
+	// starting from the given current tail, nil if never recorded.
+	limit := bc.cacheConfig.TxLookupLimit
+	moveTail := func(tail *uint64, head uint64, done chan struct{}) {
+		defer close(done)
+
+		var target uint64
+		if limit > 0 && head >= limit {
+			target = head - limit + 1
+		}
+		switch {
+		case tail == nil && target == 0:
+			// All the blocks were indexed while inserted, record it
+			rawdb.WriteTxIndexTail(bc.db, 0)
+		case tail == nil:
+			rawdb.UnindexTransactions(bc.db, 0, target, bc.quit)
+		case target < *tail:
+			// The head may have been rewound below the tail
+			if *tail > head+1 {
+				*tail = head + 1
+			}
+			rawdb.IndexTransactions(bc.db, target, *tail, bc.quit)
+		case target > *tail:
+			rawdb.UnindexTransactions(bc.db, *tail, target, bc.quit)
+		}
+	}
+	var (
+		headCh  = make(chan ChainHeadEvent, 1) // Buffered to avoid locking up the event feed
+		done    = make(chan struct{})          // Non-nil while the index is being moved
+		pending *uint64                        // Latest head announced while the index was being moved
+	)
+	sub := bc.SubscribeChainHeadEvent(headCh)
+	if sub == nil {
+		// The chain was stopped already
+		return
+	}
+	defer sub.Unsubscribe()
+
+	go moveTail(rawdb.ReadTxIndexTail(bc.db), bc.CurrentBlock().NumberU64(), done)
+	for {
+		select {
+		case head := <-headCh:
+			number := head.Block.NumberU64()
+			if done != nil {
+				pending = &number
+				continue
+			}
+			done = make(chan struct{})
+			go moveTail(rawdb.ReadTxIndexTail(bc.db), number, done)
+		case <-done:
+			done = nil
+			if pending != nil {
+				done = make(chan struct{})
+				go moveTail(rawdb.ReadTxIndexTail(bc.db), *pending, done)
+				pending = nil
+			}
+		case <-bc.quit:
+			if done != nil {
+				log.Info("Waiting for the transaction indexer to exit")
+				<-done
+			}
+			return
+		}
+	}
+}
+
+// TxIndexTail returns the number of the oldest block whose transactions are
+// indexed, nil if the index was never maintained.
+func (bc *BlockChain) TxIndexTail() *uint64 {
+	return rawdb.ReadTxIndexTail(bc.db)
+}
+
+// expireHistory discards the bodies and receipts of the ancient blocks falling
+// out of the history retention window.
+func (bc *BlockChain) expireHistory() {
+	head := bc.CurrentBlock().NumberU64()
+	if head <= bc.cacheConfig.HistoryRetention {
+		return
+	}
+	if frozen, err := bc.db.Ancients(); err != nil || frozen == 0 {
+		return
+	}
+	if err := bc.db.ExpireAncients(head - bc.cacheConfig.HistoryRetention); err != nil {
+		log.Error("Failed to expire ancient history", "err", err)
+	}
+}
+
 // BadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
 func (bc *BlockChain) BadBlocks() []*types.Block {
 	blocks := make([]*types.Block, 0, bc.badBlocks.Len())
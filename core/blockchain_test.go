@@ -2255,3 +2255,208 @@ func BenchmarkBlockChain_1x1000Executions(b *testing.B) {
 	}
 	benchmarkLargeNumberOfValueToNonexisting(b, numTxs, numBlocks, recipientFn, dataFn)
 }
+
+// Tests that the state snapshot follows the imported blocks, keeping the recent
+// ones in memory, and is restored across restarts.
+func TestBlockChainSnapshot(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		balance = new(big.Int).Mul(big.NewInt(10000000000000), big.NewInt(params.GasPriceConfig))
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: balance}}}
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+		engine  = ethash.NewFaker()
+		db      = rawdb.NewMemoryDatabase()
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, TriesInMemory+10, func(i int, b *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xaa, byte(i)}, big.NewInt(int64(i+1)), params.TxGas, big.NewInt(params.GasPriceConfig), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	diskdb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(diskdb)
+
+	cacheConfig := &CacheConfig{TrieCleanLimit: 256, TrieDirtyLimit: 256, TrieTimeLimit: 5 * time.Minute, SnapshotLimit: 16}
+	chain, err := NewBlockChain(diskdb, cacheConfig, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	head := blocks[len(blocks)-1]
+	if chain.snaps.Snapshot(head.Root()) == nil {
+		t.Fatalf("head snapshot missing")
+	}
+	// Only the diff layers of the tries in memory are kept
+	if root, want := rawdb.ReadSnapshotRoot(diskdb), blocks[len(blocks)-TriesInMemory].Root(); root != want {
+		t.Errorf("snapshot disk root mismatch: have %x, want %x", root, want)
+	}
+	chain.Stop()
+
+	// The journalled snapshot is loaded on restart
+	chain, err = NewBlockChain(diskdb, cacheConfig, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.snaps.Snapshot(blocks[len(blocks)-2].Root()) == nil {
+		t.Errorf("journalled snapshot not loaded")
+	}
+	state, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to open head state: %v", err)
+	}
+	for i := range blocks {
+		if have := state.GetBalance(common.Address{0xaa, byte(i)}); have.Cmp(big.NewInt(int64(i+1))) != 0 {
+			t.Errorf("balance %d mismatch: have %v, want %d", i, have, i+1)
+		}
+	}
+}
+
+// Tests that in pruning mode, only the recently committed states and the epoch
+// checkpoint states are kept on disk.
+func TestBlockChainPruning(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		balance = new(big.Int).Mul(big.NewInt(10000000000000), big.NewInt(params.GasPriceConfig))
+		config  = *params.TestChainConfig
+		engine  = ethash.NewFaker()
+		db      = rawdb.NewMemoryDatabase()
+	)
+	config.Tendermint = &params.TendermintConfig{Epoch: 16}
+	gspec := &Genesis{Config: &config, Alloc: GenesisAlloc{addr: {Balance: balance}}}
+	signer := types.NewEIP155Signer(gspec.Config.ChainID)
+	genesis := gspec.MustCommit(db)
+
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, TriesInMemory+100, func(i int, b *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xaa, byte(i)}, big.NewInt(int64(i+1)), params.TxGas, big.NewInt(params.GasPriceConfig), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	diskdb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(diskdb)
+
+	cacheConfig := &CacheConfig{TrieCleanLimit: 256, TrieDirtyLimit: 256, TrieTimeLimit: 5 * time.Minute, TriePruneRecent: 2}
+	chain, err := NewBlockChain(diskdb, cacheConfig, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	// The epoch checkpoints up to block 96 are committed, pruning every second.
+	// Each pruning is waited for, as none starts while another is running.
+	var start int
+	for _, end := range []int{TriesInMemory + 32, TriesInMemory + 64, TriesInMemory + 96, len(blocks)} {
+		if _, err := chain.InsertChain(blocks[start:end]); err != nil {
+			t.Fatalf("failed to insert chain: %v", err)
+		}
+		if chain.pruneDone == nil {
+			t.Fatalf("pruning not started")
+		}
+		<-chain.pruneDone
+		start = end
+	}
+	// The states of the last blocks written are retained, not only flushed ones
+	if len(chain.pruneRoots) != 2 || chain.pruneRoots[0] != blocks[len(blocks)-2].Root() || chain.pruneRoots[1] != blocks[len(blocks)-1].Root() {
+		t.Errorf("retained recent states mismatch: have %x", chain.pruneRoots)
+	}
+
+	for number := uint64(16); number <= 96; number += 16 {
+		root := blocks[number-1].Root()
+		have, _ := diskdb.Has(root[:])
+		if want := number >= 80; have != want {
+			t.Errorf("state %d on disk mismatch: have %v, want %v", number, have, want)
+		}
+	}
+	checkHead := func(chain *BlockChain) {
+		statedb, err := chain.State()
+		if err != nil {
+			t.Fatalf("failed to open head state: %v", err)
+		}
+		it := state.NewNodeIterator(statedb)
+		for it.Next() {
+		}
+		if it.Error != nil {
+			t.Fatalf("head state incomplete: %v", it.Error)
+		}
+		for i := range blocks {
+			if have := statedb.GetBalance(common.Address{0xaa, byte(i)}); have.Cmp(big.NewInt(int64(i+1))) != 0 {
+				t.Errorf("balance %d mismatch: have %v, want %d", i, have, i+1)
+			}
+		}
+	}
+	checkHead(chain)
+	chain.Stop()
+
+	// The head state is complete after a restart
+	chain, err = NewBlockChain(diskdb, cacheConfig, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate tester chain: %v", err)
+	}
+	defer chain.Stop()
+	checkHead(chain)
+}
+
+func TestTxLookupLimit(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		balance = new(big.Int).Mul(big.NewInt(10000000000000), big.NewInt(params.GasPriceConfig))
+		engine  = ethash.NewFaker()
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: balance}}}
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 20, func(i int, b *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0xaa}, big.NewInt(1), params.TxGas, big.NewInt(params.GasPriceConfig), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	// check waits for the transaction index to move to the given tail, and checks
+	// that only the transactions from it are indexed.
+	check := func(chain *BlockChain, tail uint64) {
+		for i := 0; i < 100; i++ {
+			if have := chain.TxIndexTail(); have != nil && *have == tail {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		if have := chain.TxIndexTail(); have == nil || *have != tail {
+			t.Fatalf("index tail mismatch: have %v, want %d", have, tail)
+		}
+		for _, block := range blocks {
+			for _, tx := range block.Transactions() {
+				indexed := rawdb.ReadTxLookupEntry(db, tx.Hash()) != nil
+				if want := block.NumberU64() >= tail; indexed != want {
+					t.Errorf("block %d: transaction indexed mismatch: have %v, want %v", block.NumberU64(), indexed, want)
+				}
+			}
+		}
+	}
+	chain, err := NewBlockChain(db, &CacheConfig{TrieCleanLimit: 256, TrieDirtyLimit: 256, TrieTimeLimit: 5 * time.Minute, TxLookupLimit: 5}, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	check(chain, 16)
+	chain.Stop()
+
+	// Lifting the limit indexes the entire chain again
+	chain, err = NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate tester chain: %v", err)
+	}
+	defer chain.Stop()
+	check(chain, 0)
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+var (
+	// ErrSenderValidation is returned if the validation function of a contract sender
+	// doesn't authorize the transaction
+	ErrSenderValidation = errors.New("transaction not authorized by sender contract")
+
+	// ErrContractSenderSlots is returned if the sender contract of the transaction already
+	// has the maximum number of transactions in the pool
+	ErrContractSenderSlots = errors.New("sender contract has too many pooled transactions")
+
+	// ErrContractSenderPoolFull is returned if the pool already holds the maximum number
+	// of transactions sent by contract accounts
+	ErrContractSenderPoolFull = errors.New("too many pooled contract sender transactions")
+
+	// ErrContractSenderThrottled is returned if the validation function of the sender
+	// contract failed too often since the last chain head
+	ErrContractSenderThrottled = errors.New("sender contract validation failed too often")
+)
+
+// senderValidationSelector is the selector of the function contract senders implement
+// to authorize transactions: validateTransaction(bytes32 hash, bytes auth) returns (bool).
+var senderValidationSelector = crypto.Keccak256([]byte("validateTransaction(bytes32,bytes)"))[:4]
+
+// validateContractSender calls the validation function of a contract sender with the
+// hash and the authorization data of the transaction. The call is static and its gas
+// is capped by params.SenderValidationGas. It returns the gas used by the call.
+func validateContractSender(evm *vm.EVM, sender common.Address, auth *types.SenderAuth, gas uint64) (uint64, error) {
+	if evm.StateDB.GetCodeSize(sender) == 0 {
+		return 0, ErrSenderValidation
+	}
+	if gas > params.SenderValidationGas {
+		gas = params.SenderValidationGas
+	}
+	ret, left, err := evm.StaticCall(vm.AccountRef(sender), sender, packSenderValidation(auth), gas)
+	if err != nil || len(ret) != 32 || new(big.Int).SetBytes(ret).Cmp(common.Big1) != 0 {
+		return gas - left, ErrSenderValidation
+	}
+	return gas - left, nil
+}
+
+// packSenderValidation ABI encodes the call to the validation function of a contract
+// sender.
+func packSenderValidation(auth *types.SenderAuth) []byte {
+	input := make([]byte, 0, 4+4*32+len(auth.Data))
+	input = append(input, senderValidationSelector...)
+	input = append(input, auth.Hash.Bytes()...)
+	input = append(input, common.LeftPadBytes(big.NewInt(64).Bytes(), 32)...)
+	input = append(input, common.LeftPadBytes(big.NewInt(int64(len(auth.Data))).Bytes(), 32)...)
+	input = append(input, common.RightPadBytes(auth.Data, (len(auth.Data)+31)/32*32)...)
+	return input
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// walletCode returns the first word of the authorization data as the result of the
+// validation function: PUSH1 0x64 CALLDATALOAD PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+var walletCode = []byte{0x60, 0x64, 0x35, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+
+func TestApplyContractTransaction(t *testing.T) {
+	var (
+		wallet    = common.HexToAddress("0x1000")
+		recipient = common.HexToAddress("0x2000")
+		config    = params.TestChainConfig
+		author    = common.HexToAddress("0xc0ffee")
+		header    = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: 10000000}
+		price     = big.NewInt(params.GasPriceConfig)
+		funds     = new(big.Int).Mul(price, big.NewInt(1000000))
+	)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.SetCode(wallet, walletCode)
+	statedb.SetBalance(wallet, funds)
+	statedb.SetBalance(recipient, funds)
+
+	apply := func(sender common.Address, auth []byte) (*types.Receipt, error) {
+		tx := types.NewContractTransaction(config.ChainID, sender, statedb.GetNonce(sender), &recipient, big.NewInt(100), 100000, price, nil, auth)
+		var usedGas uint64
+		gp := new(GasPool).AddGas(header.GasLimit)
+		receipt, _, err := ApplyTransaction(config, nil, &author, gp, statedb, header, tx, &usedGas, vm.Config{})
+		return receipt, err
+	}
+	// The wallet pays the value and the gas, including the validation
+	receipt, err := apply(wallet, common.LeftPadBytes([]byte{1}, 32))
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.True(t, receipt.GasUsed > params.TxGas)
+	assert.Equal(t, new(big.Int).Add(funds, big.NewInt(100)), statedb.GetBalance(recipient))
+	fee := new(big.Int).Mul(price, new(big.Int).SetUint64(receipt.GasUsed))
+	assert.Equal(t, new(big.Int).Sub(new(big.Int).Sub(funds, big.NewInt(100)), fee), statedb.GetBalance(wallet))
+	assert.Equal(t, uint64(1), statedb.GetNonce(wallet))
+
+	// Transactions the validation function rejects and the ones of accounts without
+	// code are invalid
+	_, err = apply(wallet, common.LeftPadBytes([]byte{2}, 32))
+	assert.Equal(t, ErrSenderValidation, err)
+	_, err = apply(wallet, nil)
+	assert.Equal(t, ErrSenderValidation, err)
+	_, err = apply(recipient, common.LeftPadBytes([]byte{1}, 32))
+	assert.Equal(t, ErrSenderValidation, err)
+
+	// Contract senders are only accepted from the contract sender fork on
+	forked := *config
+	forked.ContractSenderBlock = big.NewInt(2)
+	config = &forked
+	_, err = apply(wallet, common.LeftPadBytes([]byte{1}, 32))
+	assert.Equal(t, types.ErrTxTypeNotSupported, err)
+}
+
+// Tests that the pool only accepts the transactions their sender contract authorizes,
+// caps them and drops them once no longer authorized.
+func TestTransactionPoolContractSender(t *testing.T) {
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	var (
+		wallet  = common.HexToAddress("0x1000")
+		chainID = params.TestChainConfig.ChainID
+		valid   = common.LeftPadBytes([]byte{1}, 32)
+		price   = big.NewInt(params.GasPriceConfig)
+	)
+	pool.currentState.SetCode(wallet, walletCode)
+	pool.currentState.SetBalance(wallet, big.NewInt(1000000000000000000))
+
+	contractTx := func(nonce uint64, auth []byte) *types.Transaction {
+		return types.NewContractTransaction(chainID, wallet, nonce, &common.Address{}, big.NewInt(1), 100000, price, nil, auth)
+	}
+	assert.Equal(t, ErrSenderValidation, pool.AddRemote(contractTx(0, nil)))
+	for i := uint64(0); i < pool.config.ContractSenderSlots; i++ {
+		require.NoError(t, pool.AddRemote(contractTx(i, valid)))
+	}
+	assert.Equal(t, ErrContractSenderSlots, pool.AddRemote(contractTx(pool.config.ContractSenderSlots, valid)))
+
+	pending, _ := pool.Stats()
+	assert.Equal(t, int(pool.config.ContractSenderSlots), pending)
+
+	// Changing the validation function invalidates the pooled transactions
+	pool.currentState.SetCode(wallet, []byte{0x00})
+	pool.lockedReset(nil, nil)
+
+	pending, queued := pool.Stats()
+	assert.Zero(t, pending+queued)
+	require.NoError(t, validateTxPoolInternals(pool))
+}
+
+// Tests that the pool caps the transactions of all contract senders, and throttles
+// senders failing their validation until the next head.
+func TestTransactionPoolContractSenderLimits(t *testing.T) {
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	var (
+		wallet  = common.HexToAddress("0x1000")
+		other   = common.HexToAddress("0x1001")
+		chainID = params.TestChainConfig.ChainID
+		valid   = common.LeftPadBytes([]byte{1}, 32)
+		price   = big.NewInt(params.GasPriceConfig)
+	)
+	for _, addr := range []common.Address{wallet, other} {
+		pool.currentState.SetCode(addr, walletCode)
+		pool.currentState.SetBalance(addr, big.NewInt(1000000000000000000))
+	}
+	contractTx := func(sender common.Address, nonce uint64, auth []byte) *types.Transaction {
+		return types.NewContractTransaction(chainID, sender, nonce, &common.Address{}, big.NewInt(1), 100000, price, nil, auth)
+	}
+	// Senders failing their validation are throttled until the next head
+	for i := uint64(0); i < pool.config.ContractSenderSlots; i++ {
+		assert.Equal(t, ErrSenderValidation, pool.AddRemote(contractTx(wallet, 0, []byte{byte(i)})))
+	}
+	assert.Equal(t, ErrContractSenderThrottled, pool.AddRemote(contractTx(wallet, 0, valid)))
+	require.NoError(t, pool.AddRemote(contractTx(other, 0, valid)))
+
+	pool.lockedReset(nil, nil)
+	require.NoError(t, pool.AddRemote(contractTx(wallet, 0, valid)))
+
+	// The transactions of all contract senders are capped together
+	pool.config.GlobalContractSenderSlots = 3
+	require.NoError(t, pool.AddRemote(contractTx(wallet, 1, valid)))
+	assert.Equal(t, ErrContractSenderPoolFull, pool.AddRemote(contractTx(other, 1, valid)))
+	require.NoError(t, validateTxPoolInternals(pool))
+}
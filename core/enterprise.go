@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+)
+
+var (
+	// ErrNotEnterpriseContract is returned if an enterprise operation targets an account without owner
+	ErrNotEnterpriseContract = errors.New("target is not an owned enterprise contract")
+
+	// ErrNotContractOwner is returned if an enterprise operation is not sent by the contract owner
+	ErrNotContractOwner = errors.New("sender is not the contract owner")
+
+	// ErrProviderExists is returned when adding a provider which is already set on the contract
+	ErrProviderExists = errors.New("provider already exists in contract")
+
+	// ErrProviderNotFound is returned when removing a provider which is not set on the contract
+	ErrProviderNotFound = errors.New("provider does not exist in contract")
+
+	// ErrInvalidEnterpriseAddress is returned if an enterprise operation carries an empty provider or owner
+	ErrInvalidEnterpriseAddress = errors.New("invalid provider or owner address")
+
+	// ErrNotContractProvider is returned if a provider policy is not sent by a provider of the contract
+	ErrNotContractProvider = errors.New("sender is not a provider of the contract")
+
+	// ErrEnterpriseOpValue is returned if a transaction sent to the enterprise manager transfers value
+	ErrEnterpriseOpValue = errors.New("enterprise operation must not transfer value")
+
+	// ErrEnterpriseOpsInactive is returned if an enterprise operation is sent before the enterprise fork
+	ErrEnterpriseOpsInactive = errors.New("enterprise operations not yet enabled")
+)
+
+// validateEnterpriseOp checks that from is allowed to apply op against the given state.
+func validateEnterpriseOp(statedb vm.StateDB, from common.Address, op *types.EnterpriseOp) error {
+	codeHash := statedb.GetCodeHash(op.Contract)
+	if codeHash == (common.Hash{}) || codeHash == emptyCodeHash {
+		return ErrNotEnterpriseContract
+	}
+	providers := statedb.GetProviders(op.Contract)
+	if op.Type == types.EnterpriseOpSetPolicy {
+		if !from.InList(providers) {
+			return ErrNotContractProvider
+		}
+		return validateProviderPolicy(op.Policy)
+	}
+	owner := statedb.GetOwner(op.Contract)
+	if owner == nil || *owner == (common.Address{}) {
+		return ErrNotEnterpriseContract
+	}
+	if *owner != from {
+		return ErrNotContractOwner
+	}
+
+	switch op.Type {
+	case types.EnterpriseOpAddProvider:
+		if op.Address == (common.Address{}) {
+			return ErrInvalidEnterpriseAddress
+		}
+		if op.Address.InList(providers) {
+			return ErrProviderExists
+		}
+		if len(providers) >= common.MaxProvider {
+			return ErrMaxProvider
+		}
+	case types.EnterpriseOpRemoveProvider:
+		if !op.Address.InList(providers) {
+			return ErrProviderNotFound
+		}
+	case types.EnterpriseOpTransferOwnership:
+		if op.Address == (common.Address{}) {
+			return ErrInvalidEnterpriseAddress
+		}
+	case types.EnterpriseOpRenounceOwnership:
+	default:
+		return types.ErrInvalidEnterpriseOp
+	}
+	return nil
+}
+
+// applyEnterpriseOp validates and applies op sent by from to the given state.
+func applyEnterpriseOp(statedb vm.StateDB, from common.Address, op *types.EnterpriseOp) error {
+	if err := validateEnterpriseOp(statedb, from, op); err != nil {
+		return err
+	}
+	switch op.Type {
+	case types.EnterpriseOpAddProvider:
+		statedb.AddProvider(op.Contract, op.Address)
+	case types.EnterpriseOpRemoveProvider:
+		statedb.RemoveProvider(op.Contract, op.Address)
+	case types.EnterpriseOpTransferOwnership:
+		newOwner := op.Address
+		statedb.SetOwner(op.Contract, &newOwner)
+	case types.EnterpriseOpRenounceOwnership:
+		statedb.SetOwner(op.Contract, nil)
+	case types.EnterpriseOpSetPolicy:
+		setProviderPolicy(statedb, from, op.Contract, op.Policy)
+	}
+	return nil
+}
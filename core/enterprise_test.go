@@ -0,0 +1,178 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func newEnterpriseState(t *testing.T, contract, owner, provider common.Address) *state.StateDB {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.CreateAccount(contract, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+	statedb.SetCode(contract, []byte{0x60, 0x00})
+	return statedb
+}
+
+func TestApplyEnterpriseOp(t *testing.T) {
+	var (
+		contract  = common.HexToAddress("0x1000")
+		owner     = common.HexToAddress("0x2000")
+		provider  = common.HexToAddress("0x3000")
+		provider2 = common.HexToAddress("0x3001")
+		newOwner  = common.HexToAddress("0x4000")
+		statedb   = newEnterpriseState(t, contract, owner, provider)
+	)
+
+	// only the owner may manage the contract
+	err := applyEnterpriseOp(statedb, provider, types.NewEnterpriseOp(types.EnterpriseOpAddProvider, contract, provider2))
+	assert.Equal(t, ErrNotContractOwner, err)
+
+	// accounts without owner are not enterprise contracts
+	err = applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpAddProvider, owner, provider2))
+	assert.Equal(t, ErrNotEnterpriseContract, err)
+
+	require.NoError(t, applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpAddProvider, contract, provider2)))
+	assert.Len(t, statedb.GetProviders(contract), 2)
+	err = applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpAddProvider, contract, provider2))
+	assert.Equal(t, ErrProviderExists, err)
+
+	require.NoError(t, applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpRemoveProvider, contract, provider)))
+	providers := statedb.GetProviders(contract)
+	require.Len(t, providers, 1)
+	assert.Equal(t, provider2, *providers[0])
+	err = applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpRemoveProvider, contract, provider))
+	assert.Equal(t, ErrProviderNotFound, err)
+
+	// ownership changes are journaled and can be reverted
+	snap := statedb.Snapshot()
+	require.NoError(t, applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpTransferOwnership, contract, newOwner)))
+	assert.Equal(t, newOwner, *statedb.GetOwner(contract))
+	statedb.RevertToSnapshot(snap)
+	assert.Equal(t, owner, *statedb.GetOwner(contract))
+
+	require.NoError(t, applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpRenounceOwnership, contract, common.Address{})))
+	assert.Nil(t, statedb.GetOwner(contract))
+	err = applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpAddProvider, contract, provider))
+	assert.Equal(t, ErrNotEnterpriseContract, err)
+}
+
+func TestEnterpriseOpMaxProvider(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0x1000")
+		owner    = common.HexToAddress("0x2000")
+		statedb  = newEnterpriseState(t, contract, owner, common.HexToAddress("0x3000"))
+	)
+	for i := 1; i < common.MaxProvider; i++ {
+		provider := common.BigToAddress(big.NewInt(int64(0x5000 + i)))
+		require.NoError(t, applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpAddProvider, contract, provider)))
+	}
+	err := applyEnterpriseOp(statedb, owner, types.NewEnterpriseOp(types.EnterpriseOpAddProvider, contract, owner))
+	assert.Equal(t, ErrMaxProvider, err)
+}
+
+func TestDecodeEnterpriseOp(t *testing.T) {
+	op := types.NewEnterpriseOp(types.EnterpriseOpTransferOwnership, common.HexToAddress("0x1000"), common.HexToAddress("0x2000"))
+	data, err := op.Encode()
+	require.NoError(t, err)
+	decoded, err := types.DecodeEnterpriseOp(data)
+	require.NoError(t, err)
+	assert.Equal(t, op, decoded)
+
+	_, err = types.DecodeEnterpriseOp([]byte{0x01, 0x02})
+	assert.Equal(t, types.ErrInvalidEnterpriseOp, err)
+}
+
+func TestProviderPolicy(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0x1000")
+		owner    = common.HexToAddress("0x2000")
+		provider = common.HexToAddress("0x3000")
+		sender   = common.HexToAddress("0x5000")
+		other    = common.HexToAddress("0x5001")
+		method   = [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+		data     = append(method[:], make([]byte, 64)...)
+		fee      = big.NewInt(1000)
+		statedb  = newEnterpriseState(t, contract, owner, provider)
+	)
+	policy := &types.ProviderPolicy{
+		MaxGasPerTx:     100000,
+		MaxFeePerPeriod: big.NewInt(3000),
+		MaxFeePerSender: big.NewInt(2000),
+		Period:          10,
+		AllowedSenders:  []common.Address{sender, other},
+		AllowedMethods:  [][4]byte{method},
+	}
+	// only providers of the contract may set a policy
+	err := applyEnterpriseOp(statedb, owner, types.NewSetPolicyOp(contract, policy))
+	assert.Equal(t, ErrNotContractProvider, err)
+	require.NoError(t, applyEnterpriseOp(statedb, provider, types.NewSetPolicyOp(contract, policy)))
+	assert.Equal(t, policy, GetProviderPolicy(statedb, provider, contract))
+
+	assert.Equal(t, ErrProviderPolicyGas, checkProviderPolicy(statedb, 1, provider, contract, sender, data, 100001, fee))
+	assert.Equal(t, ErrProviderPolicySender, checkProviderPolicy(statedb, 1, provider, contract, owner, data, 21000, fee))
+	assert.Equal(t, ErrProviderPolicyMethod, checkProviderPolicy(statedb, 1, provider, contract, sender, nil, 21000, fee))
+	require.NoError(t, checkProviderPolicy(statedb, 1, provider, contract, sender, data, 21000, fee))
+
+	recordProviderSpending(statedb, 1, provider, contract, sender, fee)
+	recordProviderSpending(statedb, 2, provider, contract, sender, fee)
+	assert.Equal(t, ErrSenderQuotaExceeded, checkProviderPolicy(statedb, 3, provider, contract, sender, data, 21000, fee))
+	require.NoError(t, checkProviderPolicy(statedb, 3, provider, contract, other, data, 21000, fee))
+	recordProviderSpending(statedb, 3, provider, contract, other, fee)
+	assert.Equal(t, ErrProviderBudgetExceeded, checkProviderPolicy(statedb, 4, provider, contract, other, data, 21000, fee))
+	assert.Equal(t, big.NewInt(3000), GetProviderSpending(statedb, provider, contract, nil, 9))
+
+	// budgets are reset in a new period
+	require.NoError(t, checkProviderPolicy(statedb, 10, provider, contract, sender, data, 21000, fee))
+	assert.Equal(t, int64(0), GetProviderSpending(statedb, provider, contract, &sender, 10).Int64())
+
+	// a smaller policy overwrites the previous one and a nil policy removes it
+	require.NoError(t, applyEnterpriseOp(statedb, provider, types.NewSetPolicyOp(contract, &types.ProviderPolicy{MaxGasPerTx: 50000})))
+	assert.Equal(t, uint64(50000), GetProviderPolicy(statedb, provider, contract).MaxGasPerTx)
+	require.NoError(t, applyEnterpriseOp(statedb, provider, types.NewSetPolicyOp(contract, nil)))
+	assert.Nil(t, GetProviderPolicy(statedb, provider, contract))
+	require.NoError(t, checkProviderPolicy(statedb, 1, provider, contract, owner, nil, 1000000, fee))
+}
+
+func TestEnterpriseOpFork(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		owner    = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0x1000")
+		provider = common.HexToAddress("0x3000")
+		config   = *params.TestChainConfig
+		signer   = types.NewEIP155Signer(config.ChainID)
+		statedb  = newEnterpriseState(t, contract, owner, provider)
+	)
+	config.EnterpriseBlock = big.NewInt(2)
+	statedb.SetBalance(owner, big.NewInt(1000000000000000000))
+
+	data, err := types.NewEnterpriseOp(types.EnterpriseOpAddProvider, contract, common.HexToAddress("0x3001")).Encode()
+	require.NoError(t, err)
+	apply := func(number int64) {
+		tx, err := types.SignTx(types.NewTransaction(statedb.GetNonce(owner), types.EnterpriseManagerAddress, new(big.Int), 100000, big.NewInt(params.GasPriceConfig), data), signer, key)
+		require.NoError(t, err)
+
+		var usedGas uint64
+		header := &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(1), GasLimit: 10000000, Time: 1}
+		receipt, _, err := ApplyTransaction(&config, nil, &common.Address{}, new(GasPool).AddGas(header.GasLimit), statedb, header, tx, &usedGas, vm.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	}
+	// Before the fork operations are plain calls to an empty account
+	apply(1)
+	assert.Len(t, statedb.GetProviders(contract), 1)
+
+	apply(2)
+	assert.Len(t, statedb.GetProviders(contract), 2)
+}
@@ -35,4 +35,12 @@ var (
 
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
+
+	// ErrTxNotYetValid is returned if a scheduled transaction is included in a block
+	// before its window starts.
+	ErrTxNotYetValid = errors.New("transaction not yet valid")
+
+	// ErrTxExpired is returned if a scheduled transaction is included in a block or
+	// added to the pool after its window ended.
+	ErrTxExpired = errors.New("transaction expired")
 )
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+var (
+	// ErrGasPriceNotGoverned is returned if a gas price change is sent on a chain without staking admin
+	ErrGasPriceNotGoverned = errors.New("gas price is not governed on this chain")
+
+	// ErrNotStakingAdmin is returned if a gas price change is not sent by the admin of the staking contract
+	ErrNotStakingAdmin = errors.New("sender is not the staking admin")
+
+	// ErrGasPriceActivation is returned if a gas price change does not activate at a future block
+	ErrGasPriceActivation = errors.New("gas price change must activate at a future block")
+
+	// ErrGasPriceChangeValue is returned if a transaction sent to the gas price manager transfers value
+	ErrGasPriceChangeValue = errors.New("gas price change must not transfer value")
+
+	// ErrGasPriceChangesInactive is returned if a gas price change is sent before the gas price fork
+	ErrGasPriceChangesInactive = errors.New("gas price changes not yet enabled")
+)
+
+var (
+	// gasPriceScheduleKey is the storage slot of the number of entries of the gas price schedule,
+	// entries are stored as activation block and price in the following slots.
+	gasPriceScheduleKey = crypto.Keccak256Hash([]byte("gas-price-schedule"))
+)
+
+// stakingAdminGas is the gas available to the call reading the admin of the staking contract.
+const stakingAdminGas uint64 = 100000
+
+func readGasPriceEntry(statedb vm.StateDB, index uint64) (uint64, *big.Int) {
+	activation := statedb.GetState(types.GasPriceManagerAddress, slotAt(gasPriceScheduleKey, 2*index+1)).Big().Uint64()
+	price := statedb.GetState(types.GasPriceManagerAddress, slotAt(gasPriceScheduleKey, 2*index+2)).Big()
+	return activation, price
+}
+
+// GasPriceAt returns the gas price every transaction of block number must pay, which is the
+// latest scheduled price activated at or before number, or the genesis price if there is none.
+// A chain without genesis price and schedule has a zero gas price.
+func GasPriceAt(statedb vm.StateDB, config *params.ChainConfig, number uint64) *big.Int {
+	count := statedb.GetState(types.GasPriceManagerAddress, gasPriceScheduleKey).Big().Uint64()
+	for i := count; i > 0; i-- {
+		if activation, price := readGasPriceEntry(statedb, i-1); activation <= number {
+			return price
+		}
+	}
+	if config.GasPrice == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(config.GasPrice)
+}
+
+// stakingAdmin calls the staking contract for its admin, who governs the gas price.
+func stakingAdmin(evm *vm.EVM) (common.Address, error) {
+	config := evm.ChainConfig()
+	if config.Tendermint == nil || config.Tendermint.StakingSCAddress == nil {
+		return common.Address{}, ErrGasPriceNotGoverned
+	}
+	contract := *config.Tendermint.StakingSCAddress
+	if evm.StateDB.GetCodeSize(contract) == 0 {
+		return common.Address{}, ErrGasPriceNotGoverned
+	}
+	parsed, err := abi.JSON(strings.NewReader(staking_contracts.StakingContractsABI))
+	if err != nil {
+		return common.Address{}, err
+	}
+	input, err := parsed.Pack("admin")
+	if err != nil {
+		return common.Address{}, err
+	}
+	ret, _, err := evm.StaticCall(vm.AccountRef(types.GasPriceManagerAddress), contract, input, stakingAdminGas)
+	if err != nil {
+		return common.Address{}, ErrGasPriceNotGoverned
+	}
+	var admin common.Address
+	if err := parsed.Unpack(&admin, "admin", ret); err != nil || admin == (common.Address{}) {
+		return common.Address{}, ErrGasPriceNotGoverned
+	}
+	return admin, nil
+}
+
+// validateGasPriceChange checks that from is allowed to apply change in block number.
+func validateGasPriceChange(evm *vm.EVM, from common.Address, number uint64, change *types.GasPriceChange) error {
+	admin, err := stakingAdmin(evm)
+	if err != nil {
+		return err
+	}
+	if admin != from {
+		return ErrNotStakingAdmin
+	}
+	if change.Activation <= number {
+		return ErrGasPriceActivation
+	}
+	return nil
+}
+
+// applyGasPriceChange validates and schedules change sent by from in block number.
+// Scheduled changes which are not active yet are replaced by the new one.
+func applyGasPriceChange(evm *vm.EVM, from common.Address, number uint64, change *types.GasPriceChange) error {
+	if err := validateGasPriceChange(evm, from, number, change); err != nil {
+		return err
+	}
+	statedb := evm.StateDB
+	count := statedb.GetState(types.GasPriceManagerAddress, gasPriceScheduleKey).Big().Uint64()
+	for ; count > 0; count-- {
+		if activation, _ := readGasPriceEntry(statedb, count-1); activation <= number {
+			break
+		}
+	}
+	statedb.SetState(types.GasPriceManagerAddress, slotAt(gasPriceScheduleKey, 2*count+1), common.BigToHash(new(big.Int).SetUint64(change.Activation)))
+	statedb.SetState(types.GasPriceManagerAddress, slotAt(gasPriceScheduleKey, 2*count+2), common.BigToHash(change.Price))
+	statedb.SetState(types.GasPriceManagerAddress, gasPriceScheduleKey, common.BigToHash(new(big.Int).SetUint64(count+1)))
+	return nil
+}
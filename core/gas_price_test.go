@@ -0,0 +1,61 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestGasPriceSchedule(t *testing.T) {
+	var (
+		stakingAddress = common.HexToAddress("0x1000")
+		admin          = common.HexToAddress("0x2000")
+		config         = *params.TendermintTestChainConfig
+		genesisPrice   = config.GasPrice
+	)
+	config.Tendermint = &params.TendermintConfig{StakingSCAddress: &stakingAddress}
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	evm := vm.NewEVM(vm.Context{CanTransfer: CanTransfer, Transfer: Transfer, BlockNumber: new(big.Int)}, statedb, &config, vm.Config{})
+
+	// the gas price is not governed until the staking contract has an admin
+	change := types.NewGasPriceChange(big.NewInt(2), 10)
+	assert.Equal(t, ErrGasPriceNotGoverned, applyGasPriceChange(evm, admin, 1, change))
+
+	// staking contract stub returning the admin to any call
+	statedb.SetCode(stakingAddress, append(append([]byte{byte(vm.PUSH20)}, admin.Bytes()...), common.FromHex("0x60005260206000f3")...))
+
+	assert.Equal(t, ErrNotStakingAdmin, applyGasPriceChange(evm, stakingAddress, 1, change))
+	assert.Equal(t, ErrGasPriceActivation, applyGasPriceChange(evm, admin, 10, change))
+	require.NoError(t, applyGasPriceChange(evm, admin, 1, change))
+	assert.Equal(t, genesisPrice, GasPriceAt(statedb, &config, 9))
+	assert.Equal(t, big.NewInt(2), GasPriceAt(statedb, &config, 10))
+
+	// a change which is not active yet is replaced, active ones are kept for past blocks
+	require.NoError(t, applyGasPriceChange(evm, admin, 5, types.NewGasPriceChange(big.NewInt(3), 20)))
+	assert.Equal(t, genesisPrice, GasPriceAt(statedb, &config, 10))
+	assert.Equal(t, big.NewInt(3), GasPriceAt(statedb, &config, 20))
+	require.NoError(t, applyGasPriceChange(evm, admin, 20, types.NewGasPriceChange(big.NewInt(4), 30)))
+	assert.Equal(t, genesisPrice, GasPriceAt(statedb, &config, 19))
+	assert.Equal(t, big.NewInt(3), GasPriceAt(statedb, &config, 29))
+	assert.Equal(t, big.NewInt(4), GasPriceAt(statedb, &config, 30))
+
+	// the schedule survives committing the state, although the manager account is empty
+	root, err := statedb.Commit(true)
+	require.NoError(t, err)
+	statedb, err = state.New(root, statedb.Database())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(4), GasPriceAt(statedb, &config, 30))
+
+	_, err = types.DecodeGasPriceChange([]byte{0x01})
+	assert.Equal(t, types.ErrInvalidGasPriceChange, err)
+}
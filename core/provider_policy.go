@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// maxPolicyEntries bounds the number of allowed senders and methods of a provider policy
+const maxPolicyEntries = 256
+
+var (
+	// ErrInvalidProviderPolicy is returned if a provider policy has too many entries or a negative limit
+	ErrInvalidProviderPolicy = errors.New("invalid provider policy")
+
+	// ErrProviderPolicyGas is returned if a sponsored transaction has a higher gas limit than allowed by the provider
+	ErrProviderPolicyGas = errors.New("gas exceeds provider policy limit")
+
+	// ErrProviderPolicySender is returned if the provider does not pay for the sender of a transaction
+	ErrProviderPolicySender = errors.New("sender is not allowed by provider policy")
+
+	// ErrProviderPolicyMethod is returned if the provider does not pay for the called method
+	ErrProviderPolicyMethod = errors.New("method is not allowed by provider policy")
+
+	// ErrProviderBudgetExceeded is returned if the provider has spent its budget for the current period
+	ErrProviderBudgetExceeded = errors.New("provider budget exceeded for current period")
+
+	// ErrSenderQuotaExceeded is returned if the provider has spent the quota of the sender for the current period
+	ErrSenderQuotaExceeded = errors.New("sender quota exceeded for current period")
+)
+
+var (
+	policyPrefix   = []byte("provider-policy")
+	spendingPrefix = []byte("provider-spending")
+)
+
+// policyKey returns the storage slot of the policy of provider on contract in the enterprise manager account.
+func policyKey(provider, contract common.Address) common.Hash {
+	return crypto.Keccak256Hash(policyPrefix, provider.Bytes(), contract.Bytes())
+}
+
+// spendingKey returns the storage slot of the fees provider paid for contract,
+// in total if sender is nil or for sender only otherwise.
+func spendingKey(provider, contract common.Address, sender *common.Address) common.Hash {
+	if sender == nil {
+		return crypto.Keccak256Hash(spendingPrefix, provider.Bytes(), contract.Bytes())
+	}
+	return crypto.Keccak256Hash(spendingPrefix, provider.Bytes(), contract.Bytes(), sender.Bytes())
+}
+
+func slotAt(base common.Hash, offset uint64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(base.Big(), new(big.Int).SetUint64(offset)))
+}
+
+// readBlob reads a byte slice stored as its length followed by 32 bytes chunks
+func readBlob(statedb vm.StateDB, base common.Hash) []byte {
+	size := statedb.GetState(types.EnterpriseManagerAddress, base).Big().Uint64()
+	if size == 0 {
+		return nil
+	}
+	blob := make([]byte, 0, size+common.HashLength)
+	for i := uint64(0); uint64(len(blob)) < size; i++ {
+		chunk := statedb.GetState(types.EnterpriseManagerAddress, slotAt(base, i+1))
+		blob = append(blob, chunk.Bytes()...)
+	}
+	return blob[:size]
+}
+
+// writeBlob stores a byte slice as its length followed by 32 bytes chunks, clearing the chunks of the previous value
+func writeBlob(statedb vm.StateDB, base common.Hash, blob []byte) {
+	prevChunks := (statedb.GetState(types.EnterpriseManagerAddress, base).Big().Uint64() + common.HashLength - 1) / common.HashLength
+	chunks := (uint64(len(blob)) + common.HashLength - 1) / common.HashLength
+	for i := uint64(0); i < chunks; i++ {
+		var chunk common.Hash
+		copy(chunk[:], blob[i*common.HashLength:])
+		statedb.SetState(types.EnterpriseManagerAddress, slotAt(base, i+1), chunk)
+	}
+	for i := chunks; i < prevChunks; i++ {
+		statedb.SetState(types.EnterpriseManagerAddress, slotAt(base, i+1), common.Hash{})
+	}
+	statedb.SetState(types.EnterpriseManagerAddress, base, common.BigToHash(new(big.Int).SetUint64(uint64(len(blob)))))
+}
+
+// GetProviderPolicy returns the policy provider set on contract, nil if it pays for any transaction.
+func GetProviderPolicy(statedb vm.StateDB, provider, contract common.Address) *types.ProviderPolicy {
+	blob := readBlob(statedb, policyKey(provider, contract))
+	if len(blob) == 0 {
+		return nil
+	}
+	var policy types.ProviderPolicy
+	if err := rlp.DecodeBytes(blob, &policy); err != nil {
+		return nil
+	}
+	return &policy
+}
+
+// GetProviderSpending returns the fees provider paid for contract in the budget period of blockNumber,
+// in total if sender is nil or for sender only otherwise.
+func GetProviderSpending(statedb vm.StateDB, provider, contract common.Address, sender *common.Address, blockNumber uint64) *big.Int {
+	policy := GetProviderPolicy(statedb, provider, contract)
+	if policy == nil {
+		return new(big.Int)
+	}
+	return readSpending(statedb, spendingKey(provider, contract, sender), policy.PeriodOf(blockNumber))
+}
+
+// readSpending returns the counter stored at key if it belongs to period, 0 otherwise.
+// The counter is stored as period+1 followed by the amount.
+func readSpending(statedb vm.StateDB, key common.Hash, period uint64) *big.Int {
+	if statedb.GetState(types.EnterpriseManagerAddress, key).Big().Uint64() != period+1 {
+		return new(big.Int)
+	}
+	return statedb.GetState(types.EnterpriseManagerAddress, slotAt(key, 1)).Big()
+}
+
+func addSpending(statedb vm.StateDB, key common.Hash, period uint64, fee *big.Int) {
+	spent := readSpending(statedb, key, period)
+	statedb.SetState(types.EnterpriseManagerAddress, key, common.BigToHash(new(big.Int).SetUint64(period+1)))
+	statedb.SetState(types.EnterpriseManagerAddress, slotAt(key, 1), common.BigToHash(spent.Add(spent, fee)))
+}
+
+// validateProviderPolicy checks the policy can be stored, a nil policy is valid and removes the current one.
+func validateProviderPolicy(policy *types.ProviderPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if len(policy.AllowedSenders) > maxPolicyEntries || len(policy.AllowedMethods) > maxPolicyEntries {
+		return ErrInvalidProviderPolicy
+	}
+	if (policy.MaxFeePerPeriod != nil && policy.MaxFeePerPeriod.Sign() < 0) ||
+		(policy.MaxFeePerSender != nil && policy.MaxFeePerSender.Sign() < 0) {
+		return ErrInvalidProviderPolicy
+	}
+	return nil
+}
+
+func setProviderPolicy(statedb vm.StateDB, provider, contract common.Address, policy *types.ProviderPolicy) {
+	var blob []byte
+	if policy != nil {
+		// the policy has been validated, encoding can not fail
+		blob, _ = rlp.EncodeToBytes(policy)
+	}
+	writeBlob(statedb, policyKey(provider, contract), blob)
+}
+
+// checkProviderPolicy checks that provider agreed to pay up to fee for a transaction of sender to contract.
+func checkProviderPolicy(statedb vm.StateDB, blockNumber uint64, provider, contract, sender common.Address, data []byte, gas uint64, fee *big.Int) error {
+	policy := GetProviderPolicy(statedb, provider, contract)
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxGasPerTx > 0 && gas > policy.MaxGasPerTx {
+		return ErrProviderPolicyGas
+	}
+	if len(policy.AllowedSenders) > 0 {
+		allowed := false
+		for _, addr := range policy.AllowedSenders {
+			if addr == sender {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrProviderPolicySender
+		}
+	}
+	if len(policy.AllowedMethods) > 0 {
+		allowed := false
+		for _, method := range policy.AllowedMethods {
+			if len(data) >= len(method) && bytes.Equal(method[:], data[:len(method)]) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrProviderPolicyMethod
+		}
+	}
+	period := policy.PeriodOf(blockNumber)
+	if limit := policy.MaxFeePerPeriod; limit != nil && limit.Sign() > 0 {
+		spent := readSpending(statedb, spendingKey(provider, contract, nil), period)
+		if spent.Add(spent, fee).Cmp(limit) > 0 {
+			return ErrProviderBudgetExceeded
+		}
+	}
+	if limit := policy.MaxFeePerSender; limit != nil && limit.Sign() > 0 {
+		spent := readSpending(statedb, spendingKey(provider, contract, &sender), period)
+		if spent.Add(spent, fee).Cmp(limit) > 0 {
+			return ErrSenderQuotaExceeded
+		}
+	}
+	return nil
+}
+
+// recordProviderSpending adds fee to the total and per sender spending of provider, if it has a policy on contract.
+func recordProviderSpending(statedb vm.StateDB, blockNumber uint64, provider, contract, sender common.Address, fee *big.Int) {
+	policy := GetProviderPolicy(statedb, provider, contract)
+	if policy == nil {
+		return
+	}
+	period := policy.PeriodOf(blockNumber)
+	addSpending(statedb, spendingKey(provider, contract, nil), period, fee)
+	addSpending(statedb, spendingKey(provider, contract, &sender), period, fee)
+}
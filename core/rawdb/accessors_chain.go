@@ -275,7 +275,9 @@ func WriteBodyRLP(db evrdb.KeyValueWriter, hash common.Hash, number uint64, rlp
 // HasBody verifies the existence of a block body corresponding to the hash.
 func HasBody(db evrdb.Reader, hash common.Hash, number uint64) bool {
 	if has, err := db.Ancient(freezerHashTable, number); err == nil && common.BytesToHash(has) == hash {
-		return true
+		if ok, _ := db.HasAncient(freezerBodiesTable, number); ok {
+			return true
+		}
 	}
 	if has, err := db.Has(blockBodyKey(number, hash)); !has || err != nil {
 		return false
@@ -365,7 +367,9 @@ func DeleteTd(db evrdb.KeyValueWriter, hash common.Hash, number uint64) {
 // to a block.
 func HasReceipts(db evrdb.Reader, hash common.Hash, number uint64) bool {
 	if has, err := db.Ancient(freezerHashTable, number); err == nil && common.BytesToHash(has) == hash {
-		return true
+		if ok, _ := db.HasAncient(freezerReceiptTable, number); ok {
+			return true
+		}
 	}
 	if has, err := db.Has(blockReceiptsKey(number, hash)); !has || err != nil {
 		return false
@@ -436,6 +440,14 @@ func ReadReceipts(db evrdb.Reader, hash common.Hash, number uint64, config *para
 	return receipts
 }
 
+// WriteReceiptsRLP stores the RLP encoded transaction receipts belonging to a
+// block, in their storage form.
+func WriteReceiptsRLP(db evrdb.KeyValueWriter, hash common.Hash, number uint64, rlp rlp.RawValue) {
+	if err := db.Put(blockReceiptsKey(number, hash), rlp); err != nil {
+		log.Crit("Failed to store block receipts", "err", err)
+	}
+}
+
 // WriteReceipts stores all the transaction receipts belonging to a block.
 func WriteReceipts(db evrdb.KeyValueWriter, hash common.Hash, number uint64, receipts types.Receipts) {
 	// Convert the receipts into their storage form and serialize them
@@ -460,6 +472,17 @@ func DeleteReceipts(db evrdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// HistoryExpired returns whether the body and receipts of the canonical block
+// with the given number were expired from the ancient store.
+func HistoryExpired(db evrdb.Reader, number uint64) bool {
+	frozen, err := db.Ancients()
+	if err != nil || number >= frozen {
+		return false
+	}
+	ok, err := db.HasAncient(freezerBodiesTable, number)
+	return err == nil && !ok
+}
+
 // ReadBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -17,6 +17,7 @@
 package rawdb
 
 import (
+	"encoding/binary"
 	"math/big"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
@@ -63,6 +64,25 @@ func WriteTxLookupEntries(db evrdb.KeyValueWriter, block *types.Block) {
 	}
 }
 
+// ReadTxIndexTail retrieves the number of the oldest block whose transactions
+// are indexed. If the tail is not recorded, nil is returned.
+func ReadTxIndexTail(db evrdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(txIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteTxIndexTail stores the number of the oldest block whose transactions are
+// indexed.
+func WriteTxIndexTail(db evrdb.KeyValueWriter, number uint64) {
+	if err := db.Put(txIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the transaction index tail", "err", err)
+	}
+}
+
 // DeleteTxLookupEntry removes all transaction data associated with a hash.
 func DeleteTxLookupEntry(db evrdb.KeyValueWriter, hash common.Hash) {
 	db.Delete(txLookupKey(hash))
@@ -81,7 +101,9 @@ func ReadTransaction(db evrdb.Reader, hash common.Hash) (*types.Transaction, com
 	}
 	body := ReadBody(db, blockHash, *blockNumber)
 	if body == nil {
-		log.Error("Transaction referenced missing", "number", blockNumber, "hash", blockHash)
+		if !HistoryExpired(db, *blockNumber) {
+			log.Error("Transaction referenced missing", "number", blockNumber, "hash", blockHash)
+		}
 		return nil, common.Hash{}, 0, 0
 	}
 	for txIndex, tx := range body.Transactions {
@@ -129,3 +151,47 @@ func WriteBloomBits(db evrdb.KeyValueWriter, bit uint, section uint64, head comm
 		log.Crit("Failed to store bloom bits", "err", err)
 	}
 }
+
+// ReadLogIndexBits retrieves the compressed bitset of the blocks of the given
+// section holding logs emitted by the given address or with the given topic. If
+// there are none, nil is returned.
+func ReadLogIndexBits(db evrdb.KeyValueReader, value []byte, section uint64, head common.Hash) []byte {
+	data, _ := db.Get(logIndexKey(value, section, head))
+	return data
+}
+
+// WriteLogIndexBits stores the compressed bitset of the blocks of the given
+// section holding logs emitted by the given address or with the given topic.
+func WriteLogIndexBits(db evrdb.KeyValueWriter, value []byte, section uint64, head common.Hash, bits []byte) {
+	if err := db.Put(logIndexKey(value, section, head), bits); err != nil {
+		log.Crit("Failed to store log index bits", "err", err)
+	}
+}
+
+// ReadAccountIndexEntries retrieves the transactions of the blocks of the given
+// section involving the given account, in chain order. If there are none, nil
+// is returned.
+func ReadAccountIndexEntries(db evrdb.KeyValueReader, address common.Address, section uint64, head common.Hash) []AccountIndexEntry {
+	data, _ := db.Get(accountIndexKey(address, section, head))
+	if len(data) == 0 {
+		return nil
+	}
+	var entries []AccountIndexEntry
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		log.Error("Invalid account index entries", "address", address, "section", section, "err", err)
+		return nil
+	}
+	return entries
+}
+
+// WriteAccountIndexEntries stores the transactions of the blocks of the given
+// section involving the given account.
+func WriteAccountIndexEntries(db evrdb.KeyValueWriter, address common.Address, section uint64, head common.Hash, entries []AccountIndexEntry) {
+	data, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		log.Crit("Failed to encode account index entries", "err", err)
+	}
+	if err := db.Put(accountIndexKey(address, section, head), data); err != nil {
+		log.Crit("Failed to store account index entries", "err", err)
+	}
+}
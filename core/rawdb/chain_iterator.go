@@ -0,0 +1,129 @@
+package rawdb
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// IndexTransactions creates the lookup entries of the transactions of the
+// canonical blocks in the range [from, to), moving the transaction index tail
+// down to from. Blocks are indexed from the newest one, along with the tail, so
+// that an interrupted indexing leaves a contiguous index behind. Blocks whose
+// history expired can't be indexed, leaving the tail above them.
+func IndexTransactions(db evrdb.Database, from uint64, to uint64, interrupt chan struct{}) {
+	if from >= to {
+		return
+	}
+	var (
+		batch  = db.NewBatch()
+		start  = time.Now()
+		logged = time.Now()
+		txs    int
+		tail   = to
+	)
+	for tail > from {
+		if interrupted(interrupt) {
+			break
+		}
+		number := tail - 1
+		body := ReadBody(db, ReadCanonicalHash(db, number), number)
+		if body == nil {
+			if !HistoryExpired(db, number) {
+				log.Warn("Transaction index incomplete, block body missing", "number", number)
+			}
+			break
+		}
+		enc := new(big.Int).SetUint64(number).Bytes()
+		for _, tx := range body.Transactions {
+			if err := batch.Put(txLookupKey(tx.Hash()), enc); err != nil {
+				log.Crit("Failed to store transaction lookup entry", "err", err)
+			}
+		}
+		txs += len(body.Transactions)
+		tail = number
+
+		if batch.ValueSize() >= evrdb.IdealBatchSize {
+			WriteTxIndexTail(batch, tail)
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to write transaction index", "err", err)
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing transactions", "blocks", to-tail, "txs", txs, "tail", tail, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	WriteTxIndexTail(batch, tail)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write transaction index", "err", err)
+	}
+	logger := log.Debug
+	if time.Since(start) > 8*time.Second {
+		logger = log.Info
+	}
+	logger("Indexed transactions", "blocks", to-tail, "txs", txs, "tail", tail, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// UnindexTransactions removes the lookup entries of the transactions of the
+// canonical blocks in the range [from, to), moving the transaction index tail
+// up to to. Blocks are unindexed from the oldest one, along with the tail, so
+// that an interrupted unindexing leaves a contiguous index behind. The entries
+// of blocks whose history expired can't be found, and are left in place.
+func UnindexTransactions(db evrdb.Database, from uint64, to uint64, interrupt chan struct{}) {
+	if from >= to {
+		return
+	}
+	var (
+		batch  = db.NewBatch()
+		start  = time.Now()
+		logged = time.Now()
+		txs    int
+		tail   = from
+	)
+	for ; tail < to; tail++ {
+		if interrupted(interrupt) {
+			break
+		}
+		if body := ReadBody(db, ReadCanonicalHash(db, tail), tail); body != nil {
+			for _, tx := range body.Transactions {
+				DeleteTxLookupEntry(batch, tx.Hash())
+			}
+			txs += len(body.Transactions)
+		}
+		if batch.ValueSize() >= evrdb.IdealBatchSize {
+			WriteTxIndexTail(batch, tail+1)
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to write transaction index", "err", err)
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Unindexing transactions", "blocks", tail-from, "txs", txs, "tail", tail, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	WriteTxIndexTail(batch, tail)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write transaction index", "err", err)
+	}
+	logger := log.Debug
+	if time.Since(start) > 8*time.Second {
+		logger = log.Info
+	}
+	logger("Unindexed transactions", "blocks", tail-from, "txs", txs, "tail", tail, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// interrupted returns whether the given interrupt channel is closed.
+func interrupted(interrupt chan struct{}) bool {
+	select {
+	case <-interrupt:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,64 @@
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+func TestIndexTransactions(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	// Genesis has no transactions, the lookup entries can't refer to it
+	var txs [][]*types.Transaction
+	for i := uint64(0); i < 10; i++ {
+		var list []*types.Transaction
+		if i > 0 {
+			list = append(list, types.NewTransaction(i, common.Address{byte(i)}, big.NewInt(1), 21000, big.NewInt(1), nil))
+		}
+		block := types.NewBlock(&types.Header{Number: new(big.Int).SetUint64(i)}, list, nil, nil)
+		WriteBlock(db, block)
+		WriteCanonicalHash(db, block.Hash(), i)
+		txs = append(txs, block.Transactions())
+	}
+	// check verifies that the transactions of the blocks from tail are indexed,
+	// and only those.
+	check := func(tail uint64) {
+		t.Helper()
+		if have := ReadTxIndexTail(db); have == nil || *have != tail {
+			t.Fatalf("index tail mismatch: have %v, want %d", have, tail)
+		}
+		for number, list := range txs {
+			for _, tx := range list {
+				entry := ReadTxLookupEntry(db, tx.Hash())
+				if uint64(number) < tail && entry != nil {
+					t.Errorf("block %d: transaction indexed below the tail", number)
+				}
+				if uint64(number) >= tail && (entry == nil || *entry != uint64(number)) {
+					t.Errorf("block %d: transaction lookup mismatch: have %v", number, entry)
+				}
+			}
+		}
+	}
+	if ReadTxIndexTail(db) != nil {
+		t.Fatalf("index tail present in a pristine database")
+	}
+	IndexTransactions(db, 0, 10, nil)
+	check(0)
+
+	UnindexTransactions(db, 0, 6, nil)
+	check(6)
+
+	IndexTransactions(db, 3, 6, nil)
+	check(3)
+
+	// Interrupted runs leave the index untouched
+	interrupt := make(chan struct{})
+	close(interrupt)
+	IndexTransactions(db, 0, 3, interrupt)
+	check(3)
+	UnindexTransactions(db, 3, 10, interrupt)
+	check(3)
+}
@@ -88,6 +88,11 @@ func (db *nofreezedb) TruncateAncients(items uint64) error {
 	return errNotSupported
 }
 
+// ExpireAncients returns an error as we don't have a backing chain freezer.
+func (db *nofreezedb) ExpireAncients(items uint64) error {
+	return errNotSupported
+}
+
 // Sync returns an error as we don't have a backing chain freezer.
 func (db *nofreezedb) Sync() error {
 	return errNotSupported
@@ -242,8 +247,12 @@ func InspectDatabase(db evrdb.Database) error {
 		txlookupSize        common.StorageSize
 		preimageSize        common.StorageSize
 		bloomBitsSize       common.StorageSize
+		logIndexSize        common.StorageSize
+		accountIndexSize    common.StorageSize
 		cliqueSnapsSize     common.StorageSize
 		tendermintSnapsSize common.StorageSize
+		accountSnapSize     common.StorageSize
+		storageSnapSize     common.StorageSize
 
 		// Ancient store statistics
 		ancientHeaders  common.StorageSize
@@ -286,6 +295,14 @@ func InspectDatabase(db evrdb.Database) error {
 			preimageSize += size
 		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
 			bloomBitsSize += size
+		case bytes.HasPrefix(key, logIndexPrefix) && (len(key) == len(logIndexPrefix)+common.AddressLength+8+common.HashLength || len(key) == len(logIndexPrefix)+2*common.HashLength+8):
+			logIndexSize += size
+		case bytes.HasPrefix(key, accountIndexPrefix) && len(key) == (len(accountIndexPrefix)+common.AddressLength+8+common.HashLength):
+			accountIndexSize += size
+		case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
+			accountSnapSize += size
+		case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
+			storageSnapSize += size
 		case bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength:
 			cliqueSnapsSize += size
 		case bytes.HasPrefix(key, tendermintPrefix) && len(key) == (len(tendermintPrefix)+common.HashLength):
@@ -298,7 +315,7 @@ func InspectDatabase(db evrdb.Database) error {
 			trieSize += size
 		default:
 			var accounted bool
-			for _, meta := range [][]byte{databaseVerisionKey, headHeaderKey, headBlockKey, headFastBlockKey, fastTrieProgressKey} {
+			for _, meta := range [][]byte{databaseVerisionKey, headHeaderKey, headBlockKey, headFastBlockKey, fastTrieProgressKey, snapshotRootKey, snapshotJournalKey, snapshotGeneratorKey, txIndexTailKey} {
 				if bytes.Equal(key, meta) {
 					metadata += size
 					accounted = true
@@ -333,8 +350,12 @@ func InspectDatabase(db evrdb.Database) error {
 		{"Key-Value store", "Block hash->number", hashNumPairing.String()},
 		{"Key-Value store", "Transaction index", txlookupSize.String()},
 		{"Key-Value store", "Bloombit index", bloomBitsSize.String()},
+		{"Key-Value store", "Log index", logIndexSize.String()},
+		{"Key-Value store", "Account index", accountIndexSize.String()},
 		{"Key-Value store", "Trie nodes", trieSize.String()},
 		{"Key-Value store", "Trie preimages", preimageSize.String()},
+		{"Key-Value store", "Account snapshot", accountSnapSize.String()},
+		{"Key-Value store", "Storage snapshot", storageSnapSize.String()},
 		{"Key-Value store", "Clique snapshots", cliqueSnapsSize.String()},
 		{"Key-Value store", "Tendermint snapshots", tendermintSnapsSize.String()},
 		{"Key-Value store", "Singleton metadata", metadata.String()},
@@ -0,0 +1,169 @@
+package rawdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb/boltdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb/leveldb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// Engines the persistent key-value store can be backed by.
+const (
+	LevelDBEngine = "leveldb"
+	BoltDBEngine  = "bolt"
+)
+
+// migrationBatchSize is the amount of data copied per batch when migrating a
+// database to another engine.
+const migrationBatchSize = 100 * evrdb.IdealBatchSize
+
+// DatabaseEngine returns the engine of the key-value store in the given
+// directory, or an empty string if there's none.
+func DatabaseEngine(dir string) string {
+	switch {
+	case common.FileExist(filepath.Join(dir, boltdb.FileName)):
+		return BoltDBEngine
+	case common.FileExist(filepath.Join(dir, "CURRENT")):
+		return LevelDBEngine
+	}
+	return ""
+}
+
+// NewKeyValueStore opens the persistent key-value store in the given directory
+// with the given engine, or creates it if none exists. If no engine is given,
+// an existing store is opened with its own engine and a new one uses LevelDB.
+func NewKeyValueStore(engine string, dir string, cache int, handles int, namespace string) (evrdb.KeyValueStore, error) {
+	existing := DatabaseEngine(dir)
+	if engine == "" {
+		engine = existing
+	}
+	if existing != "" && existing != engine {
+		return nil, fmt.Errorf("database in %s uses the %s engine, not %s", dir, existing, engine)
+	}
+	switch engine {
+	case LevelDBEngine, "":
+		return leveldb.New(dir, cache, handles, namespace)
+	case BoltDBEngine:
+		return boltdb.New(filepath.Join(dir, boltdb.FileName))
+	default:
+		return nil, fmt.Errorf("unknown database engine %q", engine)
+	}
+}
+
+// NewDatabaseWithEngine creates a persistent key-value database backed by the
+// given engine, without a freezer moving immutable chain segments into cold
+// storage.
+func NewDatabaseWithEngine(engine string, dir string, cache int, handles int, namespace string) (evrdb.Database, error) {
+	kvdb, err := NewKeyValueStore(engine, dir, cache, handles, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return NewDatabase(kvdb), nil
+}
+
+// NewDatabaseWithEngineAndFreezer creates a persistent key-value database
+// backed by the given engine, with a freezer moving immutable chain segments
+// into cold storage.
+func NewDatabaseWithEngineAndFreezer(engine string, dir string, cache int, handles int, freezer string, namespace string) (evrdb.Database, error) {
+	kvdb, err := NewKeyValueStore(engine, dir, cache, handles, namespace)
+	if err != nil {
+		return nil, err
+	}
+	frdb, err := NewDatabaseWithFreezer(kvdb, freezer, namespace)
+	if err != nil {
+		kvdb.Close()
+		return nil, err
+	}
+	return frdb, nil
+}
+
+// MigrateDatabase copies the LevelDB key-value store in the given directory into
+// a new store backed by the given engine, which replaces it once complete. The
+// LevelDB files are left in place, but aren't used anymore. The freezer is kept
+// as is, being independent of the engine.
+func MigrateDatabase(dir string, engine string, cache int, handles int) error {
+	if existing := DatabaseEngine(dir); existing != LevelDBEngine {
+		return fmt.Errorf("no leveldb database in %s", dir)
+	}
+	// Copy into a temporary store, so an interrupted migration is discarded
+	var (
+		file string
+		tmp  string
+	)
+	switch engine {
+	case BoltDBEngine:
+		file = filepath.Join(dir, boltdb.FileName)
+		tmp = file + ".tmp"
+	default:
+		return fmt.Errorf("can't migrate to database engine %q", engine)
+	}
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	src, err := leveldb.New(dir, cache, handles, "")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := boltdb.New(tmp)
+	if err != nil {
+		return err
+	}
+	if err := copyKeyValueStore(dst, src); err != nil {
+		dst.Close()
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// copyKeyValueStore copies all the entries of src into dst.
+func copyKeyValueStore(dst evrdb.KeyValueStore, src evrdb.Iteratee) error {
+	var (
+		count  int
+		size   common.StorageSize
+		start  = time.Now()
+		logged = time.Now()
+	)
+	it := src.NewIterator()
+	defer it.Release()
+
+	batch := dst.NewBatch()
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return err
+		}
+		count++
+		size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		if batch.ValueSize() >= migrationBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Migrating database", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Migrated database", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,55 @@
+package rawdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// Tests that a LevelDB database is migrated to another engine, which is then
+// used to open it.
+func TestMigrateDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rawdb-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewKeyValueStore("", dir, 0, 0, "")
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		db.Put([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	db.Close()
+
+	if engine := DatabaseEngine(dir); engine != LevelDBEngine {
+		t.Fatalf("engine mismatch: have %q, want %q", engine, LevelDBEngine)
+	}
+	if err := MigrateDatabase(dir, BoltDBEngine, 0, 0); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	if engine := DatabaseEngine(dir); engine != BoltDBEngine {
+		t.Fatalf("engine mismatch: have %q, want %q", engine, BoltDBEngine)
+	}
+	if _, err := NewKeyValueStore(LevelDBEngine, dir, 0, 0, ""); err == nil {
+		t.Fatalf("migrated database opened with the old engine")
+	}
+	if err := MigrateDatabase(dir, BoltDBEngine, 0, 0); err == nil {
+		t.Fatalf("migrated database migrated again")
+	}
+	db, err = NewKeyValueStore("", dir, 0, 0, "")
+	if err != nil {
+		t.Fatalf("failed to open migrated database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 1000; i++ {
+		have, err := db.Get([]byte(fmt.Sprintf("key-%d", i)))
+		if want := fmt.Sprintf("value-%d", i); err != nil || string(have) != want {
+			t.Fatalf("key %d: have %q (err %v), want %q", i, have, err, want)
+		}
+	}
+}
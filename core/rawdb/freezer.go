@@ -232,6 +232,18 @@ func (f *freezer) TruncateAncients(items uint64) error {
 	return nil
 }
 
+// ExpireAncients discards the bodies and receipts of the ancient blocks below the
+// given number. The tables are truncated a whole data file at a time, so some of
+// the blocks below the number may be kept.
+func (f *freezer) ExpireAncients(items uint64) error {
+	for _, kind := range []string{freezerBodiesTable, freezerReceiptTable} {
+		if err := f.tables[kind].truncateTail(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // sync flushes all data tables to disk.
 func (f *freezer) Sync() error {
 	var errs []error
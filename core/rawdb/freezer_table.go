@@ -23,6 +23,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 
@@ -235,8 +236,9 @@ func (t *freezerTable) repair() error {
 	t.tailId = firstIndex.offset
 	t.itemOffset = firstIndex.filenum
 
-	t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
-	lastIndex.unmarshalBinary(buffer)
+	if lastIndex, err = t.readIndex(uint64(offsetsSize/indexEntrySize - 1)); err != nil {
+		return err
+	}
 	t.head, err = t.openFile(lastIndex.filenum, openFreezerFileForAppend)
 	if err != nil {
 		return err
@@ -265,9 +267,10 @@ func (t *freezerTable) repair() error {
 				return err
 			}
 			offsetsSize -= indexEntrySize
-			t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
-			var newLastIndex indexEntry
-			newLastIndex.unmarshalBinary(buffer)
+			newLastIndex, err := t.readIndex(uint64(offsetsSize/indexEntrySize - 1))
+			if err != nil {
+				return err
+			}
 			// We might have slipped back into an earlier head-file here
 			if newLastIndex.filenum != lastIndex.filenum {
 				// Release earlier opened file
@@ -336,18 +339,24 @@ func (t *freezerTable) truncate(items uint64) error {
 	if err != nil {
 		return err
 	}
+	// Items deleted from the tail can't be kept, discard the entire table
+	if offset := uint64(atomic.LoadUint32(&t.itemOffset)); items < offset {
+		if err := t.reset(items); err != nil {
+			return err
+		}
+		return t.updateSizeCounter(oldSize)
+	}
 	// Something's out of sync, truncate the table's offset index
 	t.logger.Warn("Truncating freezer table", "items", t.items, "limit", items)
-	if err := truncateFreezerFile(t.index, int64(items+1)*indexEntrySize); err != nil {
+	pos := items - uint64(atomic.LoadUint32(&t.itemOffset))
+	if err := truncateFreezerFile(t.index, int64(pos+1)*indexEntrySize); err != nil {
 		return err
 	}
 	// Calculate the new expected size of the data file and truncate it
-	buffer := make([]byte, indexEntrySize)
-	if _, err := t.index.ReadAt(buffer, int64(items*indexEntrySize)); err != nil {
+	expected, err := t.readIndex(pos)
+	if err != nil {
 		return err
 	}
-	var expected indexEntry
-	expected.unmarshalBinary(buffer)
 
 	// We might need to truncate back to older files
 	if expected.filenum != t.headId {
@@ -371,13 +380,150 @@ func (t *freezerTable) truncate(items uint64) error {
 	atomic.StoreUint64(&t.items, items)
 	atomic.StoreUint32(&t.headBytes, expected.offset)
 
-	// Retrieve the new size and update the total size counter
-	newSize, err := t.sizeNolock()
+	return t.updateSizeCounter(oldSize)
+}
+
+// reset discards all the items of the table, the next appended item being the
+// given one. It assumes that the write-lock is held by the caller.
+func (t *freezerTable) reset(items uint64) error {
+	t.logger.Warn("Discarding freezer table", "items", t.items, "limit", items)
+
+	// Restart the table from the head file, as the tail can't be moved backwards
+	headId := atomic.LoadUint32(&t.headId)
+	for num := t.tailId; num < headId; num++ {
+		t.releaseFile(num)
+		os.Remove(t.fileName(num))
+	}
+	if err := t.writeIndex([]byte{}, items, headId); err != nil {
+		return err
+	}
+	if err := truncateFreezerFile(t.head, 0); err != nil {
+		return err
+	}
+	atomic.StoreUint32(&t.itemOffset, uint32(items))
+	atomic.StoreUint64(&t.items, items)
+	atomic.StoreUint32(&t.headBytes, 0)
+	t.tailId = headId
+	return nil
+}
+
+// truncateTail discards the data files only holding items older than the given
+// threshold number. Items are deleted a whole data file at a time, so the tail
+// may keep some items below the threshold.
+func (t *freezerTable) truncateTail(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil || t.head == nil {
+		return errClosed
+	}
+	offset := uint64(atomic.LoadUint32(&t.itemOffset))
+	if items > atomic.LoadUint64(&t.items) {
+		items = atomic.LoadUint64(&t.items)
+	}
+	if items <= offset {
+		return nil
+	}
+	// Find the data file holding the threshold item, and the first item in it
+	var (
+		entry indexEntry
+		err   error
+	)
+	if items == atomic.LoadUint64(&t.items) {
+		entry.filenum = atomic.LoadUint32(&t.headId)
+	} else if entry, err = t.readIndex(items - offset + 1); err != nil {
+		return err
+	}
+	tail := entry.filenum
+	if tail == t.tailId {
+		return nil
+	}
+	first := sort.Search(int(items-offset), func(pos int) bool {
+		entry, err := t.readIndex(uint64(pos) + 1)
+		return err != nil || entry.filenum >= tail
+	})
+	oldSize, err := t.sizeNolock()
 	if err != nil {
 		return err
 	}
-	t.sizeCounter.Dec(int64(oldSize - newSize))
+	// Rewrite the index without the discarded items, then delete their files
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	entries := make([]byte, stat.Size()-int64(first+1)*indexEntrySize)
+	if _, err := t.index.ReadAt(entries, int64(first+1)*indexEntrySize); err != nil {
+		return err
+	}
+	if err := t.writeIndex(entries, offset+uint64(first), tail); err != nil {
+		return err
+	}
+	for num := t.tailId; num < tail; num++ {
+		t.releaseFile(num)
+		if err := os.Remove(t.fileName(num)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	atomic.StoreUint32(&t.itemOffset, uint32(offset+uint64(first)))
+	t.tailId = tail
+
+	t.logger.Info("Discarded freezer table tail", "items", offset+uint64(first), "tail", tail)
+	return t.updateSizeCounter(oldSize)
+}
+
+// writeIndex atomically replaces the index file with the given entries, preceded
+// by the entry marking the tail of the table. It assumes that the write-lock is
+// held by the caller.
+func (t *freezerTable) writeIndex(entries []byte, itemOffset uint64, tailId uint32) error {
+	name := t.index.Name()
+	f, err := os.OpenFile(name+".tmp", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	tail := indexEntry{filenum: uint32(itemOffset), offset: tailId}
+	if _, err := f.Write(append(tail.marshallBinary(), entries...)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		return err
+	}
+	t.index.Close()
+	t.index, err = openFreezerFileForAppend(name)
+	return err
+}
 
+// readIndex reads the index entry at the given position, pointing to the end of
+// the item preceding it. The first entry marks the tail of the table, so the
+// end it points to is the start of the first data file.
+func (t *freezerTable) readIndex(pos uint64) (indexEntry, error) {
+	if pos == 0 {
+		return indexEntry{filenum: t.tailId}, nil
+	}
+	buffer := make([]byte, indexEntrySize)
+	if _, err := t.index.ReadAt(buffer, int64(pos*indexEntrySize)); err != nil {
+		return indexEntry{}, err
+	}
+	var entry indexEntry
+	entry.unmarshalBinary(buffer)
+	return entry, nil
+}
+
+// updateSizeCounter retrieves the new size of the table and updates the total
+// size counter. It assumes that the lock is held by the caller.
+func (t *freezerTable) updateSizeCounter(oldSize uint64) error {
+	newSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	t.sizeCounter.Dec(int64(oldSize) - int64(newSize))
 	return nil
 }
 
@@ -409,13 +555,7 @@ func (t *freezerTable) Close() error {
 func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error)) (f *os.File, err error) {
 	var exist bool
 	if f, exist = t.files[num]; !exist {
-		var name string
-		if t.noCompression {
-			name = fmt.Sprintf("%s.%04d.rdat", t.name, num)
-		} else {
-			name = fmt.Sprintf("%s.%04d.cdat", t.name, num)
-		}
-		f, err = opener(filepath.Join(t.path, name))
+		f, err = opener(t.fileName(num))
 		if err != nil {
 			return nil, err
 		}
@@ -424,6 +564,14 @@ func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error
 	return f, err
 }
 
+// fileName returns the path of the data file with the given number.
+func (t *freezerTable) fileName(num uint32) string {
+	if t.noCompression {
+		return filepath.Join(t.path, fmt.Sprintf("%s.%04d.rdat", t.name, num))
+	}
+	return filepath.Join(t.path, fmt.Sprintf("%s.%04d.cdat", t.name, num))
+}
+
 // releaseFile closes a file, and removes it from the open file cache.
 // Assumes that the caller holds the write lock
 func (t *freezerTable) releaseFile(num uint32) {
@@ -517,16 +665,14 @@ func (t *freezerTable) Append(item uint64, blob []byte) error {
 // getBounds returns the indexes for the item
 // returns start, end, filenumber and error
 func (t *freezerTable) getBounds(item uint64) (uint32, uint32, uint32, error) {
-	var startIdx, endIdx indexEntry
-	buffer := make([]byte, indexEntrySize)
-	if _, err := t.index.ReadAt(buffer, int64(item*indexEntrySize)); err != nil {
+	startIdx, err := t.readIndex(item)
+	if err != nil {
 		return 0, 0, 0, err
 	}
-	startIdx.unmarshalBinary(buffer)
-	if _, err := t.index.ReadAt(buffer, int64((item+1)*indexEntrySize)); err != nil {
+	endIdx, err := t.readIndex(item + 1)
+	if err != nil {
 		return 0, 0, 0, err
 	}
-	endIdx.unmarshalBinary(buffer)
 	if startIdx.filenum != endIdx.filenum {
 		// If a piece of data 'crosses' a data-file,
 		// it's actually in one piece on the second data-file.
@@ -547,11 +693,12 @@ func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
 		return nil, errOutOfBounds
 	}
 	// Ensure the item was not deleted from the tail either
+	t.lock.RLock()
 	offset := atomic.LoadUint32(&t.itemOffset)
 	if uint64(offset) > item {
+		t.lock.RUnlock()
 		return nil, errOutOfBounds
 	}
-	t.lock.RLock()
 	startOffset, endOffset, filenum, err := t.getBounds(item - uint64(offset))
 	if err != nil {
 		t.lock.RUnlock()
@@ -580,7 +727,13 @@ func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
 // has returns an indicator whether the specified number data
 // exists in the freezer table.
 func (t *freezerTable) has(number uint64) bool {
-	return atomic.LoadUint64(&t.items) > number
+	return atomic.LoadUint64(&t.items) > number && uint64(atomic.LoadUint32(&t.itemOffset)) <= number
+}
+
+// tail returns the number of the first item in the freezer table, the ones
+// before having been deleted.
+func (t *freezerTable) tail() uint64 {
+	return uint64(atomic.LoadUint32(&t.itemOffset))
 }
 
 // size returns the total data size in the freezer table.
@@ -398,6 +398,97 @@ func TestFreezerTruncate(t *testing.T) {
 
 }
 
+// TestFreezerTruncateTail tests that the old items of a table are discarded a
+// data file at a time, and that the table can still be truncated and appended
+// to afterwards.
+func TestFreezerTruncateTail(t *testing.T) {
+	t.Parallel()
+	rm, wm, sc := metrics.NewMeter(), metrics.NewMeter(), metrics.NewCounter()
+	fname := fmt.Sprintf("truncatetail-%d", rand.Uint64())
+
+	f, err := newCustomTable(os.TempDir(), fname, rm, wm, sc, 50, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write 15 bytes 30 times, 3 items per data file
+	for x := 0; x < 30; x++ {
+		if err := f.Append(uint64(x), getChunk(15, x)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Item 10 is the second one of data file 3, so item 9 is kept too
+	if err := f.truncateTail(10); err != nil {
+		t.Fatal(err)
+	}
+	check := func(f *freezerTable, tail uint64, items uint64) {
+		t.Helper()
+		if have := f.tail(); have != tail {
+			t.Fatalf("tail mismatch: have %d, want %d", have, tail)
+		}
+		if have := f.items; have != items {
+			t.Fatalf("items mismatch: have %d, want %d", have, items)
+		}
+		for x := uint64(0); x < items+1; x++ {
+			blob, err := f.Retrieve(x)
+			if x < tail || x >= items {
+				if err == nil || f.has(x) {
+					t.Fatalf("item %d: discarded item retrieved", x)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("item %d: failed to retrieve: %v", x, err)
+			}
+			if !bytes.Equal(blob, getChunk(15, int(x))) {
+				t.Fatalf("item %d: have %x, want %x", x, blob, getChunk(15, int(x)))
+			}
+		}
+	}
+	check(f, 9, 30)
+	if _, err := os.Stat(filepath.Join(os.TempDir(), fmt.Sprintf("%s.0002.rdat", fname))); !os.IsNotExist(err) {
+		t.Fatalf("discarded data file still present: %v", err)
+	}
+	// Discarding items already gone is a noop
+	if err := f.truncateTail(5); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 9, 30)
+	f.Close()
+
+	// Reopen, the tail must survive and the table must be extensible
+	if f, err = newCustomTable(os.TempDir(), fname, rm, wm, sc, 50, true); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 9, 30)
+
+	if err := f.Append(30, getChunk(15, 30)); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 9, 31)
+
+	// Truncating the head keeps the tail, unless going below it
+	if err := f.truncate(20); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 9, 20)
+	if err := f.truncate(5); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 5, 5)
+	f.Close()
+
+	if f, err = newCustomTable(os.TempDir(), fname, rm, wm, sc, 50, true); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	check(f, 5, 5)
+
+	if err := f.Append(5, getChunk(15, 5)); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 5, 6)
+}
+
 // TestFreezerRepairFirstFile tests a head file with the very first item only half-written.
 // That will rewind the index, and _should_ truncate the head file
 func TestFreezerRepairFirstFile(t *testing.T) {
@@ -86,6 +86,12 @@ func (t *table) TruncateAncients(items uint64) error {
 	return t.db.TruncateAncients(items)
 }
 
+// ExpireAncients is a noop passthrough that just forwards the request to the
+// underlying database.
+func (t *table) ExpireAncients(items uint64) error {
+	return t.db.ExpireAncients(items)
+}
+
 // Sync is a noop passthrough that just forwards the request to the underlying
 // database.
 func (t *table) Sync() error {
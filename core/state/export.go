@@ -0,0 +1,235 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/trie"
+)
+
+// exportChunkSize is the approximate size of the chunks of an exported state, only
+// exceeded by chunks holding a single account with a larger storage.
+const exportChunkSize = 4 * 1024 * 1024
+
+var (
+	// ErrStateChunkChecksum is returned if an exported state chunk is corrupted,
+	// missing or out of order.
+	ErrStateChunkChecksum = errors.New("state chunk checksum mismatch")
+
+	// ErrStateRootMismatch is returned if an imported state doesn't hash to the
+	// expected root.
+	ErrStateRootMismatch = errors.New("imported state root mismatch")
+)
+
+// exportSlot is a storage slot of an exported account, as stored in its storage trie.
+type exportSlot struct {
+	Hash  common.Hash // Hash of the slot key
+	Value []byte
+}
+
+// exportAccount is an account of an exported state. The account is kept as stored
+// in the account trie so that legacy accounts without owner and providers are
+// rebuilt byte for byte.
+type exportAccount struct {
+	Hash    common.Hash // Hash of the address
+	Account []byte      // RLP encoded account, as stored in the account trie
+	Code    []byte
+	Storage []exportSlot
+}
+
+// exportChunk is a chunk of an exported state. Its checksum hashes the checksum of
+// the previous chunk, the state root for the first one, with its accounts, so that
+// dropped or reordered chunks are detected. The last chunk has no accounts.
+type exportChunk struct {
+	Accounts []exportAccount
+	Checksum common.Hash
+}
+
+// decodeAccount decodes an account of the account trie, falling back to accounts
+// stored without owner and providers.
+func decodeAccount(enc []byte) (Account, error) {
+	var data Account
+	if err := rlp.DecodeBytes(enc, &data); err != nil {
+		var dataWithoutProvider AccountWithoutProvider
+		if retryErr := rlp.DecodeBytes(enc, &dataWithoutProvider); retryErr != nil {
+			return Account{}, err
+		}
+		data = dataWithoutProvider.ToAccount()
+	}
+	return data, nil
+}
+
+// ExportState writes the state with the given root into w, as a stream of RLP
+// encoded chunks of accounts with their code and storage, in the order of the
+// account trie.
+func ExportState(db Database, root common.Hash, w io.Writer) error {
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	var (
+		accounts []exportAccount
+		size     int
+		checksum = root
+	)
+	flush := func() error {
+		enc, err := rlp.EncodeToBytes(accounts)
+		if err != nil {
+			return err
+		}
+		checksum = crypto.Keccak256Hash(checksum[:], enc)
+		if err := rlp.Encode(w, &exportChunk{Accounts: accounts, Checksum: checksum}); err != nil {
+			return err
+		}
+		accounts, size = nil, 0
+		return nil
+	}
+	it := trie.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		data, err := decodeAccount(it.Value)
+		if err != nil {
+			return err
+		}
+		account := exportAccount{Hash: common.BytesToHash(it.Key), Account: common.CopyBytes(it.Value)}
+		if codeHash := common.BytesToHash(data.CodeHash); codeHash != emptyCode {
+			if account.Code, err = db.ContractCode(account.Hash, codeHash); err != nil {
+				return err
+			}
+		}
+		if data.Root != emptyRoot {
+			st, err := db.OpenStorageTrie(account.Hash, data.Root)
+			if err != nil {
+				return err
+			}
+			storageIt := trie.NewIterator(st.NodeIterator(nil))
+			for storageIt.Next() {
+				account.Storage = append(account.Storage, exportSlot{Hash: common.BytesToHash(storageIt.Key), Value: common.CopyBytes(storageIt.Value)})
+				size += common.HashLength + len(storageIt.Value)
+			}
+			if storageIt.Err != nil {
+				return storageIt.Err
+			}
+		}
+		accounts = append(accounts, account)
+		size += common.HashLength + len(account.Account) + len(account.Code)
+
+		if size >= exportChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+	if len(accounts) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	// Terminate the stream with an empty chunk, so truncated exports are detected
+	return flush()
+}
+
+// ImportState rebuilds the state with the given root into the trie database from
+// a stream written by ExportState. The checksum of every chunk, the code hash and
+// storage root of every account and the final state root are verified, the trie
+// nodes are flushed to disk chunk by chunk.
+func ImportState(db Database, root common.Hash, s *rlp.Stream) error {
+	triedb := db.TrieDB()
+	accTrie, err := trie.New(common.Hash{}, triedb)
+	if err != nil {
+		return err
+	}
+	// Reference the storage tries and code of the accounts to flush them with the
+	// account trie
+	onleaf := func(leaf []byte, parent common.Hash) error {
+		account, err := decodeAccount(leaf)
+		if err != nil {
+			return nil
+		}
+		if account.Root != emptyRoot {
+			triedb.Reference(account.Root, parent)
+		}
+		if code := common.BytesToHash(account.CodeHash); code != emptyCode {
+			triedb.Reference(code, parent)
+		}
+		return nil
+	}
+	var (
+		checksum = root
+		last     *common.Hash
+		imported = emptyRoot
+	)
+	for {
+		var chunk exportChunk
+		if err := s.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("state export truncated: %v", err)
+			}
+			return err
+		}
+		enc, err := rlp.EncodeToBytes(chunk.Accounts)
+		if err != nil {
+			return err
+		}
+		if checksum = crypto.Keccak256Hash(checksum[:], enc); checksum != chunk.Checksum {
+			return ErrStateChunkChecksum
+		}
+		if len(chunk.Accounts) == 0 {
+			break
+		}
+		for i := range chunk.Accounts {
+			account := &chunk.Accounts[i]
+			if last != nil && account.Hash.Big().Cmp(last.Big()) <= 0 {
+				return fmt.Errorf("account %x out of order", account.Hash)
+			}
+			last = &account.Hash
+
+			data, err := decodeAccount(account.Account)
+			if err != nil {
+				return fmt.Errorf("account %x: %v", account.Hash, err)
+			}
+			if codeHash := common.BytesToHash(data.CodeHash); codeHash != emptyCode {
+				if crypto.Keccak256Hash(account.Code) != codeHash {
+					return fmt.Errorf("account %x: code hash mismatch", account.Hash)
+				}
+				triedb.InsertBlob(codeHash, account.Code)
+			}
+			st, err := trie.New(common.Hash{}, triedb)
+			if err != nil {
+				return err
+			}
+			for _, slot := range account.Storage {
+				if err := st.TryUpdate(slot.Hash[:], slot.Value); err != nil {
+					return err
+				}
+			}
+			storageRoot, err := st.Commit(nil)
+			if err != nil {
+				return err
+			}
+			if storageRoot != data.Root {
+				return fmt.Errorf("account %x: storage root mismatch", account.Hash)
+			}
+			if err := accTrie.TryUpdate(account.Hash[:], account.Account); err != nil {
+				return err
+			}
+		}
+		// Flush the partial account trie, it is resolved from disk from now on
+		if imported, err = accTrie.Commit(onleaf); err != nil {
+			return err
+		}
+		if err := triedb.Commit(imported, false); err != nil {
+			return err
+		}
+	}
+	if imported != root {
+		return ErrStateRootMismatch
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	exportContract = common.HexToAddress("0x1000")
+	exportOwner    = common.HexToAddress("0x2000")
+	exportProvider = common.HexToAddress("0x3000")
+	exportLegacy   = common.HexToAddress("0x4000")
+)
+
+// makeExportTestState creates a state with plain, contract, enterprise and legacy
+// accounts, committed to disk.
+func makeExportTestState(t *testing.T) (Database, common.Hash) {
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, _ := New(common.Hash{}, db)
+
+	for i := byte(1); i < 64; i++ {
+		addr := common.BytesToAddress([]byte{0x10, i})
+		statedb.AddBalance(addr, big.NewInt(int64(i)))
+		statedb.SetNonce(addr, uint64(i))
+	}
+	statedb.CreateAccount(exportContract, types.CreateAccountOption{OwnerAddress: &exportOwner, ProviderAddress: &exportProvider})
+	statedb.SetCode(exportContract, []byte{0x60, 0x00, 0x60, 0x00, 0xf3})
+	for i := int64(1); i < 32; i++ {
+		statedb.SetState(exportContract, common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(i*i)))
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	// Add an account stored without owner and providers
+	tr, _ := db.OpenTrie(root)
+	enc, _ := rlp.EncodeToBytes(&AccountWithoutProvider{Nonce: 1, Balance: big.NewInt(100), Root: emptyRoot, CodeHash: emptyCodeHash})
+	if err := tr.TryUpdate(exportLegacy.Bytes(), enc); err != nil {
+		t.Fatalf("failed to insert legacy account: %v", err)
+	}
+	if root, err = tr.Commit(nil); err != nil {
+		t.Fatalf("failed to commit legacy account: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	return db, root
+}
+
+func TestExportImportState(t *testing.T) {
+	db, root := makeExportTestState(t)
+
+	var buf bytes.Buffer
+	if err := ExportState(db, root, &buf); err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+	diskdb := rawdb.NewMemoryDatabase()
+	if err := ImportState(NewDatabase(diskdb), root, rlp.NewStream(bytes.NewReader(buf.Bytes()), 0)); err != nil {
+		t.Fatalf("failed to import state: %v", err)
+	}
+	// Read back the imported state from disk only
+	statedb, err := New(root, NewDatabase(diskdb))
+	if err != nil {
+		t.Fatalf("failed to open imported state: %v", err)
+	}
+	if owner := statedb.GetOwner(exportContract); owner == nil || *owner != exportOwner {
+		t.Errorf("owner mismatch: have %v, want %x", owner, exportOwner)
+	}
+	if providers := statedb.GetProviders(exportContract); len(providers) != 1 || *providers[0] != exportProvider {
+		t.Errorf("providers mismatch: have %x, want [%x]", providers, exportProvider)
+	}
+	if code := statedb.GetCode(exportContract); !bytes.Equal(code, []byte{0x60, 0x00, 0x60, 0x00, 0xf3}) {
+		t.Errorf("code mismatch: have %x", code)
+	}
+	if value := statedb.GetState(exportContract, common.BigToHash(big.NewInt(7))); value != common.BigToHash(big.NewInt(49)) {
+		t.Errorf("storage mismatch: have %x, want %x", value, common.BigToHash(big.NewInt(49)))
+	}
+	if balance := statedb.GetBalance(exportLegacy); balance.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("legacy account balance mismatch: have %v, want 100", balance)
+	}
+	if nonce := statedb.GetNonce(common.BytesToAddress([]byte{0x10, 42})); nonce != 42 {
+		t.Errorf("nonce mismatch: have %d, want 42", nonce)
+	}
+}
+
+func TestImportStateCorrupted(t *testing.T) {
+	db, root := makeExportTestState(t)
+
+	var buf bytes.Buffer
+	if err := ExportState(db, root, &buf); err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+	export := buf.Bytes()
+
+	// A flipped byte in an account breaks the chunk checksum
+	corrupted := common.CopyBytes(export)
+	corrupted[len(corrupted)/2] ^= 0xff
+	if err := ImportState(NewDatabase(rawdb.NewMemoryDatabase()), root, rlp.NewStream(bytes.NewReader(corrupted), 0)); err == nil {
+		t.Errorf("corrupted export imported")
+	}
+	// A missing terminating chunk is detected
+	var chunk exportChunk
+	stream := rlp.NewStream(bytes.NewReader(export), 0)
+	if err := stream.Decode(&chunk); err != nil {
+		t.Fatalf("failed to decode chunk: %v", err)
+	}
+	enc, _ := rlp.EncodeToBytes(&chunk)
+	if err := ImportState(NewDatabase(rawdb.NewMemoryDatabase()), root, rlp.NewStream(bytes.NewReader(enc), 0)); err == nil {
+		t.Errorf("truncated export imported")
+	}
+	// A state exported from another root is refused
+	if err := ImportState(NewDatabase(rawdb.NewMemoryDatabase()), common.Hash{0x01}, rlp.NewStream(bytes.NewReader(export), 0)); err != ErrStateChunkChecksum {
+		t.Errorf("export of another root: have %v, want %v", err, ErrStateChunkChecksum)
+	}
+}
@@ -90,7 +90,8 @@ type (
 		account *common.Address
 	}
 	resetObjectChange struct {
-		prev *stateObject
+		prev         *stateObject
+		prevdestruct bool
 	}
 	suicideChange struct {
 		account     *common.Address
@@ -115,6 +116,14 @@ type (
 		account            *common.Address
 		prevcode, prevhash []byte
 	}
+	ownerChange struct {
+		account *common.Address
+		prev    *common.Address
+	}
+	providersChange struct {
+		account *common.Address
+		prev    []*common.Address
+	}
 
 	// Changes to other state values.
 	refundChange struct {
@@ -144,6 +153,9 @@ func (ch createObjectChange) dirtied() *common.Address {
 
 func (ch resetObjectChange) revert(s *StateDB) {
 	s.setStateObject(ch.prev)
+	if !ch.prevdestruct && s.snap != nil {
+		delete(s.snapDestructs, ch.prev.addrHash)
+	}
 }
 
 func (ch resetObjectChange) dirtied() *common.Address {
@@ -195,6 +207,22 @@ func (ch codeChange) dirtied() *common.Address {
 	return ch.account
 }
 
+func (ch ownerChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setOwner(ch.prev)
+}
+
+func (ch ownerChange) dirtied() *common.Address {
+	return ch.account
+}
+
+func (ch providersChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setProviders(ch.prev)
+}
+
+func (ch providersChange) dirtied() *common.Address {
+	return ch.account
+}
+
 func (ch storageChange) revert(s *StateDB) {
 	s.getStateObject(*ch.account).setState(ch.key, ch.prevalue)
 }
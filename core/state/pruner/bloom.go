@@ -0,0 +1,46 @@
+package pruner
+
+import (
+	"encoding/binary"
+
+	"github.com/steakknife/bloomfilter"
+)
+
+// stateBloomHasher is a wrapper around a byte blob to satisfy the interface API
+// requirements of the bloom library used. It's used to convert a trie node or
+// code hash into a 64 bit mini hash.
+type stateBloomHasher []byte
+
+func (f stateBloomHasher) Write(p []byte) (n int, err error) { panic("not implemented") }
+func (f stateBloomHasher) Sum(b []byte) []byte               { panic("not implemented") }
+func (f stateBloomHasher) Reset()                            { panic("not implemented") }
+func (f stateBloomHasher) BlockSize() int                    { panic("not implemented") }
+func (f stateBloomHasher) Size() int                         { return 8 }
+func (f stateBloomHasher) Sum64() uint64                     { return binary.BigEndian.Uint64(f) }
+
+// stateBloom is a bloom filter of the trie nodes and contract codes belonging
+// to the retained states. False positives only leave some stale entries on
+// disk, while a retained entry is never reported missing.
+type stateBloom struct {
+	bloom *bloomfilter.Filter
+}
+
+// newStateBloom creates a bloom filter of the given size (in megabytes). The
+// bloom is hard coded to use 4 filters.
+func newStateBloom(megabytes uint64) (*stateBloom, error) {
+	bloom, err := bloomfilter.New(megabytes*1024*1024*8, 4)
+	if err != nil {
+		return nil, err
+	}
+	return &stateBloom{bloom: bloom}, nil
+}
+
+// Put marks the given hash as retained.
+func (b *stateBloom) Put(hash []byte) {
+	b.bloom.Add(stateBloomHasher(hash))
+}
+
+// Contain returns whether the given hash may be retained.
+func (b *stateBloom) Contain(hash []byte) bool {
+	return b.bloom.Contains(stateBloomHasher(hash))
+}
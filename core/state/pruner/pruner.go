@@ -0,0 +1,222 @@
+// Package pruner deletes the trie nodes and contract codes of stale states from
+// the database, keeping only the states of a set of retained roots.
+package pruner
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// ErrPruneAborted is returned if a pruning is aborted before its sweep finished.
+var ErrPruneAborted = errors.New("state pruning aborted")
+
+// Pruner is a mark and sweep garbage collector of the state stored on disk. The
+// trie nodes and codes reachable from the retained roots are marked in a bloom
+// filter, after which every other node and code is deleted.
+//
+// The pruner can run while new states are committed, as long as the nodes they
+// flush to disk are reported through Keep: a flushed node is either marked
+// before its sweeping batch is checked, or written after that batch deleted it.
+type Pruner struct {
+	diskdb    evrdb.KeyValueStore // Key-value store holding the state to prune
+	statedb   state.Database      // State database to resolve the retained tries through
+	bloomSize uint64              // Megabytes of the bloom filter of the retained nodes
+
+	bloom   *stateBloom // Retained nodes of the running pruning, nil if not pruning
+	lock    sync.Mutex  // Lock protecting the bloom against concurrent node writes
+	running sync.Mutex  // Lock serializing the prunings
+}
+
+// New creates a pruner of the state stored in diskdb, marking the retained tries
+// through statedb with a bloom filter of the given size (in megabytes).
+func New(diskdb evrdb.KeyValueStore, statedb state.Database, bloomSize uint64) *Pruner {
+	return &Pruner{
+		diskdb:    diskdb,
+		statedb:   statedb,
+		bloomSize: bloomSize,
+	}
+}
+
+// Keep marks a trie node about to be written to disk as retained by the running
+// pruning, if any. It is meant to be installed as the write hook of the trie
+// database the new states are committed to.
+func (p *Pruner) Keep(hash common.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.bloom != nil {
+		p.bloom.Put(hash[:])
+	}
+}
+
+// Prepare allocates the bloom filter of the next pruning, retaining the nodes
+// written to disk from then on. If new states are committed concurrently, it
+// must be called before choosing the roots to retain, otherwise the states
+// committed until the pruning starts are deleted. It must not be called while
+// a pruning is running.
+func (p *Pruner) Prepare() error {
+	bloom, err := newStateBloom(p.bloomSize)
+	if err != nil {
+		return err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.bloom = bloom
+	return nil
+}
+
+// Prune deletes all the trie nodes and codes not reachable from the given roots.
+// The tries of the roots must be complete, otherwise nothing is deleted. The
+// pruning is interrupted if the abort channel is closed.
+func (p *Pruner) Prune(roots []common.Hash, abort <-chan struct{}) error {
+	p.running.Lock()
+	defer p.running.Unlock()
+
+	p.lock.Lock()
+	prepared := p.bloom != nil
+	p.lock.Unlock()
+
+	if !prepared {
+		if err := p.Prepare(); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		p.lock.Lock()
+		p.bloom = nil
+		p.lock.Unlock()
+	}()
+	start := time.Now()
+	for _, root := range roots {
+		if err := p.mark(root, abort); err != nil {
+			return err
+		}
+	}
+	log.Info("Marked retained state", "roots", len(roots), "elapsed", common.PrettyDuration(time.Since(start)))
+	return p.sweep(abort)
+}
+
+// mark adds all the trie nodes and codes of the state with the given root to
+// the bloom filter.
+func (p *Pruner) mark(root common.Hash, abort <-chan struct{}) error {
+	statedb, err := state.New(root, p.statedb)
+	if err != nil {
+		return err
+	}
+	var (
+		nodes  int
+		logged = time.Now()
+	)
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+		// Embedded nodes have no hash and aren't stored on their own
+		if it.Hash == (common.Hash{}) {
+			continue
+		}
+		p.Keep(it.Hash)
+		nodes++
+
+		if nodes%10000 == 0 {
+			select {
+			case <-abort:
+				return ErrPruneAborted
+			default:
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Marking retained state", "root", root, "nodes", nodes)
+				logged = time.Now()
+			}
+		}
+	}
+	if it.Error != nil {
+		return it.Error
+	}
+	log.Debug("Marked retained state", "root", root, "nodes", nodes)
+	return nil
+}
+
+// sweep deletes every trie node and code missing from the bloom filter.
+func (p *Pruner) sweep(abort <-chan struct{}) error {
+	var (
+		pending [][]byte
+		deleted int
+		size    common.StorageSize
+		start   = time.Now()
+		logged  = time.Now()
+	)
+	// flush deletes the pending keys, checking them again against the bloom in
+	// case they were written meanwhile
+	flush := func() error {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+
+		batch := p.diskdb.NewBatch()
+		for _, key := range pending {
+			if p.bloom.Contain(key) {
+				continue
+			}
+			if err := batch.Delete(key); err != nil {
+				return err
+			}
+			deleted++
+		}
+		pending = pending[:0]
+		return batch.Write()
+	}
+	it := p.diskdb.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		// Trie nodes and codes are keyed by the hash of their content, any other
+		// entry keyed by a bare hash is left alone
+		key := it.Key()
+		if len(key) != common.HashLength || p.retained(key) {
+			continue
+		}
+		if !bytes.Equal(crypto.Keccak256(it.Value()), key) {
+			continue
+		}
+		pending = append(pending, common.CopyBytes(key))
+		size += common.StorageSize(len(key) + len(it.Value()))
+
+		if len(pending)*common.HashLength >= evrdb.IdealBatchSize {
+			select {
+			case <-abort:
+				return ErrPruneAborted
+			default:
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Pruning stale state", "deleted", deleted, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	log.Info("Pruned stale state", "deleted", deleted, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// retained returns whether the given key may be retained by the running pruning.
+func (p *Pruner) retained(key []byte) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.bloom != nil && p.bloom.Contain(key)
+}
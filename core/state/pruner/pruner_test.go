@@ -0,0 +1,173 @@
+package pruner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+)
+
+// commitState applies the given balance to a set of accounts, along with some
+// storage and code, on top of root and flushes the result to disk.
+func commitState(t *testing.T, db state.Database, root common.Hash, balance int64) common.Hash {
+	statedb, err := state.New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	for i := byte(0); i < 50; i++ {
+		addr := common.Address{i}
+		statedb.SetBalance(addr, big.NewInt(balance))
+		if i%5 == 0 {
+			statedb.SetState(addr, common.Hash{i}, common.BigToHash(big.NewInt(balance)))
+			statedb.SetCode(addr, []byte{i, byte(balance)})
+		}
+	}
+	root, err = statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return root
+}
+
+// stateKeys returns the trie nodes and codes of a state.
+func stateKeys(t *testing.T, db state.Database, root common.Hash) map[common.Hash]struct{} {
+	statedb, err := state.New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open state %x: %v", root, err)
+	}
+	keys := make(map[common.Hash]struct{})
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+		if it.Hash != (common.Hash{}) {
+			keys[it.Hash] = struct{}{}
+		}
+	}
+	if it.Error != nil {
+		t.Fatalf("failed to iterate state %x: %v", root, it.Error)
+	}
+	return keys
+}
+
+// diskKeys returns all the entries keyed by a bare hash.
+func diskKeys(db evrdb.Iteratee) map[common.Hash]struct{} {
+	keys := make(map[common.Hash]struct{})
+	it := db.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		if len(it.Key()) == common.HashLength {
+			keys[common.BytesToHash(it.Key())] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// Tests that pruning deletes exactly the nodes and codes of the states which
+// aren't retained.
+func TestPrune(t *testing.T) {
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		db     = state.NewDatabase(diskdb)
+		roots  []common.Hash
+	)
+	for i := int64(1); i <= 4; i++ {
+		var parent common.Hash
+		if len(roots) > 0 {
+			parent = roots[len(roots)-1]
+		}
+		roots = append(roots, commitState(t, db, parent, i))
+	}
+	// Unrelated entries must survive the pruning, even if keyed by a bare hash
+	rawdb.WriteSnapshotRoot(diskdb, roots[0])
+	unrelated := crypto.Keccak256Hash([]byte("unrelated"))
+	diskdb.Put(unrelated[:], []byte{0x01})
+
+	retained := append([]common.Hash{roots[1]}, roots[3])
+	want := map[common.Hash]struct{}{unrelated: {}}
+	for _, root := range retained {
+		for hash := range stateKeys(t, db, root) {
+			want[hash] = struct{}{}
+		}
+	}
+	if have := diskKeys(diskdb); len(have) <= len(want) {
+		t.Fatalf("nothing to prune: %d entries on disk, %d retained", len(have), len(want))
+	}
+	// A fresh database is used to avoid reading pruned nodes from the caches
+	pruner := New(diskdb, state.NewDatabase(diskdb), 1)
+	if err := pruner.Prune(retained, nil); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	have := diskKeys(diskdb)
+	for hash := range want {
+		if _, ok := have[hash]; !ok {
+			t.Errorf("retained entry %x deleted", hash)
+		}
+	}
+	for hash := range have {
+		if _, ok := want[hash]; !ok {
+			t.Errorf("stale entry %x not deleted", hash)
+		}
+	}
+	for _, root := range retained {
+		stateKeys(t, state.NewDatabase(diskdb), root)
+	}
+	if _, err := state.New(roots[0], state.NewDatabase(diskdb)); err == nil {
+		t.Errorf("pruned state %x still available", roots[0])
+	}
+	if root := rawdb.ReadSnapshotRoot(diskdb); root != roots[0] {
+		t.Errorf("unrelated entry deleted")
+	}
+}
+
+// Tests that a pruning with an unavailable retained state deletes nothing.
+func TestPruneMissingRoot(t *testing.T) {
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		db     = state.NewDatabase(diskdb)
+		root   = commitState(t, db, common.Hash{}, 1)
+	)
+	commitState(t, db, root, 2)
+	before := len(diskKeys(diskdb))
+
+	pruner := New(diskdb, state.NewDatabase(diskdb), 1)
+	if err := pruner.Prune([]common.Hash{crypto.Keccak256Hash([]byte("missing"))}, nil); err == nil {
+		t.Fatalf("pruning succeeded with a missing root")
+	}
+	if after := len(diskKeys(diskdb)); after != before {
+		t.Errorf("entries deleted: have %d, want %d", after, before)
+	}
+}
+
+// Tests that the nodes written during a pruning are retained.
+func TestPruneKeep(t *testing.T) {
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		db     = state.NewDatabase(diskdb)
+		root   = commitState(t, db, common.Hash{}, 1)
+	)
+	pruner := New(diskdb, state.NewDatabase(diskdb), 1)
+
+	// Nodes reported while no pruning runs are ignored
+	node := []byte("written")
+	written := crypto.Keccak256Hash(node)
+	pruner.Keep(written)
+
+	pruner.bloom, _ = newStateBloom(1)
+	pruner.Keep(written)
+	diskdb.Put(written[:], node)
+	if err := pruner.sweep(nil); err != nil {
+		t.Fatalf("failed to sweep: %v", err)
+	}
+	if ok, _ := diskdb.Has(written[:]); !ok {
+		t.Errorf("node written during pruning deleted")
+	}
+	if ok, _ := diskdb.Has(root[:]); ok {
+		t.Errorf("unmarked node not deleted")
+	}
+}
@@ -0,0 +1,223 @@
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/trie"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// cacheItemSize is the estimated memory used by a cached snapshot entry, to turn
+// the allowance in megabytes into a number of cached entries.
+const cacheItemSize = 128
+
+// newCache creates the read cache of the disk layer, within the given allowance
+// in megabytes.
+func newCache(megabytes int) *lru.Cache {
+	size := megabytes * 1024 * 1024 / cacheItemSize
+	if size < 1 {
+		size = 1
+	}
+	cache, _ := lru.New(size)
+	return cache
+}
+
+// diskLayer is a low level persistent snapshot built on top of a key-value store.
+type diskLayer struct {
+	diskdb evrdb.KeyValueStore // Key-value store containing the base snapshot
+	triedb *trie.Database      // Trie node cache for reconstruction purposes
+	cache  *lru.Cache          // Cache to avoid hitting the disk for direct access
+
+	root  common.Hash // Root hash of the base snapshot
+	stale bool        // Signals that the layer became stale (state progressed)
+
+	genMarker []byte           // Marker of the last generated account, nil if done
+	genAbort  chan chan []byte // Notification channel to abort generating the snapshot in this layer
+
+	lock sync.RWMutex
+}
+
+// Root returns root hash for which this snapshot was made.
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Parent always returns nil as there's no layer below the disk.
+func (dl *diskLayer) Parent() snapshot {
+	return nil
+}
+
+// Stale return whether this layer has become stale (was flattened across) or if
+// it's still live.
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// markStale flags the layer as stale, its readers being sent to the trie.
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// covered returns whether the snapshot generation went past the given account.
+// The caller must hold the layer lock.
+func (dl *diskLayer) covered(accountHash common.Hash) bool {
+	return dl.genMarker == nil || bytes.Compare(accountHash[:], dl.genMarker) <= 0
+}
+
+// Account directly retrieves the RLP encoded account associated with a
+// particular hash in the snapshot.
+func (dl *diskLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(hash) {
+		return nil, ErrNotCoveredYet
+	}
+	key := string(hash[:])
+	if blob, found := dl.cache.Get(key); found {
+		return blob.([]byte), nil
+	}
+	blob := rawdb.ReadAccountSnapshot(dl.diskdb, hash)
+	dl.cache.Add(key, blob)
+	return blob, nil
+}
+
+// Storage directly retrieves the RLP encoded storage slot associated with a
+// particular hash within a particular account.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(accountHash) {
+		return nil, ErrNotCoveredYet
+	}
+	key := string(append(accountHash[:], storageHash[:]...))
+	if blob, found := dl.cache.Get(key); found {
+		return blob.([]byte), nil
+	}
+	blob := rawdb.ReadStorageSnapshot(dl.diskdb, accountHash, storageHash)
+	dl.cache.Add(key, blob)
+	return blob, nil
+}
+
+// Update creates a new layer on top of the existing snapshot diff tree with
+// the specified data items. Note, the maps are retained by the method to avoid
+// copying everything.
+func (dl *diskLayer) Update(blockRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer {
+	return newDiffLayer(dl, blockRoot, destructs, accounts, storage)
+}
+
+// stopGeneration aborts the generation running on the layer, if any, waiting
+// for its progress to be persisted.
+func (dl *diskLayer) stopGeneration() {
+	if dl.genAbort == nil {
+		return
+	}
+	abort := make(chan []byte)
+	dl.genAbort <- abort
+	<-abort
+	dl.genAbort = nil
+}
+
+// diffToDisk merges a bottom-most diff into the persistent disk layer underneath
+// it. The method will panic if called onto a non-bottom-most diff layer.
+func diffToDisk(bottom *diffLayer, base *diskLayer) *diskLayer {
+	// Stop the generation on the disk layer, it is resumed on the new root
+	base.stopGeneration()
+
+	batch := base.diskdb.NewBatch()
+	flush := func() {
+		if batch.ValueSize() > evrdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				panic(err)
+			}
+			batch.Reset()
+		}
+	}
+	// The entries past the generation marker are left to the generator
+	marker := base.genMarker
+	covered := func(hash common.Hash) bool {
+		return marker == nil || bytes.Compare(hash[:], marker) <= 0
+	}
+	// Destroy the destructed accounts with all their storage
+	for hash := range bottom.destructSet {
+		if !covered(hash) {
+			continue
+		}
+		rawdb.DeleteAccountSnapshot(batch, hash)
+		base.cache.Remove(string(hash[:]))
+
+		it := rawdb.IterateStorageSnapshots(base.diskdb, hash)
+		for it.Next() {
+			key := it.Key()
+			if len(key) != len(rawdb.SnapshotStoragePrefix)+2*common.HashLength {
+				continue
+			}
+			batch.Delete(key)
+			base.cache.Remove(string(key[1:]))
+			flush()
+		}
+		it.Release()
+	}
+	// Push all updated accounts into the database
+	for hash, data := range bottom.accountData {
+		if !covered(hash) {
+			continue
+		}
+		rawdb.WriteAccountSnapshot(batch, hash, data)
+		base.cache.Add(string(hash[:]), data)
+		flush()
+	}
+	// Push all the storage slots into the database
+	for accountHash, storage := range bottom.storageData {
+		if !covered(accountHash) {
+			continue
+		}
+		for storageHash, data := range storage {
+			if len(data) > 0 {
+				rawdb.WriteStorageSnapshot(batch, accountHash, storageHash, data)
+			} else {
+				rawdb.DeleteStorageSnapshot(batch, accountHash, storageHash)
+			}
+			base.cache.Add(string(append(accountHash[:], storageHash[:]...)), data)
+		}
+		flush()
+	}
+	// Update the snapshot root and write the remainder of the data
+	rawdb.WriteSnapshotRoot(batch, bottom.root)
+	if err := batch.Write(); err != nil {
+		panic(err)
+	}
+	res := &diskLayer{
+		diskdb:    base.diskdb,
+		triedb:    base.triedb,
+		cache:     base.cache,
+		root:      bottom.root,
+		genMarker: marker,
+	}
+	base.markStale()
+	bottom.markStale()
+
+	// Resume the generation on the new root if it wasn't done
+	if marker != nil {
+		res.genAbort = make(chan chan []byte)
+		go res.generate()
+	}
+	return res
+}
@@ -0,0 +1,190 @@
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/trie"
+)
+
+// emptyRoot is the known root hash of an empty trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// generatorAccount is the part of an account trie leaf needed to generate the
+// snapshot, ignoring the owner and providers of enterprise accounts.
+type generatorAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+	Rest     []rlp.RawValue `rlp:"tail"`
+}
+
+// generateSnapshot regenerates a brand new snapshot based on an existing state
+// database and head block, on a background thread.
+func generateSnapshot(diskdb evrdb.KeyValueStore, triedb *trie.Database, cache int, root common.Hash) *diskLayer {
+	// Invalidate the previous snapshot, it is wiped before the generation
+	batch := diskdb.NewBatch()
+	rawdb.WriteSnapshotRoot(batch, root)
+	writeGenerator(batch, []byte{})
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write initialized state marker", "err", err)
+	}
+	base := &diskLayer{
+		diskdb:    diskdb,
+		triedb:    triedb,
+		cache:     newCache(cache),
+		root:      root,
+		genMarker: []byte{}, // Initialized but empty!
+		genAbort:  make(chan chan []byte),
+	}
+	go base.generate()
+	return base
+}
+
+// wipeSnapshot deletes all the account and storage entries of a snapshot.
+func wipeSnapshot(db evrdb.KeyValueStore) error {
+	batch := db.NewBatch()
+	for prefix, keylen := range map[string]int{
+		string(rawdb.SnapshotAccountPrefix): len(rawdb.SnapshotAccountPrefix) + common.HashLength,
+		string(rawdb.SnapshotStoragePrefix): len(rawdb.SnapshotStoragePrefix) + 2*common.HashLength,
+	} {
+		it := db.NewIteratorWithPrefix([]byte(prefix))
+		for it.Next() {
+			// Skip the trie nodes sharing the prefix
+			if len(it.Key()) != keylen {
+				continue
+			}
+			if err := batch.Delete(it.Key()); err != nil {
+				it.Release()
+				return err
+			}
+			if batch.ValueSize() > evrdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// generate is a background thread that iterates over the state tries and creates
+// a snapshot of the state, past the generation marker. Its progress is persisted
+// along the generated entries and the marker updated so that readers are served
+// the covered accounts.
+func (dl *diskLayer) generate() {
+	var (
+		marker = dl.genMarker
+		batch  = dl.diskdb.NewBatch()
+
+		accounts int
+		slots    int
+		start    = time.Now()
+		logged   = time.Now()
+	)
+	// fail waits for an abort signal after the generation failed, leaving the
+	// accounts past the marker to the trie
+	fail := func(msg string, err error) {
+		log.Error(msg, "root", dl.root, "err", err)
+		abort := <-dl.genAbort
+		abort <- marker
+	}
+	// checkpoint flushes the generated entries along with the progress
+	checkpoint := func(marker []byte) {
+		writeGenerator(batch, marker)
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write snapshot entries", "err", err)
+		}
+		batch.Reset()
+
+		dl.lock.Lock()
+		dl.genMarker = marker
+		dl.lock.Unlock()
+	}
+	// Wipe the previous snapshot when starting from scratch
+	if len(marker) == 0 {
+		if err := wipeSnapshot(dl.diskdb); err != nil {
+			fail("Failed to wipe previous snapshot", err)
+			return
+		}
+	}
+	log.Info("Generating state snapshot", "root", dl.root, "at", common.BytesToHash(marker))
+
+	accTrie, err := trie.NewSecure(dl.root, dl.triedb)
+	if err != nil {
+		// The account trie is missing (GC), wait for the disk layer to move on
+		fail("Failed to open snapshot account trie", err)
+		return
+	}
+	it := trie.NewIterator(accTrie.NodeIterator(marker))
+	for it.Next() {
+		if len(marker) > 0 && bytes.Equal(it.Key, marker) {
+			continue
+		}
+		select {
+		case abort := <-dl.genAbort:
+			checkpoint(marker)
+			log.Debug("Aborted state snapshot generation", "root", dl.root, "at", common.BytesToHash(marker))
+			abort <- marker
+			return
+		default:
+		}
+		accountHash := common.BytesToHash(it.Key)
+		rawdb.WriteAccountSnapshot(batch, accountHash, it.Value)
+
+		var acc generatorAccount
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			log.Crit("Invalid account encountered during snapshot creation", "err", err)
+		}
+		if acc.Root != emptyRoot {
+			storeTrie, err := trie.NewSecure(acc.Root, dl.triedb)
+			if err != nil {
+				fail("Failed to open snapshot storage trie", err)
+				return
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(nil))
+			for storeIt.Next() {
+				rawdb.WriteStorageSnapshot(batch, accountHash, common.BytesToHash(storeIt.Key), storeIt.Value)
+				slots++
+			}
+			if storeIt.Err != nil {
+				fail("Failed to iterate snapshot storage trie", storeIt.Err)
+				return
+			}
+		}
+		marker = common.CopyBytes(accountHash[:])
+		accounts++
+
+		if batch.ValueSize() > evrdb.IdealBatchSize {
+			checkpoint(marker)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Generating state snapshot", "root", dl.root, "at", accountHash, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		fail("Failed to iterate snapshot account trie", it.Err)
+		return
+	}
+	// Snapshot fully generated, wait for someone to ask for the result
+	checkpoint(nil)
+	log.Info("Generated state snapshot", "root", dl.root, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	abort := <-dl.genAbort
+	abort <- nil
+}
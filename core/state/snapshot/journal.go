@@ -0,0 +1,174 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/trie"
+)
+
+// journalGenerator is a disk layer entry containing the generator progress marker.
+type journalGenerator struct {
+	Done   bool // Whether the generator finished creating the snapshot
+	Marker []byte
+}
+
+// journalAccount is an account entry in a diffLayer's disk journal.
+type journalAccount struct {
+	Hash common.Hash
+	Blob []byte
+}
+
+// journalStorage is an account's storage map in a diffLayer's disk journal.
+type journalStorage struct {
+	Hash common.Hash
+	Keys []common.Hash
+	Vals [][]byte
+}
+
+// journalDiff is a diffLayer in the disk journal.
+type journalDiff struct {
+	Root      common.Hash
+	Destructs []common.Hash
+	Accounts  []journalAccount
+	Storage   []journalStorage
+}
+
+// journal is the list of diff layers on top of the disk layer, saved at shutdown.
+type journal struct {
+	Base  common.Hash // Root of the disk layer the diffs apply to
+	Diffs []journalDiff
+}
+
+// writeGenerator stores the generation progress, a nil marker meaning done.
+func writeGenerator(db evrdb.KeyValueWriter, marker []byte) {
+	blob, err := rlp.EncodeToBytes(&journalGenerator{Done: marker == nil, Marker: marker})
+	if err != nil {
+		panic(err) // Cannot happen, here to catch dev errors
+	}
+	rawdb.WriteSnapshotGenerator(db, blob)
+}
+
+// readGenerator retrieves the generation progress, a nil marker meaning done.
+func readGenerator(db evrdb.KeyValueReader) ([]byte, error) {
+	blob := rawdb.ReadSnapshotGenerator(db)
+	if len(blob) == 0 {
+		return nil, errors.New("missing snapshot generator")
+	}
+	var generator journalGenerator
+	if err := rlp.DecodeBytes(blob, &generator); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot generator: %v", err)
+	}
+	if generator.Done {
+		return nil, nil
+	}
+	return append([]byte{}, generator.Marker...), nil
+}
+
+// writeJournal stores the diff layers on top of the disk layer with the given root.
+func writeJournal(db evrdb.KeyValueWriter, base common.Hash, diffs []journalDiff) error {
+	blob, err := rlp.EncodeToBytes(&journal{Base: base, Diffs: diffs})
+	if err != nil {
+		return err
+	}
+	rawdb.WriteSnapshotJournal(db, blob)
+	log.Info("Journalled state snapshot", "base", base, "diffs", len(diffs), "size", common.StorageSize(len(blob)))
+	return nil
+}
+
+// journal returns the journal entry of the diff layer.
+func (dl *diffLayer) journal() journalDiff {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	diff := journalDiff{Root: dl.root}
+	for hash := range dl.destructSet {
+		diff.Destructs = append(diff.Destructs, hash)
+	}
+	for hash, blob := range dl.accountData {
+		diff.Accounts = append(diff.Accounts, journalAccount{Hash: hash, Blob: blob})
+	}
+	for hash, slots := range dl.storageData {
+		storage := journalStorage{Hash: hash}
+		for key, val := range slots {
+			storage.Keys = append(storage.Keys, key)
+			storage.Vals = append(storage.Vals, val)
+		}
+		diff.Storage = append(diff.Storage, storage)
+	}
+	return diff
+}
+
+// layer recreates the diff layer of a journal entry on top of the given parent.
+func (diff *journalDiff) layer(parent snapshot) (*diffLayer, error) {
+	destructs := make(map[common.Hash]struct{})
+	for _, hash := range diff.Destructs {
+		destructs[hash] = struct{}{}
+	}
+	accounts := make(map[common.Hash][]byte)
+	for _, entry := range diff.Accounts {
+		accounts[entry.Hash] = entry.Blob
+	}
+	storage := make(map[common.Hash]map[common.Hash][]byte)
+	for _, entry := range diff.Storage {
+		if len(entry.Keys) != len(entry.Vals) {
+			return nil, fmt.Errorf("storage of account %x corrupted", entry.Hash)
+		}
+		slots := make(map[common.Hash][]byte)
+		for i, key := range entry.Keys {
+			slots[key] = entry.Vals[i]
+		}
+		storage[entry.Hash] = slots
+	}
+	return parent.Update(diff.Root, destructs, accounts, storage), nil
+}
+
+// loadSnapshot loads a pre-existing state snapshot backed by a key-value store,
+// with the diff layers journalled at the last shutdown on top.
+func loadSnapshot(diskdb evrdb.KeyValueStore, triedb *trie.Database, cache int, root common.Hash) (snapshot, error) {
+	baseRoot := rawdb.ReadSnapshotRoot(diskdb)
+	if baseRoot == (common.Hash{}) {
+		return nil, errors.New("missing or corrupted snapshot")
+	}
+	marker, err := readGenerator(diskdb)
+	if err != nil {
+		return nil, err
+	}
+	base := &diskLayer{
+		diskdb:    diskdb,
+		triedb:    triedb,
+		cache:     newCache(cache),
+		root:      baseRoot,
+		genMarker: marker,
+	}
+	var snap snapshot = base
+	if blob := rawdb.ReadSnapshotJournal(diskdb); len(blob) > 0 {
+		var j journal
+		if err := rlp.DecodeBytes(blob, &j); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot journal: %v", err)
+		}
+		// Diffs journalled on top of another disk layer are discarded, the
+		// head check below failing if they were needed
+		if j.Base == baseRoot {
+			for i := range j.Diffs {
+				if snap, err = j.Diffs[i].layer(snap); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if head := snap.Root(); head != root {
+		return nil, fmt.Errorf("head doesn't match snapshot: have %#x, want %#x", head, root)
+	}
+	if marker != nil {
+		base.genAbort = make(chan chan []byte)
+		go base.generate()
+	}
+	log.Info("Loaded state snapshot", "root", root, "base", baseRoot, "generating", marker != nil)
+	return snap, nil
+}
@@ -0,0 +1,251 @@
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/trie"
+)
+
+// testState is a state trie committed to disk, with the leaves it holds.
+type testState struct {
+	diskdb   evrdb.Database
+	triedb   *trie.Database
+	root     common.Hash
+	accounts map[common.Hash][]byte
+	storage  map[common.Hash]map[common.Hash][]byte
+}
+
+// newTestState creates a state of the given number of accounts, every third one
+// holding a few storage slots.
+func newTestState(t *testing.T, count int) *testState {
+	diskdb := rawdb.NewMemoryDatabase()
+	state := &testState{
+		diskdb:   diskdb,
+		triedb:   trie.NewDatabase(diskdb),
+		accounts: make(map[common.Hash][]byte),
+		storage:  make(map[common.Hash]map[common.Hash][]byte),
+	}
+	accTrie, _ := trie.NewSecure(common.Hash{}, state.triedb)
+	for i := 0; i < count; i++ {
+		addr := common.BytesToAddress([]byte{byte(i >> 8), byte(i)})
+		account := generatorAccount{Nonce: uint64(i), Balance: big.NewInt(int64(i)), Root: emptyRoot, CodeHash: crypto.Keccak256(nil)}
+		if i%3 == 0 {
+			slots := make(map[common.Hash][]byte)
+			storeTrie, _ := trie.NewSecure(common.Hash{}, state.triedb)
+			for j := 1; j <= 4; j++ {
+				key := common.BigToHash(big.NewInt(int64(j)))
+				val, _ := rlp.EncodeToBytes([]byte{byte(i), byte(j)})
+				storeTrie.Update(key[:], val)
+				slots[crypto.Keccak256Hash(key[:])] = val
+			}
+			account.Root, _ = storeTrie.Commit(nil)
+			state.storage[crypto.Keccak256Hash(addr[:])] = slots
+		}
+		enc, _ := rlp.EncodeToBytes(&account)
+		accTrie.Update(addr[:], enc)
+		state.accounts[crypto.Keccak256Hash(addr[:])] = enc
+	}
+	state.root, _ = accTrie.Commit(nil)
+	if err := state.triedb.Commit(state.root, false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	return state
+}
+
+// waitGeneration waits for the generation of a disk layer to finish.
+func waitGeneration(t *testing.T, dl *diskLayer) {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		dl.lock.RLock()
+		done := dl.genMarker == nil
+		dl.lock.RUnlock()
+		if done {
+			return
+		}
+	}
+	t.Fatalf("snapshot generation timed out")
+}
+
+// checkSnapshot checks that a snapshot holds the given accounts and storage.
+func checkSnapshot(t *testing.T, snap Snapshot, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) {
+	t.Helper()
+	for hash, want := range accounts {
+		have, err := snap.Account(hash)
+		if err != nil {
+			t.Fatalf("account %x: failed to read: %v", hash, err)
+		}
+		if !bytes.Equal(have, want) {
+			t.Errorf("account %x: have %x, want %x", hash, have, want)
+		}
+	}
+	for accountHash, slots := range storage {
+		for hash, want := range slots {
+			have, err := snap.Storage(accountHash, hash)
+			if err != nil {
+				t.Fatalf("slot %x of %x: failed to read: %v", hash, accountHash, err)
+			}
+			if !bytes.Equal(have, want) {
+				t.Errorf("slot %x of %x: have %x, want %x", hash, accountHash, have, want)
+			}
+		}
+	}
+}
+
+// Tests that a snapshot generated from the tries holds all their leaves.
+func TestGenerateSnapshot(t *testing.T) {
+	state := newTestState(t, 300)
+
+	// Leftovers of an older snapshot are wiped
+	stale := common.Hash{0xff}
+	rawdb.WriteAccountSnapshot(state.diskdb, stale, []byte{0x01})
+
+	snaps := New(state.diskdb, state.triedb, 16, state.root)
+	base := snaps.Snapshot(state.root).(*diskLayer)
+	waitGeneration(t, base)
+
+	checkSnapshot(t, base, state.accounts, state.storage)
+	if blob := rawdb.ReadAccountSnapshot(state.diskdb, stale); len(blob) != 0 {
+		t.Errorf("stale account not wiped: %x", blob)
+	}
+	if blob, err := base.Account(common.Hash{0x01}); err != nil || len(blob) != 0 {
+		t.Errorf("missing account: have %x, %v, want none", blob, err)
+	}
+	// The generated snapshot is loaded on restart
+	if _, err := snaps.Journal(state.root); err != nil {
+		t.Fatalf("failed to journal snapshot: %v", err)
+	}
+	if _, err := loadSnapshot(state.diskdb, state.triedb, 16, state.root); err != nil {
+		t.Fatalf("failed to load generated snapshot: %v", err)
+	}
+}
+
+// Tests that an interrupted generation is resumed from its persisted progress.
+func TestGenerateSnapshotResume(t *testing.T) {
+	state := newTestState(t, 3000)
+
+	// Generate part of the snapshot and journal it on shutdown
+	snaps := New(state.diskdb, state.triedb, 16, state.root)
+	if _, err := snaps.Journal(state.root); err != nil {
+		t.Fatalf("failed to journal snapshot: %v", err)
+	}
+	snaps = New(state.diskdb, state.triedb, 16, state.root)
+	base := snaps.Snapshot(state.root).(*diskLayer)
+	waitGeneration(t, base)
+	checkSnapshot(t, base, state.accounts, state.storage)
+}
+
+// Tests that diff layers shadow their parents, and are flattened into the disk
+// layer once beyond the allowed depth.
+func TestDiffLayers(t *testing.T) {
+	state := newTestState(t, 30)
+	snaps := New(state.diskdb, state.triedb, 16, state.root)
+	waitGeneration(t, snaps.Snapshot(state.root).(*diskLayer))
+
+	var (
+		created   = common.Hash{0x01}
+		modified  = crypto.Keccak256Hash(common.BytesToAddress([]byte{0, 3}).Bytes())
+		destroyed = crypto.Keccak256Hash(common.BytesToAddress([]byte{0, 6}).Bytes())
+		slot      = crypto.Keccak256Hash(common.BigToHash(big.NewInt(1)).Bytes())
+		roots     = []common.Hash{state.root, {0xa1}, {0xa2}, {0xa3}}
+	)
+	if err := snaps.Update(roots[1], roots[0], nil, map[common.Hash][]byte{created: {0x01}}, nil); err != nil {
+		t.Fatalf("failed to add diff layer: %v", err)
+	}
+	if err := snaps.Update(roots[2], roots[1], map[common.Hash]struct{}{destroyed: {}}, map[common.Hash][]byte{modified: {0x02}}, map[common.Hash]map[common.Hash][]byte{modified: {slot: nil}}); err != nil {
+		t.Fatalf("failed to add diff layer: %v", err)
+	}
+	if err := snaps.Update(roots[3], roots[2], nil, map[common.Hash][]byte{created: {0x03}}, nil); err != nil {
+		t.Fatalf("failed to add diff layer: %v", err)
+	}
+	if err := snaps.Update(roots[3], roots[3], nil, nil, nil); err != errSnapshotCycle {
+		t.Errorf("cycle: have %v, want %v", err, errSnapshotCycle)
+	}
+	// A fork off the first layer, dropped once it is flattened
+	if err := snaps.Update(common.Hash{0xb2}, roots[1], nil, nil, nil); err != nil {
+		t.Fatalf("failed to add diff layer: %v", err)
+	}
+	// The destructed storage is gone, the untouched one read from disk
+	head := snaps.Snapshot(roots[3])
+	checkSnapshot(t, head, map[common.Hash][]byte{created: {0x03}, modified: {0x02}, destroyed: nil}, map[common.Hash]map[common.Hash][]byte{
+		modified:  {slot: nil},
+		destroyed: {slot: nil},
+	})
+	untouched := crypto.Keccak256Hash(common.BytesToAddress([]byte{0, 9}).Bytes())
+	checkSnapshot(t, head, nil, map[common.Hash]map[common.Hash][]byte{untouched: state.storage[untouched]})
+
+	// Flatten all but the top layer into the disk layer
+	flattened := snaps.Snapshot(roots[1])
+	if err := snaps.Cap(roots[3], 1); err != nil {
+		t.Fatalf("failed to cap snapshot tree: %v", err)
+	}
+	if _, err := flattened.Account(created); err != ErrSnapshotStale {
+		t.Errorf("flattened layer read: have %v, want %v", err, ErrSnapshotStale)
+	}
+	if len(snaps.layers) != 2 {
+		t.Errorf("layer count mismatch: have %d, want 2", len(snaps.layers))
+	}
+	base, ok := snaps.Snapshot(roots[2]).(*diskLayer)
+	if !ok {
+		t.Fatalf("flattened layer is not the disk layer")
+	}
+	if root := rawdb.ReadSnapshotRoot(state.diskdb); root != roots[2] {
+		t.Errorf("persisted root mismatch: have %x, want %x", root, roots[2])
+	}
+	checkSnapshot(t, base, map[common.Hash][]byte{created: {0x01}, modified: {0x02}, destroyed: nil}, map[common.Hash]map[common.Hash][]byte{destroyed: {slot: nil}})
+	if blob := rawdb.ReadStorageSnapshot(state.diskdb, destroyed, slot); len(blob) != 0 {
+		t.Errorf("destructed storage persisted: %x", blob)
+	}
+	checkSnapshot(t, snaps.Snapshot(roots[3]), map[common.Hash][]byte{created: {0x03}}, nil)
+	if _, err := head.Account(created); err != nil {
+		t.Errorf("head layer unreadable: %v", err)
+	}
+	for _, root := range []common.Hash{roots[0], roots[1], {0xb2}} {
+		if snaps.Snapshot(root) != nil {
+			t.Errorf("layer %x not dropped", root)
+		}
+	}
+}
+
+// Tests that the diff layers journalled at shutdown are restored.
+func TestJournal(t *testing.T) {
+	state := newTestState(t, 30)
+	snaps := New(state.diskdb, state.triedb, 16, state.root)
+	waitGeneration(t, snaps.Snapshot(state.root).(*diskLayer))
+
+	var (
+		account = common.Hash{0x01}
+		slot    = common.Hash{0x02}
+		root    = common.Hash{0xa1}
+	)
+	if err := snaps.Update(root, state.root, map[common.Hash]struct{}{{0x03}: {}}, map[common.Hash][]byte{account: {0x01}}, map[common.Hash]map[common.Hash][]byte{account: {slot: {0x02}}}); err != nil {
+		t.Fatalf("failed to add diff layer: %v", err)
+	}
+	base, err := snaps.Journal(root)
+	if err != nil {
+		t.Fatalf("failed to journal snapshot: %v", err)
+	}
+	if base != state.root {
+		t.Errorf("journal base mismatch: have %x, want %x", base, state.root)
+	}
+	restored := New(state.diskdb, state.triedb, 16, root)
+	if len(restored.layers) != 2 {
+		t.Fatalf("layer count mismatch: have %d, want 2", len(restored.layers))
+	}
+	checkSnapshot(t, restored.Snapshot(root), map[common.Hash][]byte{account: {0x01}}, map[common.Hash]map[common.Hash][]byte{account: {slot: {0x02}}})
+	if _, ok := restored.Snapshot(root).(*diffLayer).destructSet[common.Hash{0x03}]; !ok {
+		t.Errorf("destructed account not restored")
+	}
+	// A snapshot not matching the head is regenerated
+	rebuilt := New(state.diskdb, state.triedb, 16, state.root)
+	waitGeneration(t, rebuilt.Snapshot(state.root).(*diskLayer))
+	if len(rebuilt.layers) != 1 {
+		t.Errorf("layer count mismatch: have %d, want 1", len(rebuilt.layers))
+	}
+}
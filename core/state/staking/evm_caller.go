@@ -178,17 +178,19 @@ type callmsg struct {
 	evrynet.CallMsg
 }
 
-func (m callmsg) GasPayer() common.Address  { return m.CallMsg.From }
-func (m callmsg) Owner() *common.Address    { return nil }
-func (m callmsg) Provider() *common.Address { return nil }
-func (m callmsg) From() common.Address      { return m.CallMsg.From }
-func (m callmsg) Nonce() uint64             { return 0 }
-func (m callmsg) CheckNonce() bool          { return false }
-func (m callmsg) To() *common.Address       { return m.CallMsg.To }
-func (m callmsg) GasPrice() *big.Int        { return m.CallMsg.GasPrice }
-func (m callmsg) Gas() uint64               { return m.CallMsg.Gas }
-func (m callmsg) Value() *big.Int           { return m.CallMsg.Value }
-func (m callmsg) Data() []byte              { return m.CallMsg.Data }
+func (m callmsg) GasPayer() common.Address      { return m.CallMsg.From }
+func (m callmsg) Owner() *common.Address        { return nil }
+func (m callmsg) Provider() *common.Address     { return nil }
+func (m callmsg) From() common.Address          { return m.CallMsg.From }
+func (m callmsg) Nonce() uint64                 { return 0 }
+func (m callmsg) CheckNonce() bool              { return false }
+func (m callmsg) Calls() []types.BatchCall      { return nil }
+func (m callmsg) SenderAuth() *types.SenderAuth { return nil }
+func (m callmsg) To() *common.Address           { return m.CallMsg.To }
+func (m callmsg) GasPrice() *big.Int            { return m.CallMsg.GasPrice }
+func (m callmsg) Gas() uint64                   { return m.CallMsg.Gas }
+func (m callmsg) Value() *big.Int               { return m.CallMsg.Value }
+func (m callmsg) Data() []byte                  { return m.CallMsg.Data }
 
 type chainContextWrapper struct {
 	engine      consensus.Engine
@@ -95,6 +95,23 @@ func (s *stateObject) empty() bool {
 	return s.data.Nonce == 0 && s.data.Balance.Sign() == 0 && bytes.Equal(s.data.CodeHash, emptyCodeHash)
 }
 
+// system returns whether the account belongs to a natively executed operation,
+// keeping its state in storage only.
+func (s *stateObject) system() bool {
+	_, ok := systemAccounts[s.address]
+	return ok
+}
+
+// deletable returns whether the account is empty and can be removed when touched.
+// System accounts are not deleted while they hold any storage, as of their storage
+// root, which must be up to date.
+func (s *stateObject) deletable() bool {
+	if !s.empty() {
+		return false
+	}
+	return !s.system() || s.data.Root == emptyRoot
+}
+
 // Account is the Evrynet consensus representation of accounts.
 // These objects are stored in the main account trie.
 type Account struct {
@@ -134,6 +151,9 @@ func newObject(db *StateDB, address common.Address, data Account) *stateObject {
 	if data.CodeHash == nil {
 		data.CodeHash = emptyCodeHash
 	}
+	if data.Root == (common.Hash{}) {
+		data.Root = emptyRoot
+	}
 	return &stateObject{
 		db:            db,
 		address:       address,
@@ -205,11 +225,24 @@ func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Has
 	if metrics.EnabledExpensive {
 		defer func(start time.Time) { s.db.StorageReads += time.Since(start) }(time.Now())
 	}
-	// Otherwise load the value from the database
-	enc, err := s.getTrie(db).TryGet(key[:])
-	if err != nil {
-		s.setError(err)
-		return common.Hash{}
+	// Otherwise load the value from the snapshot if it covers it, from the trie otherwise
+	var (
+		enc []byte
+		err error
+	)
+	if s.db.snap != nil {
+		// The storage of an overwritten account is gone, but still in the snapshot
+		if _, destructed := s.db.snapDestructs[s.addrHash]; destructed {
+			s.originStorage[key] = common.Hash{}
+			return common.Hash{}
+		}
+		enc, err = s.db.snap.Storage(s.addrHash, crypto.Keccak256Hash(key[:]))
+	}
+	if s.db.snap == nil || err != nil {
+		if enc, err = s.getTrie(db).TryGet(key[:]); err != nil {
+			s.setError(err)
+			return common.Hash{}
+		}
 	}
 	if len(enc) > 0 {
 		_, content, _, err := rlp.Split(enc)
@@ -259,13 +292,23 @@ func (s *stateObject) updateTrie(db Database) Trie {
 		}
 		s.originStorage[key] = value
 
+		var v []byte
 		if (value == common.Hash{}) {
 			s.setError(tr.TryDelete(key[:]))
-			continue
+		} else {
+			// Encoding []byte cannot fail, ok to ignore the error.
+			v, _ = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+			s.setError(tr.TryUpdate(key[:], v))
+		}
+		// Track the slot to add to the snapshot
+		if s.db.snap != nil {
+			storage := s.db.snapStorage[s.addrHash]
+			if storage == nil {
+				storage = make(map[common.Hash][]byte)
+				s.db.snapStorage[s.addrHash] = storage
+			}
+			storage[crypto.Keccak256Hash(key[:])] = v
 		}
-		// Encoding []byte cannot fail, ok to ignore the error.
-		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
-		s.setError(tr.TryUpdate(key[:], v))
 	}
 	return tr
 }
@@ -431,3 +474,27 @@ func (s *stateObject) OwnerAddress() *common.Address {
 func (s *stateObject) ProviderAddresses() []*common.Address {
 	return s.data.ProviderAddresses
 }
+
+func (s *stateObject) SetOwner(owner *common.Address) {
+	s.db.journal.append(ownerChange{
+		account: &s.address,
+		prev:    s.data.OwnerAddress,
+	})
+	s.setOwner(owner)
+}
+
+func (s *stateObject) setOwner(owner *common.Address) {
+	s.data.OwnerAddress = owner
+}
+
+func (s *stateObject) SetProviders(providers []*common.Address) {
+	s.db.journal.append(providersChange{
+		account: &s.address,
+		prev:    s.data.ProviderAddresses,
+	})
+	s.setProviders(providers)
+}
+
+func (s *stateObject) setProviders(providers []*common.Address) {
+	s.data.ProviderAddresses = providers
+}
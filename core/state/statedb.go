@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state/snapshot"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/log"
@@ -44,6 +45,15 @@ var (
 
 	// emptyCode is the known hash of the empty EVM bytecode.
 	emptyCode = crypto.Keccak256Hash(nil)
+
+	// systemAccounts are the accounts of the natively executed operations, kept
+	// while holding storage even if empty. They only hold storage once the fork of
+	// their operation is active, so the deletion of empty accounts is unchanged
+	// before.
+	systemAccounts = map[common.Address]struct{}{
+		types.EnterpriseManagerAddress: {},
+		types.GasPriceManagerAddress:   {},
+	}
 )
 
 type proofList [][]byte
@@ -66,6 +76,12 @@ type StateDB struct {
 	db   Database
 	trie Trie
 
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects      map[common.Address]*stateObject
 	stateObjectsDirty map[common.Address]struct{}
@@ -106,19 +122,43 @@ type StateDB struct {
 
 // Create a new state from a given trie.
 func New(root common.Hash, db Database) (*StateDB, error) {
+	return NewWithSnapshots(root, db, nil)
+}
+
+// NewWithSnapshots creates a new state from a given trie, reading the accounts and
+// storage from the flat state snapshots if they cover the root. The changes made
+// to the state are added to the snapshots on commit.
+func NewWithSnapshots(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error) {
 	tr, err := db.OpenTrie(root)
 	if err != nil {
 		return nil, err
 	}
-	return &StateDB{
+	sdb := &StateDB{
 		db:                db,
 		trie:              tr,
+		snaps:             snaps,
 		stateObjects:      make(map[common.Address]*stateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
 		journal:           newJournal(),
-	}, nil
+	}
+	sdb.openSnapshot(root)
+	return sdb, nil
+}
+
+// openSnapshot retrieves the snapshot of the given root, tracking the changes to
+// add to it on commit.
+func (s *StateDB) openSnapshot(root common.Hash) {
+	s.snap, s.snapDestructs, s.snapAccounts, s.snapStorage = nil, nil, nil, nil
+	if s.snaps == nil {
+		return
+	}
+	if s.snap = s.snaps.Snapshot(root); s.snap != nil {
+		s.snapDestructs = make(map[common.Hash]struct{})
+		s.snapAccounts = make(map[common.Hash][]byte)
+		s.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
 }
 
 // setError remembers the first non-nil error it is called with.
@@ -148,6 +188,7 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.logs = make(map[common.Hash][]*types.Log)
 	self.logSize = 0
 	self.preimages = make(map[common.Hash][]byte)
+	self.openSnapshot(root)
 	self.clearJournalAndRefund()
 	return nil
 }
@@ -236,6 +277,39 @@ func (self *StateDB) GetProviders(addr common.Address) []*common.Address {
 	return []*common.Address{}
 }
 
+// SetOwner sets the owner of an account, a nil owner leaves the account without owner
+func (self *StateDB) SetOwner(addr common.Address, owner *common.Address) {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetOwner(owner)
+	}
+}
+
+// AddProvider appends provider to the providers of an account
+func (self *StateDB) AddProvider(addr common.Address, provider common.Address) {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		providers := make([]*common.Address, 0, len(stateObject.ProviderAddresses())+1)
+		providers = append(providers, stateObject.ProviderAddresses()...)
+		providers = append(providers, &provider)
+		stateObject.SetProviders(providers)
+	}
+}
+
+// RemoveProvider removes provider from the providers of an account, keeping the order of the others
+func (self *StateDB) RemoveProvider(addr common.Address, provider common.Address) {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		providers := make([]*common.Address, 0, len(stateObject.ProviderAddresses()))
+		for _, p := range stateObject.ProviderAddresses() {
+			if *p != provider {
+				providers = append(providers, p)
+			}
+		}
+		stateObject.SetProviders(providers)
+	}
+}
+
 // Retrieve the balance from the given address or 0 if object not found
 func (self *StateDB) GetBalance(addr common.Address) *big.Int {
 	stateObject := self.getStateObject(addr)
@@ -442,6 +516,11 @@ func (s *StateDB) updateStateObject(stateObject *stateObject) {
 		panic(fmt.Errorf("can't encode object at %x: %v", addr[:], err))
 	}
 	s.setError(s.trie.TryUpdate(addr[:], data))
+
+	// Track the account to add to the snapshot
+	if s.snap != nil {
+		s.snapAccounts[stateObject.addrHash] = data
+	}
 }
 
 // deleteStateObject removes the given object from the state trie.
@@ -455,6 +534,13 @@ func (s *StateDB) deleteStateObject(stateObject *stateObject) {
 
 	addr := stateObject.Address()
 	s.setError(s.trie.TryDelete(addr[:]))
+
+	// Track the account and its storage to drop from the snapshot
+	if s.snap != nil {
+		s.snapDestructs[stateObject.addrHash] = struct{}{}
+		delete(s.snapAccounts, stateObject.addrHash)
+		delete(s.snapStorage, stateObject.addrHash)
+	}
 }
 
 // Retrieve a state object given by the address. Returns nil if not found.
@@ -470,8 +556,17 @@ func (s *StateDB) getStateObject(addr common.Address) (stateObject *stateObject)
 	if metrics.EnabledExpensive {
 		defer func(start time.Time) { s.AccountReads += time.Since(start) }(time.Now())
 	}
-	// Load the object from the database
-	enc, err := s.trie.TryGet(addr[:])
+	// Load the object from the snapshot if it covers it, from the trie otherwise
+	var (
+		enc []byte
+		err error
+	)
+	if s.snap != nil {
+		enc, err = s.snap.Account(crypto.Keccak256Hash(addr[:]))
+	}
+	if s.snap == nil || err != nil {
+		enc, err = s.trie.TryGet(addr[:])
+	}
 	if len(enc) == 0 {
 		s.setError(err)
 		return nil
@@ -521,11 +616,19 @@ func (self *StateDB) createObject(addr common.Address, opts ...types.CreateAccou
 	}
 	newobj = newObject(self, addr, account)
 
+	// The storage of an overwritten account is dropped from the snapshot
+	var prevdestruct bool
+	if self.snap != nil && prev != nil {
+		_, prevdestruct = self.snapDestructs[prev.addrHash]
+		if !prevdestruct {
+			self.snapDestructs[prev.addrHash] = struct{}{}
+		}
+	}
 	newobj.setNonce(0) // sets the object to dirty
 	if prev == nil {
 		self.journal.append(createObjectChange{account: &addr})
 	} else {
-		self.journal.append(resetObjectChange{prev: prev})
+		self.journal.append(resetObjectChange{prev: prev, prevdestruct: prevdestruct})
 	}
 	self.setStateObject(newobj)
 	return newobj, prev
@@ -592,6 +695,27 @@ func (self *StateDB) Copy() *StateDB {
 		logSize:           self.logSize,
 		preimages:         make(map[common.Hash][]byte, len(self.preimages)),
 		journal:           newJournal(),
+		snaps:             self.snaps,
+		snap:              self.snap,
+	}
+	// Copy the changes to add to the snapshot
+	if self.snap != nil {
+		state.snapDestructs = make(map[common.Hash]struct{}, len(self.snapDestructs))
+		for hash := range self.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte, len(self.snapAccounts))
+		for hash, data := range self.snapAccounts {
+			state.snapAccounts[hash] = data
+		}
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte, len(self.snapStorage))
+		for hash, storage := range self.snapStorage {
+			cpy := make(map[common.Hash][]byte, len(storage))
+			for key, data := range storage {
+				cpy[key] = data
+			}
+			state.snapStorage[hash] = cpy
+		}
 	}
 	// Copy the dirty states, logs, and preimages
 	for addr := range self.journal.dirties {
@@ -671,7 +795,11 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 			continue
 		}
 
-		if stateObject.suicided || (deleteEmptyObjects && stateObject.empty()) {
+		// The storage decides whether a touched empty system account is kept
+		if stateObject.system() && !stateObject.suicided {
+			stateObject.updateRoot(s.db)
+		}
+		if stateObject.suicided || (deleteEmptyObjects && stateObject.deletable()) {
 			s.deleteStateObject(stateObject)
 		} else {
 			stateObject.updateRoot(s.db)
@@ -720,8 +848,11 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 	// Commit objects to the trie, measuring the elapsed time
 	for addr, stateObject := range s.stateObjects {
 		_, isDirty := s.stateObjectsDirty[addr]
+		if isDirty && stateObject.system() && !stateObject.suicided {
+			stateObject.updateRoot(s.db)
+		}
 		switch {
-		case stateObject.suicided || (isDirty && deleteEmptyObjects && stateObject.empty()):
+		case stateObject.suicided || (isDirty && deleteEmptyObjects && stateObject.deletable()):
 			// If the object has been removed, don't bother syncing it
 			// and just mark it for deletion in the trie.
 			s.deleteStateObject(stateObject)
@@ -760,3 +891,14 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 	})
 	return root, err
 }
+
+// SnapshotChanges returns the root of the snapshot the state was opened on, along
+// with the accounts and storage slots destructed or changed on top of it, for the
+// block chain to add to its snapshot tree once the state is committed. The root is
+// empty if the state isn't covered by a snapshot.
+func (s *StateDB) SnapshotChanges() (common.Hash, map[common.Hash]struct{}, map[common.Hash][]byte, map[common.Hash]map[common.Hash][]byte) {
+	if s.snap == nil {
+		return common.Hash{}, nil, nil, nil
+	}
+	return s.snap.Root(), s.snapDestructs, s.snapAccounts, s.snapStorage
+}
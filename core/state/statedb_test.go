@@ -27,12 +27,15 @@ import (
 	"strings"
 	"testing"
 	"testing/quick"
+	"time"
 
 	check "gopkg.in/check.v1"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state/snapshot"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -449,3 +452,117 @@ func TestCopyOfCopy(t *testing.T) {
 		t.Fatalf("2nd copy fail, expected 42, got %v", got)
 	}
 }
+
+// Tests that touched empty accounts are deleted, unless they're the accounts of
+// natively executed operations holding storage.
+func TestDeleteEmptyAccounts(t *testing.T) {
+	sdb, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	var (
+		empty   = common.HexToAddress("aaaa")
+		other   = common.HexToAddress("cccc")
+		storage = types.GasPriceManagerAddress
+		key     = common.HexToHash("01")
+	)
+	sdb.AddBalance(empty, new(big.Int))
+	sdb.SetState(other, key, common.HexToHash("02"))
+	sdb.SetState(storage, key, common.HexToHash("02"))
+	root, _ := sdb.Commit(true)
+
+	sdb, _ = New(root, sdb.Database())
+	if sdb.Exist(empty) {
+		t.Errorf("touched empty account not deleted")
+	}
+	if sdb.Exist(other) {
+		t.Errorf("empty account with storage kept")
+	}
+	if !sdb.Exist(storage) {
+		t.Fatalf("empty system account with storage deleted")
+	}
+	// Touching it again keeps it, clearing its storage deletes it
+	sdb.AddBalance(storage, new(big.Int))
+	sdb.Finalise(true)
+	if have := sdb.GetState(storage, key); have != common.HexToHash("02") {
+		t.Fatalf("storage mismatch: have %x, want %x", have, common.HexToHash("02"))
+	}
+	sdb.SetState(storage, key, common.Hash{})
+	root, _ = sdb.Commit(true)
+
+	sdb, _ = New(root, sdb.Database())
+	if sdb.Exist(storage) {
+		t.Errorf("empty account with cleared storage not deleted")
+	}
+}
+
+// Tests that states read from the flat snapshot match the ones read from the
+// trie, including the storage of destructed and recreated accounts.
+func TestStateSnapshotReads(t *testing.T) {
+	diskdb := rawdb.NewMemoryDatabase()
+	db := NewDatabase(diskdb)
+	state, _ := New(common.Hash{}, db)
+
+	var addrs []common.Address
+	for i := byte(1); i <= 10; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)))
+		if i%2 == 0 {
+			state.SetCode(addr, []byte{i})
+			for j := int64(1); j <= 3; j++ {
+				state.SetState(addr, common.BigToHash(big.NewInt(j)), common.BigToHash(big.NewInt(int64(i)*j)))
+			}
+		}
+		addrs = append(addrs, addr)
+	}
+	root, _ := state.Commit(false)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	snaps := snapshot.New(diskdb, db.TrieDB(), 16, root)
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := snaps.Snapshot(root).Account(crypto.Keccak256Hash(common.Hash{}.Bytes())); err == nil {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("snapshot generation timed out")
+		}
+	}
+	// Modify, destruct and recreate accounts on top of the snapshot
+	state, _ = NewWithSnapshots(root, db, snaps)
+	state.SetState(addrs[1], common.BigToHash(big.NewInt(1)), common.Hash{})
+	state.SetState(addrs[1], common.BigToHash(big.NewInt(4)), common.Hash{0x04})
+	state.Suicide(addrs[3])
+	state.Finalise(true)
+	state.CreateAccount(addrs[5])
+	state.SetState(addrs[5], common.BigToHash(big.NewInt(4)), common.Hash{0x05})
+	state.AddBalance(common.Address{0xff}, big.NewInt(1))
+	if value := state.GetState(addrs[5], common.BigToHash(big.NewInt(1))); value != (common.Hash{}) {
+		t.Errorf("recreated account storage not dropped: %x", value)
+	}
+	parent := root
+	root, _ = state.Commit(true)
+	base, destructs, accounts, storage := state.SnapshotChanges()
+	if base != parent {
+		t.Fatalf("snapshot base mismatch: have %x, want %x", base, parent)
+	}
+	if err := snaps.Update(root, base, destructs, accounts, storage); err != nil {
+		t.Fatalf("failed to update snapshot tree: %v", err)
+	}
+	snapState, _ := NewWithSnapshots(root, db, snaps)
+	trieState, _ := New(root, db)
+	for _, addr := range append(addrs, common.Address{0xff}) {
+		if have, want := snapState.Exist(addr), trieState.Exist(addr); have != want {
+			t.Errorf("account %x existence mismatch: have %v, want %v", addr, have, want)
+		}
+		if have, want := snapState.GetBalance(addr), trieState.GetBalance(addr); have.Cmp(want) != 0 {
+			t.Errorf("account %x balance mismatch: have %v, want %v", addr, have, want)
+		}
+		if have, want := snapState.GetCodeHash(addr), trieState.GetCodeHash(addr); have != want {
+			t.Errorf("account %x code hash mismatch: have %x, want %x", addr, have, want)
+		}
+		for j := int64(1); j <= 4; j++ {
+			key := common.BigToHash(big.NewInt(j))
+			if have, want := snapState.GetState(addr, key), trieState.GetState(addr, key); have != want {
+				t.Errorf("account %x slot %d mismatch: have %x, want %x", addr, j, have, want)
+			}
+		}
+	}
+}
@@ -17,6 +17,8 @@
 package core
 
 import (
+	"math/big"
+
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/misc"
@@ -85,6 +87,16 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
 func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, uint64, error) {
+	if !txTypeSupported(config, tx, header.Number) {
+		return nil, 0, types.ErrTxTypeNotSupported
+	}
+	// Scheduled transactions can only be included within their window
+	if tx.NotYetValid(header.Number.Uint64()) {
+		return nil, 0, ErrTxNotYetValid
+	}
+	if tx.Expired(header.Number.Uint64()) {
+		return nil, 0, ErrTxExpired
+	}
 	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
 	if err != nil {
 		return nil, 0, err
@@ -95,7 +107,8 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 	// about the transaction and calling mechanisms.
 	vmenv := vm.NewEVM(context, statedb, config, cfg)
 	// Apply the transaction to the current state (included in the env)
-	_, gas, failed, err := ApplyMessage(vmenv, msg, gp)
+	st := NewStateTransition(vmenv, msg, gp)
+	_, gas, failed, err := st.TransitionDb()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -118,6 +131,10 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 		receipt.ContractAddress = crypto.CreateAddress(vmenv.Context.Origin, tx.Nonce())
 	}
 	receipt.GasPayer = msg.GasPayer()
+	// Call results are only part of the receipts from the batch fork on
+	if config.IsBatch(header.Number) {
+		receipt.CallResults = st.CallResults()
+	}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = statedb.GetLogs(tx.Hash())
 	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
@@ -127,3 +144,19 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 
 	return receipt, gas, err
 }
+
+// txTypeSupported returns whether the envelope type of the transaction is
+// enabled by the chain configuration at the given block.
+func txTypeSupported(config *params.ChainConfig, tx *types.Transaction, number *big.Int) bool {
+	switch tx.Type() {
+	case types.SponsoredTxType:
+		return config.IsSponsored(number)
+	case types.BatchTxType:
+		return config.IsBatch(number)
+	case types.ScheduledTxType:
+		return config.IsScheduled(number)
+	case types.ContractTxType:
+		return config.IsContractSender(number)
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestApplyScheduledTransaction(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		config = params.TestChainConfig
+		signer = types.NewEIP155Signer(config.ChainID)
+		author = common.HexToAddress("0xc0ffee")
+		to     = common.HexToAddress("0x2000")
+	)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.SetBalance(sender, big.NewInt(1000000000000000000))
+
+	tx := types.NewScheduledTransaction(config.ChainID, 0, &to, big.NewInt(1), params.TxGas, big.NewInt(params.GasPriceConfig), nil, 5, 10, nil)
+	tx, err = types.SignTx(tx, signer, key)
+	require.NoError(t, err)
+
+	apply := func(number int64) error {
+		var (
+			usedGas uint64
+			header  = &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(1), GasLimit: 10000000}
+			gp      = new(GasPool).AddGas(header.GasLimit)
+		)
+		_, _, err := ApplyTransaction(config, nil, &author, gp, statedb.Copy(), header, tx, &usedGas, vm.Config{})
+		return err
+	}
+	assert.Equal(t, ErrTxNotYetValid, apply(4))
+	assert.NoError(t, apply(5))
+	assert.NoError(t, apply(9))
+	assert.Equal(t, ErrTxExpired, apply(10))
+
+	// Scheduled transactions are only accepted from the scheduled fork on
+	forked := *config
+	forked.ScheduledBlock = big.NewInt(6)
+	config = &forked
+	assert.Equal(t, types.ErrTxTypeNotSupported, apply(5))
+	assert.NoError(t, apply(6))
+}
+
+func TestApplySponsoredTransactionFork(t *testing.T) {
+	var (
+		key, _         = crypto.GenerateKey()
+		providerKey, _ = crypto.GenerateKey()
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		config         = params.TestChainConfig
+		signer         = types.NewEIP155Signer(config.ChainID)
+		author         = common.HexToAddress("0xc0ffee")
+		to             = common.HexToAddress("0x2000")
+	)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.SetBalance(provider, big.NewInt(1000000000000000000))
+
+	tx := types.NewSponsoredTransaction(config.ChainID, 0, to, provider, big.NewInt(0), params.TxGas, big.NewInt(params.GasPriceConfig), nil)
+	tx, err = types.SignTx(tx, signer, key)
+	require.NoError(t, err)
+	tx, err = types.ProviderSignTx(tx, signer, providerKey)
+	require.NoError(t, err)
+
+	apply := func(number int64) error {
+		var (
+			usedGas uint64
+			header  = &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(1), GasLimit: 10000000}
+			gp      = new(GasPool).AddGas(header.GasLimit)
+		)
+		_, _, err := ApplyTransaction(config, nil, &author, gp, statedb.Copy(), header, tx, &usedGas, vm.Config{})
+		return err
+	}
+	// Sponsored transactions are only accepted from the sponsored fork on
+	forked := *config
+	forked.SponsoredBlock = big.NewInt(6)
+	config = &forked
+	assert.Equal(t, types.ErrTxTypeNotSupported, apply(5))
+	assert.NoError(t, apply(6))
+}
+
+func TestApplyTransactionGasPrice(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		config = params.TestChainConfig
+		signer = types.NewEIP155Signer(config.ChainID)
+		author = common.HexToAddress("0xc0ffee")
+		to     = common.HexToAddress("0x2000")
+		header = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: 10000000}
+	)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.SetBalance(sender, big.NewInt(1000000000000000000))
+
+	apply := func(config *params.ChainConfig, price *big.Int) error {
+		tx, err := types.SignTx(types.NewTransaction(0, to, big.NewInt(1), params.TxGas, price, nil), signer, key)
+		require.NoError(t, err)
+
+		var usedGas uint64
+		_, _, err = ApplyTransaction(config, nil, &author, new(GasPool).AddGas(header.GasLimit), statedb.Copy(), header, tx, &usedGas, vm.Config{})
+		return err
+	}
+	// Transactions must pay the gas price scheduled for the block
+	assert.Equal(t, ErrInvalidGasPrice, apply(config, big.NewInt(1)))
+	assert.NoError(t, apply(config, big.NewInt(params.GasPriceConfig)))
+
+	// Before the gas price fork, blocks are executed as they were
+	forked := *config
+	forked.GasPriceBlock = big.NewInt(2)
+	assert.NoError(t, apply(&forked, big.NewInt(1)))
+}
+
+// Tests that transactions to the gas price manager are only executed natively
+// from the gas price fork on, and plain transfers before.
+func TestApplyGasPriceChangeFork(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.TestChainConfig
+		signer = types.NewEIP155Signer(config.ChainID)
+		author = common.HexToAddress("0xc0ffee")
+		header = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: 10000000}
+	)
+	config.GasPriceBlock = big.NewInt(2)
+
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.SetBalance(sender, big.NewInt(1000000000000000000))
+
+	data, err := types.NewGasPriceChange(big.NewInt(2), 10).Encode()
+	require.NoError(t, err)
+	tx, err := types.SignTx(types.NewTransaction(0, types.GasPriceManagerAddress, big.NewInt(1), 100000, big.NewInt(params.GasPriceConfig), data), signer, key)
+	require.NoError(t, err)
+
+	var usedGas uint64
+	receipt, _, err := ApplyTransaction(&config, nil, &author, new(GasPool).AddGas(header.GasLimit), statedb, header, tx, &usedGas, vm.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, big.NewInt(1), statedb.GetBalance(types.GasPriceManagerAddress))
+}
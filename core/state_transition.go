@@ -59,6 +59,7 @@ type StateTransition struct {
 	data       []byte
 	state      vm.StateDB
 	evm        *vm.EVM
+	results    []*types.CallResult
 }
 
 // Message represents a message sent to a contract.
@@ -77,6 +78,8 @@ type Message interface {
 	Nonce() uint64
 	CheckNonce() bool
 	Data() []byte
+	Calls() []types.BatchCall
+	SenderAuth() *types.SenderAuth
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
@@ -144,6 +147,11 @@ func (st *StateTransition) to() common.Address {
 	return *st.msg.To()
 }
 
+// sponsored returns true if a provider pays the gas of the message on behalf of its sender.
+func (st *StateTransition) sponsored() bool {
+	return st.msg.To() != nil && st.msg.GasPayer() != st.msg.From()
+}
+
 func (st *StateTransition) useGas(amount uint64) error {
 	if st.gas < amount {
 		return vm.ErrOutOfGas
@@ -158,6 +166,14 @@ func (st *StateTransition) buyGas() error {
 	if st.state.GetBalance(st.msg.GasPayer()).Cmp(mgval) < 0 {
 		return errInsufficientBalanceForGas
 	}
+	if st.sponsored() {
+		contract, payloads := sponsoredCalls(*st.msg.To(), st.data, st.msg.Calls())
+		for _, payload := range payloads {
+			if err := checkProviderPolicy(st.state, st.evm.BlockNumber.Uint64(), st.msg.GasPayer(), contract, st.msg.From(), payload, st.msg.Gas(), mgval); err != nil {
+				return err
+			}
+		}
+	}
 	if err := st.gp.SubGas(st.msg.Gas()); err != nil {
 		return err
 	}
@@ -177,6 +193,13 @@ func (st *StateTransition) preCheck() error {
 		} else if nonce > st.msg.Nonce() {
 			return ErrNonceTooLow
 		}
+		// Make sure this transaction pays the gas price scheduled for the block,
+		// once gas price changes are enabled.
+		if config := st.evm.ChainConfig(); config.IsGasPrice(st.evm.BlockNumber) {
+			if price := GasPriceAt(st.state, config, st.evm.BlockNumber.Uint64()); st.gasPrice.Cmp(price) != 0 {
+				return ErrInvalidGasPrice
+			}
+		}
 	}
 	return st.buyGas()
 }
@@ -202,6 +225,14 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	if err = st.useGas(gas); err != nil {
 		return nil, 0, false, err
 	}
+	// Contract senders authorize their transactions with their validation function
+	if auth := msg.SenderAuth(); auth != nil {
+		gas, err := validateContractSender(st.evm, msg.From(), auth, st.gas)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		st.gas -= gas
+	}
 
 	var (
 		evm = st.evm
@@ -219,6 +250,18 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 			option.ProviderAddress = msg.Provider()
 		}
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value, option)
+	} else if st.to() == types.BatchCallAddress && st.evm.ChainConfig().IsBatch(st.evm.BlockNumber) {
+		// Batches run their calls natively, one after the other
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyBatch()
+	} else if st.to() == types.EnterpriseManagerAddress && st.evm.ChainConfig().IsEnterprise(st.evm.BlockNumber) {
+		// Enterprise operations are executed natively, failures are reported like vm errors
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyEnterpriseOp()
+	} else if st.to() == types.GasPriceManagerAddress && st.evm.ChainConfig().IsGasPrice(st.evm.BlockNumber) {
+		// Gas price changes are executed natively as well
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyGasPriceChange()
 	} else {
 		// Increment the nonce for the next transaction
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
@@ -240,6 +283,81 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	return ret, st.gasUsed(), vmerr != nil, err
 }
 
+// applyBatch charges the gas of the calls then runs them in order. The calls are atomic:
+// if one of them fails, the state changes of all of them are reverted. The results of
+// the calls run are collected for the receipt.
+func (st *StateTransition) applyBatch() error {
+	calls := st.msg.Calls()
+	if err := validateBatch(calls, st.sponsored()); err != nil {
+		return err
+	}
+	gas, err := BatchCallsGas(calls)
+	if err != nil {
+		return err
+	}
+	if err := st.useGas(gas); err != nil {
+		return err
+	}
+	var (
+		sender   = vm.AccountRef(st.msg.From())
+		snapshot = st.state.Snapshot()
+	)
+	st.results = make([]*types.CallResult, 0, len(calls))
+	for _, call := range calls {
+		var (
+			available = st.gas
+			err       error
+		)
+		_, st.gas, err = st.evm.Call(sender, call.To, call.Data, st.gas, call.Value)
+
+		result := &types.CallResult{Status: types.ReceiptStatusSuccessful, GasUsed: available - st.gas}
+		if err != nil {
+			result.Status = types.ReceiptStatusFailed
+		}
+		st.results = append(st.results, result)
+		if err != nil {
+			st.state.RevertToSnapshot(snapshot)
+			return err
+		}
+	}
+	return nil
+}
+
+// CallResults returns the results of the calls of a batch message, once applied.
+func (st *StateTransition) CallResults() []*types.CallResult {
+	return st.results
+}
+
+// applyEnterpriseOp charges the operation gas then applies the owner operation carried in the message data.
+func (st *StateTransition) applyEnterpriseOp() error {
+	if err := st.useGas(params.EnterpriseOpGas); err != nil {
+		return err
+	}
+	if st.value.Sign() != 0 {
+		return ErrEnterpriseOpValue
+	}
+	op, err := types.DecodeEnterpriseOp(st.data)
+	if err != nil {
+		return err
+	}
+	return applyEnterpriseOp(st.state, st.msg.From(), op)
+}
+
+// applyGasPriceChange charges the operation gas then schedules the gas price change carried in the message data.
+func (st *StateTransition) applyGasPriceChange() error {
+	if err := st.useGas(params.GasPriceChangeGas); err != nil {
+		return err
+	}
+	if st.value.Sign() != 0 {
+		return ErrGasPriceChangeValue
+	}
+	change, err := types.DecodeGasPriceChange(st.data)
+	if err != nil {
+		return err
+	}
+	return applyGasPriceChange(st.evm, st.msg.From(), st.evm.BlockNumber.Uint64(), change)
+}
+
 func (st *StateTransition) refundGas() {
 	// Apply refund counter, capped to half of the used gas.
 	refund := st.gasUsed() / 2
@@ -252,6 +370,13 @@ func (st *StateTransition) refundGas() {
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
 	st.state.AddBalance(st.msg.GasPayer(), remaining)
 
+	// Count the fee actually paid by the provider against its budget
+	if st.sponsored() {
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice)
+		contract, _ := sponsoredCalls(*st.msg.To(), st.data, st.msg.Calls())
+		recordProviderSpending(st.state, st.evm.BlockNumber.Uint64(), st.msg.GasPayer(), contract, st.msg.From(), fee)
+	}
+
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
 	st.gp.AddGas(st.gas)
@@ -188,13 +188,14 @@ func (m *txSortedMap) Remove(nonce uint64) bool {
 }
 
 // Ready retrieves a sequentially increasing list of transactions starting at the
-// provided nonce that is ready for processing. The returned transactions will be
-// removed from the list.
+// provided nonce that is ready for processing in the block with the given number.
+// The list stops at the first scheduled transaction whose window did not start
+// yet. The returned transactions will be removed from the list.
 //
 // Note, all transactions with nonces lower than start will also be returned to
 // prevent getting into and invalid state. This is not something that should ever
 // happen but better to be self correcting than failing!
-func (m *txSortedMap) Ready(start uint64) types.Transactions {
+func (m *txSortedMap) Ready(start, number uint64) types.Transactions {
 	// Short circuit if no transactions are available
 	if m.index.Len() == 0 || (*m.index)[0] > start {
 		return nil
@@ -202,6 +203,9 @@ func (m *txSortedMap) Ready(start uint64) types.Transactions {
 	// Otherwise start accumulating incremental transactions
 	var ready types.Transactions
 	for next := (*m.index)[0]; m.index.Len() > 0 && (*m.index)[0] == next; next++ {
+		if m.items[next].NotYetValid(number) {
+			break
+		}
 		ready = append(ready, m.items[next])
 		delete(m.items, next)
 		heap.Pop(m.index)
@@ -355,14 +359,15 @@ func (l *txList) Remove(tx *types.Transaction) (bool, types.Transactions) {
 }
 
 // Ready retrieves a sequentially increasing list of transactions starting at the
-// provided nonce that is ready for processing. The returned transactions will be
-// removed from the list.
+// provided nonce that is ready for processing in the block with the given number.
+// The list stops at the first scheduled transaction whose window did not start
+// yet. The returned transactions will be removed from the list.
 //
 // Note, all transactions with nonces lower than start will also be returned to
 // prevent getting into and invalid state. This is not something that should ever
 // happen but better to be self correcting than failing!
-func (l *txList) Ready(start uint64) types.Transactions {
-	return l.txs.Ready(start)
+func (l *txList) Ready(start, number uint64) types.Transactions {
+	return l.txs.Ready(start, number)
 }
 
 // Len returns the length of the transaction list.
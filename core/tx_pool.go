@@ -29,6 +29,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common/prque"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/log"
@@ -75,6 +76,14 @@ var (
 	// is higher than the balance of the provider's account (fee's paid by provider)
 	ErrProviderInsufficientFunds = errors.New("provider has insufficient funds for gas * price")
 
+	// ErrProviderSlots is returned if the provider of the transaction already
+	// sponsors the maximum number of pooled transactions
+	ErrProviderSlots = errors.New("provider has too many pooled transactions")
+
+	// ErrProviderOvercommitted is returned if the fees of all pooled transactions paid by
+	// the provider, including the new one, are higher than the balance of the provider's account
+	ErrProviderOvercommitted = errors.New("provider has insufficient funds for pending transactions")
+
 	// ErrSenderInsufficientFunds is returned if the transaction value
 	// is higher than the balance of the user's account (fee's paid by provider)
 	ErrSenderInsufficientFunds = errors.New("sender has insufficient funds for value")
@@ -108,6 +117,10 @@ var (
 
 	// ErrMaxProvider will be returned if the providers are over the limit
 	ErrMaxProvider = errors.New("maximum provider in contract")
+
+	// ErrScheduleHorizon is returned if the window of a scheduled transaction starts
+	// further after the pending block than the pool is willing to hold it.
+	ErrScheduleHorizon = errors.New("transaction scheduled too far in the future")
 )
 
 var (
@@ -133,6 +146,12 @@ var (
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
 
+	// fairnessEvictionMeter counts transactions dropped from the largest account to make room for another one
+	fairnessEvictionMeter = metrics.NewRegisteredMeter("txpool/fairness/evicted", nil)
+
+	// providerEvictionMeter counts transactions dropped because their provider can no longer pay for them
+	providerEvictionMeter = metrics.NewRegisteredMeter("txpool/provider/evicted", nil)
+
 	pendingCounter = metrics.NewRegisteredCounter("txpool/pending", nil)
 	queuedCounter  = metrics.NewRegisteredCounter("txpool/queued", nil)
 	localCounter   = metrics.NewRegisteredCounter("txpool/local", nil)
@@ -161,6 +180,7 @@ type blockChain interface {
 // TxPoolConfig are the configuration parameters of the transaction pool.
 type TxPoolConfig struct {
 	Locals    []common.Address // Addresses that should be treated by default as local
+	Priority  []common.Address // Whitelisted addresses exempt from fair share eviction and mined before remotes
 	NoLocals  bool             // Whether local transaction handling should be disabled
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
@@ -173,7 +193,12 @@ type TxPoolConfig struct {
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
-	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+	ProviderSlots             uint64 // Maximum number of transaction slots sponsored by a single provider
+	ContractSenderSlots       uint64 // Maximum number of transaction slots of a single contract sender
+	GlobalContractSenderSlots uint64 // Maximum number of transaction slots of all contract senders
+
+	Lifetime        time.Duration // Maximum amount of time non-executable transaction are queued
+	ScheduleHorizon uint64        // Maximum number of blocks the window of a scheduled transaction may start after the pending block
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -190,7 +215,12 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	AccountQueue: 64,
 	GlobalQueue:  1024,
 
-	Lifetime: 3 * time.Hour,
+	ProviderSlots:             1024,
+	ContractSenderSlots:       4,
+	GlobalContractSenderSlots: 256,
+
+	Lifetime:        3 * time.Hour,
+	ScheduleHorizon: 86400,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -225,10 +255,26 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool global queue", "provided", conf.GlobalQueue, "updated", DefaultTxPoolConfig.GlobalQueue)
 		conf.GlobalQueue = DefaultTxPoolConfig.GlobalQueue
 	}
+	if conf.ProviderSlots < 1 {
+		log.Warn("Sanitizing invalid txpool provider slots", "provided", conf.ProviderSlots, "updated", DefaultTxPoolConfig.ProviderSlots)
+		conf.ProviderSlots = DefaultTxPoolConfig.ProviderSlots
+	}
+	if conf.ContractSenderSlots < 1 {
+		log.Warn("Sanitizing invalid txpool contract sender slots", "provided", conf.ContractSenderSlots, "updated", DefaultTxPoolConfig.ContractSenderSlots)
+		conf.ContractSenderSlots = DefaultTxPoolConfig.ContractSenderSlots
+	}
+	if conf.GlobalContractSenderSlots < 1 {
+		log.Warn("Sanitizing invalid txpool global contract sender slots", "provided", conf.GlobalContractSenderSlots, "updated", DefaultTxPoolConfig.GlobalContractSenderSlots)
+		conf.GlobalContractSenderSlots = DefaultTxPoolConfig.GlobalContractSenderSlots
+	}
 	if conf.Lifetime < 1 {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.ScheduleHorizon < 1 {
+		log.Warn("Sanitizing invalid txpool schedule horizon", "provided", conf.ScheduleHorizon, "updated", DefaultTxPoolConfig.ScheduleHorizon)
+		conf.ScheduleHorizon = DefaultTxPoolConfig.ScheduleHorizon
+	}
 	return conf
 }
 
@@ -251,12 +297,17 @@ type TxPool struct {
 	signer       types.Signer
 	mu           sync.RWMutex
 
-	currentState  *state.StateDB      // Current state in the blockchain head
-	pendingState  *state.ManagedState // Pending state tracking virtual nonces
-	currentMaxGas uint64              // Current gas limit for transaction caps
+	currentState    *state.StateDB      // Current state in the blockchain head
+	pendingState    *state.ManagedState // Pending state tracking virtual nonces
+	currentMaxGas   uint64              // Current gas limit for transaction caps
+	currentGasPrice *big.Int            // Gas price scheduled for the pending block
+	pendingNumber   uint64              // Number of the pending block, to check transaction windows against
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	senderFailures map[common.Address]int // Failed validations of each contract sender since the last head
+
+	locals   *accountSet // Set of local transaction to exempt from eviction rules
+	priority *accountSet // Set of whitelisted accounts exempt from fair share eviction
+	journal  *txJournal  // Journal of local transaction to back up to disk
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -284,15 +335,22 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pending:     make(map[common.Address]*txList),
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
-		all:         newTxLookup(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
+
+		currentGasPrice: new(big.Int),
+		senderFailures:  make(map[common.Address]int),
 	}
+	pool.all = newTxLookup(pool.signer)
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
+	pool.priority = newAccountSet(pool.signer)
+	for _, addr := range config.Priority {
+		pool.priority.add(addr)
+	}
 	pool.priced = newTxPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
@@ -350,8 +408,14 @@ func (pool *TxPool) loop() {
 				}
 				pool.reset(head.Header(), ev.Block.Header())
 				head = ev.Block
+				price, threshold := pool.currentGasPrice, pool.gasPrice
 
 				pool.mu.Unlock()
+
+				// Follow the gas price scheduled in the state of the new head
+				if price.Cmp(threshold) != 0 {
+					pool.SetGasPrice(new(big.Int).Set(price))
+				}
 			}
 		// Be unsubscribed due to system stopped
 		case <-pool.chainHeadSub.Err():
@@ -377,10 +441,13 @@ func (pool *TxPool) loop() {
 				if pool.locals.contains(addr) {
 					continue
 				}
-				// Any non-locals old enough should be removed
+				// Any non-locals old enough should be removed, except for scheduled
+				// transactions held until their window starts
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
-						pool.removeTx(tx.Hash(), true)
+						if !tx.NotYetValid(pool.pendingNumber) {
+							pool.removeTx(tx.Hash(), true)
+						}
 					}
 				}
 			}
@@ -485,6 +552,12 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.currentState = statedb
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
+	pool.pendingNumber = newHead.Number.Uint64() + 1
+	pool.senderFailures = make(map[common.Address]int)
+
+	gasPrice := GasPriceAt(statedb, pool.chainconfig, newHead.Number.Uint64()+1)
+	priceChanged := pool.currentGasPrice.Cmp(gasPrice) != 0
+	pool.currentGasPrice = gasPrice
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
@@ -497,6 +570,19 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// higher gas price)
 	pool.demoteUnexecutables()
 
+	// drop the transactions priced for the previous gas price schedule
+	if priceChanged {
+		pool.removeMispriced()
+	}
+	// drop the transactions of providers which can no longer pay for all of them
+	pool.evictOvercommittedProviders()
+
+	// drop the scheduled transactions whose window ended
+	pool.removeExpired()
+
+	// drop the transactions their sender contract no longer authorizes
+	pool.revalidateContractSenders()
+
 	// Update all accounts to the latest known pending nonce
 	for addr, list := range pool.pending {
 		txs := list.Flatten() // Heavy but will be cached and is needed by the miner anyway
@@ -536,6 +622,15 @@ func (pool *TxPool) GasPrice() *big.Int {
 	return new(big.Int).Set(pool.gasPrice)
 }
 
+// ScheduledGasPrice returns the gas price scheduled for the pending block in the
+// state of the current head, which every transaction must pay.
+func (pool *TxPool) ScheduledGasPrice() *big.Int {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return new(big.Int).Set(pool.currentGasPrice)
+}
+
 // SetGasPrice updates the minimum price required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
@@ -597,6 +692,12 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// ProviderSpends retrieves the sum of the fees each provider committed to pay for
+// the pending and queued transactions of the pool.
+func (pool *TxPool) ProviderSpends() map[common.Address]*big.Int {
+	return pool.all.ProviderSpends()
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -611,6 +712,14 @@ func (pool *TxPool) Pending() (map[common.Address]types.Transactions, error) {
 	return pending, nil
 }
 
+// Priorities retrieves the whitelisted accounts of the pool.
+func (pool *TxPool) Priorities() []common.Address {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.priority.flatten()
+}
+
 // Locals retrieves the accounts currently considered local by the pool.
 func (pool *TxPool) Locals() []common.Address {
 	pool.mu.Lock()
@@ -651,14 +760,27 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 	if pool.currentMaxGas < tx.Gas() {
 		return ErrGasLimit
 	}
+	// Reject transaction types not yet enabled at the pending block
+	if !txTypeSupported(pool.chainconfig, tx, new(big.Int).SetUint64(pool.pendingNumber)) {
+		return types.ErrTxTypeNotSupported
+	}
 	// Make sure the transaction is signed properly
 	from, err := types.Sender(pool.signer, tx)
 	if err != nil {
 		return ErrInvalidSender
 	}
 
-	//Vlidate gasPrice of tx must be as the same as gasPrice of chainConfig
-	if tx.GasPrice().Cmp(pool.chainconfig.GasPrice) != 0 {
+	// Scheduled transactions can't be included once their window ended
+	if tx.Expired(pool.pendingNumber) {
+		return ErrTxExpired
+	}
+	// Scheduled transactions can only be held for a limited number of blocks
+	if tx.ValidAfter() > pool.pendingNumber+pool.config.ScheduleHorizon {
+		return ErrScheduleHorizon
+	}
+
+	//Vlidate gasPrice of tx must be as the same as the scheduled gasPrice of the pending block
+	if tx.GasPrice().Cmp(pool.currentGasPrice) != 0 {
 		return ErrInvalidGasPrice
 	}
 
@@ -666,12 +788,21 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 	// Otherwise, it should not have any provider's signature
 	// TODO: remove the log in production
 	signedProvider, providerRetrieveErr := types.Provider(pool.signer, tx)
+	if providerRetrieveErr != nil && tx.Type() != types.LegacyTxType {
+		// Typed transactions name their provider, who must always co-sign them
+		return ErrInvalidProvider
+	}
+	// Batches are checked call by call, sponsored ones may only call a single contract
+	if tx.IsBatch() {
+		if err := validateBatch(tx.Calls(), signedProvider != nil); err != nil {
+			return err
+		}
+	}
 	var isEnterpriseContract = false
-	if tx.To() != nil {
-		to := tx.To()
-		contractHash := pool.currentState.GetCodeHash(*to)
+	for _, to := range callTargets(tx.To(), tx.Calls()) {
+		contractHash := pool.currentState.GetCodeHash(to)
 		if (contractHash != common.Hash{}) && (contractHash != emptyCodeHash) {
-			expectedProviders := pool.currentState.GetProviders(*to)
+			expectedProviders := pool.currentState.GetProviders(to)
 			if len(expectedProviders) > 0 {
 				isEnterpriseContract = true
 				if providerRetrieveErr != nil {
@@ -688,7 +819,8 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 				}
 			}
 		}
-	} else {
+	}
+	if tx.To() == nil {
 		emptyAddress := common.Address{}
 		if tx.Provider() != nil && tx.Provider().Hex() != emptyAddress.Hex() {
 			if tx.Owner() == nil || (tx.Owner() != nil && tx.Owner().Hex() == emptyAddress.Hex()) {
@@ -701,6 +833,41 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 		// this case happens when there is no provider address required but still have provider's signature
 		return ErrRedundantProvider
 	}
+	// Enterprise operations must be signed by the current owner of the target contract
+	if tx.IsEnterpriseOp() {
+		if !pool.chainconfig.IsEnterprise(new(big.Int).SetUint64(pool.pendingNumber)) {
+			return ErrEnterpriseOpsInactive
+		}
+		if tx.Value().Sign() != 0 {
+			return ErrEnterpriseOpValue
+		}
+		op, err := types.DecodeEnterpriseOp(tx.Data())
+		if err != nil {
+			return err
+		}
+		if err := validateEnterpriseOp(pool.currentState, from, op); err != nil {
+			return err
+		}
+	}
+	// Gas price changes must be signed by the staking admin and activate after the pending block
+	if tx.IsGasPriceChange() {
+		if !pool.chainconfig.IsGasPrice(new(big.Int).SetUint64(pool.pendingNumber)) {
+			return ErrGasPriceChangesInactive
+		}
+		if tx.Value().Sign() != 0 {
+			return ErrGasPriceChangeValue
+		}
+		change, err := types.DecodeGasPriceChange(tx.Data())
+		if err != nil {
+			return err
+		}
+		snapshot := pool.currentState.Snapshot()
+		err = validateGasPriceChange(pool.pendingEVM(tx, from), from, pool.pendingNumber, change)
+		pool.currentState.RevertToSnapshot(snapshot)
+		if err != nil {
+			return err
+		}
+	}
 	// Drop non-local transactions under our own minimal accepted gas price
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
 	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
@@ -721,9 +888,39 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 		}
 
 		// Check provider's balance for transaction fee
-		if pool.currentState.GetBalance(*signedProvider).Cmp(tx.TransactionFee()) < 0 {
+		providerBalance := pool.currentState.GetBalance(*signedProvider)
+		if providerBalance.Cmp(tx.TransactionFee()) < 0 {
 			return ErrProviderInsufficientFunds
 		}
+		// Check provider's slots and balance for the fees of all its pooled transactions,
+		// not counting a pending or queued transaction this one would replace
+		committed, slots := pool.all.ProviderSpend(*signedProvider), pool.all.ProviderSlots(*signedProvider)
+		list := pool.pending[from]
+		if list == nil || list.txs.Get(tx.Nonce()) == nil {
+			list = pool.queue[from]
+		}
+		if list != nil {
+			if old := list.txs.Get(tx.Nonce()); old != nil {
+				if oldProvider := old.SignedProvider(pool.signer); oldProvider != nil && *oldProvider == *signedProvider {
+					committed.Sub(committed, old.TransactionFee())
+					slots--
+				}
+			}
+		}
+		if uint64(slots) >= pool.config.ProviderSlots {
+			return ErrProviderSlots
+		}
+		if committed.Add(committed, tx.TransactionFee()).Cmp(providerBalance) > 0 {
+			return ErrProviderOvercommitted
+		}
+		// Check the transaction is allowed by the provider's spending policy
+		number := pool.chain.CurrentBlock().NumberU64() + 1
+		contract, payloads := sponsoredCalls(*tx.To(), tx.Data(), tx.Calls())
+		for _, payload := range payloads {
+			if err := checkProviderPolicy(pool.currentState, number, *signedProvider, contract, from, payload, tx.Gas(), tx.TransactionFee()); err != nil {
+				return err
+			}
+		}
 	} else {
 		// Sender pays transaction fee, check sender's balance for tx costs
 		// cost == V + GP * GL
@@ -735,12 +932,105 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return err
 	}
+	if tx.IsEnterpriseOp() {
+		intrGas += params.EnterpriseOpGas
+	}
+	if tx.IsGasPriceChange() {
+		intrGas += params.GasPriceChangeGas
+	}
+	if tx.IsBatch() {
+		callsGas, err := BatchCallsGas(tx.Calls())
+		if err != nil {
+			return err
+		}
+		intrGas += callsGas
+	}
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
+	// Contract senders authorize their transactions with their validation function,
+	// which is costly to run, so their pooled transactions are capped
+	if tx.SenderAuth() != nil {
+		replaces := false
+		for _, list := range []*txList{pool.pending[from], pool.queue[from]} {
+			if list != nil && list.txs.Get(tx.Nonce()) != nil {
+				replaces = true
+			}
+		}
+		if !replaces && uint64(pool.slots(from)) >= pool.config.ContractSenderSlots {
+			return ErrContractSenderSlots
+		}
+		if !replaces && uint64(pool.all.ContractSenderSlots()) >= pool.config.GlobalContractSenderSlots {
+			return ErrContractSenderPoolFull
+		}
+		// Senders failing their validation too often are throttled until the next
+		// head, without running their validation function again
+		if uint64(pool.senderFailures[from]) >= pool.config.ContractSenderSlots {
+			return ErrContractSenderThrottled
+		}
+		if err := pool.validateContractSender(tx, from); err != nil {
+			if err == ErrSenderValidation {
+				pool.senderFailures[from]++
+			}
+			return err
+		}
+	}
 	return nil
 }
 
+// validateContractSender runs the validation function of the contract sending the
+// transaction against the current state, discarding any state change.
+func (pool *TxPool) validateContractSender(tx *types.Transaction, from common.Address) error {
+	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	if err != nil {
+		return err
+	}
+	if tx.Gas() < intrGas {
+		return ErrIntrinsicGas
+	}
+	snapshot := pool.currentState.Snapshot()
+	defer pool.currentState.RevertToSnapshot(snapshot)
+
+	_, err = validateContractSender(pool.pendingEVM(tx, from), from, tx.SenderAuth(), tx.Gas()-intrGas)
+	return err
+}
+
+// pendingEVM returns an EVM to run the calls validating a transaction in the pending
+// block on top of the current state. Callers revert the state changes of the calls.
+func (pool *TxPool) pendingEVM(tx *types.Transaction, from common.Address) *vm.EVM {
+	context := vm.Context{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Origin:      from,
+		GasPrice:    tx.GasPrice(),
+		GasLimit:    pool.currentMaxGas,
+		BlockNumber: new(big.Int).SetUint64(pool.pendingNumber),
+		Time:        new(big.Int).SetUint64(uint64(time.Now().Unix())),
+		Difficulty:  new(big.Int),
+	}
+	return vm.NewEVM(context, pool.currentState, pool.chainconfig, vm.Config{})
+}
+
+// revalidateContractSenders removes the transactions their sender contract no longer
+// authorizes, the transactions following them are moved back into the queue.
+func (pool *TxPool) revalidateContractSenders() {
+	var txs []*types.Transaction
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+		if tx.SenderAuth() != nil {
+			txs = append(txs, tx)
+		}
+		return true
+	})
+	for _, tx := range txs {
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		if err := pool.validateContractSender(tx, from); err != nil {
+			log.Trace("Removing unauthorized contract transaction", "hash", tx.Hash(), "err", err)
+			pool.removeTx(tx.Hash(), true)
+		}
+	}
+}
+
 // add validates a transaction and inserts it into the non-executable queue for
 // later pending promotion and execution. If the transaction is a replacement for
 // an already pending or queued one, it overwrites the previous and returns this
@@ -762,13 +1052,6 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
-	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
-		//discard new transaction when transaction pool is full
-		log.Trace("Discarding new transaction because transaction pool is full", "hash", hash, "price", tx.GasPrice())
-		pendingDiscardMeter.Mark(1)
-		return false, ErrTxPoolFull
-	}
 	// If the transaction has the same nonce with a pending transaction, discard it
 	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
@@ -776,6 +1059,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		pendingDiscardMeter.Mark(1)
 		return false, ErrSameNonce
 	}
+	// If the transaction pool is full, make room by evicting from the account using the
+	// most slots, discard the new transaction if its sender already uses its fair share
+	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue && !pool.evictForFairness(from, local) {
+		log.Trace("Discarding new transaction because transaction pool is full", "hash", hash, "price", tx.GasPrice())
+		pendingDiscardMeter.Mark(1)
+		return false, ErrTxPoolFull
+	}
 	// New transaction isn't replacing a pending one, push into queue
 	replace, err := pool.enqueueTx(hash, tx)
 	if err != nil {
@@ -1049,6 +1339,128 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 	}
 }
 
+// slots returns the number of pending and queued transactions of addr.
+func (pool *TxPool) slots(addr common.Address) int {
+	count := 0
+	if list := pool.pending[addr]; list != nil {
+		count += list.Len()
+	}
+	if list := pool.queue[addr]; list != nil {
+		count += list.Len()
+	}
+	return count
+}
+
+// evictForFairness removes the transaction with the highest nonce of the account using the
+// most slots, preferring queued transactions, if that account uses at least two slots more
+// than from. Local and priority accounts are never evicted, and always make room.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) evictForFairness(from common.Address, local bool) bool {
+	var (
+		victim common.Address
+		most   int
+	)
+	for _, accounts := range []map[common.Address]*txList{pool.pending, pool.queue} {
+		for addr := range accounts {
+			if pool.locals.contains(addr) || pool.priority.contains(addr) {
+				continue
+			}
+			if count := pool.slots(addr); count > most {
+				victim, most = addr, count
+			}
+		}
+	}
+	exempt := local || pool.locals.contains(from) || pool.priority.contains(from)
+	if most == 0 || (!exempt && most < pool.slots(from)+2) {
+		return false
+	}
+	list := pool.queue[victim]
+	if list == nil {
+		list = pool.pending[victim]
+	}
+	txs := list.Flatten()
+	log.Trace("Evicting transaction for fair share", "hash", txs[len(txs)-1].Hash(), "from", victim)
+	pool.removeTx(txs[len(txs)-1].Hash(), true)
+	fairnessEvictionMeter.Mark(1)
+	return true
+}
+
+// removeMispriced removes every transaction which does not pay the gas price currently
+// scheduled, as it can not be included in the pending block anymore.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) removeMispriced() {
+	var hashes []common.Hash
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+		if tx.GasPrice().Cmp(pool.currentGasPrice) != 0 {
+			hashes = append(hashes, hash)
+		}
+		return true
+	})
+	for _, hash := range hashes {
+		log.Trace("Removing transaction with outdated gas price", "hash", hash)
+		pool.removeTx(hash, true)
+	}
+}
+
+// removeExpired removes all scheduled transactions whose window ended before the
+// pending block, the transactions following them are moved back into the queue.
+func (pool *TxPool) removeExpired() {
+	var hashes []common.Hash
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+		if tx.Expired(pool.pendingNumber) {
+			hashes = append(hashes, hash)
+		}
+		return true
+	})
+	for _, hash := range hashes {
+		log.Trace("Removing expired scheduled transaction", "hash", hash)
+		pool.removeTx(hash, true)
+	}
+}
+
+// evictOvercommittedProviders removes transactions of providers whose balance no longer
+// covers the fees of all their pooled transactions. Queued transactions are dropped before
+// pending ones and higher nonces before lower ones, until the remaining fees are covered.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) evictOvercommittedProviders() {
+	for provider, spend := range pool.all.ProviderSpends() {
+		balance := pool.currentState.GetBalance(provider)
+		if spend.Cmp(balance) <= 0 {
+			continue
+		}
+		var txs types.Transactions
+		pool.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+			if signed := tx.SignedProvider(pool.signer); signed != nil && *signed == provider {
+				txs = append(txs, tx)
+			}
+			return true
+		})
+		isPending := func(tx *types.Transaction) bool {
+			from, _ := types.Sender(pool.signer, tx) // already validated
+			list := pool.pending[from]
+			return list != nil && list.txs.Get(tx.Nonce()) == tx
+		}
+		sort.SliceStable(txs, func(i, j int) bool {
+			if pi, pj := isPending(txs[i]), isPending(txs[j]); pi != pj {
+				return pj
+			}
+			return txs[i].Nonce() > txs[j].Nonce()
+		})
+		for _, tx := range txs {
+			if spend.Cmp(balance) <= 0 {
+				break
+			}
+			log.Trace("Removing transaction of overcommitted provider", "hash", tx.Hash(), "provider", provider)
+			spend.Sub(spend, tx.TransactionFee())
+			pool.removeTx(tx.Hash(), true)
+			providerEvictionMeter.Mark(1)
+		}
+	}
+}
+
 // TODO: Write comments about what this function does and returns
 func (pool *TxPool) filterUnpayableTransactions(account common.Address, l *txList) (types.Transactions, types.Transactions) {
 	var (
@@ -1125,7 +1537,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
 		// Gather all executable transactions and promote them
-		readies := list.Ready(pool.pendingState.GetNonce(addr))
+		readies := list.Ready(pool.pendingState.GetNonce(addr), pool.pendingNumber)
 		for _, tx := range readies {
 			hash := tx.Hash()
 			if pool.promoteTx(addr, hash, tx) {
@@ -1411,14 +1823,21 @@ func (as *accountSet) flatten() []common.Address {
 // peeking into the pool in TxPool.Get without having to acquire the widely scoped
 // TxPool.mu mutex.
 type txLookup struct {
-	all  map[common.Hash]*types.Transaction
-	lock sync.RWMutex
+	all     map[common.Hash]*types.Transaction
+	spends  map[common.Address]*big.Int // fees committed by providers across all pooled transactions
+	counts  map[common.Address]int      // number of pooled transactions sponsored by each provider
+	senders int                         // number of pooled transactions sent by contract accounts
+	signer  types.Signer
+	lock    sync.RWMutex
 }
 
 // newTxLookup returns a new txLookup structure.
-func newTxLookup() *txLookup {
+func newTxLookup(signer types.Signer) *txLookup {
 	return &txLookup{
-		all: make(map[common.Hash]*types.Transaction),
+		all:    make(map[common.Hash]*types.Transaction),
+		spends: make(map[common.Address]*big.Int),
+		counts: make(map[common.Address]int),
+		signer: signer,
 	}
 }
 
@@ -1456,6 +1875,18 @@ func (t *txLookup) Add(tx *types.Transaction) {
 	defer t.lock.Unlock()
 
 	t.all[tx.Hash()] = tx
+	if provider := tx.SignedProvider(t.signer); provider != nil {
+		spend, ok := t.spends[*provider]
+		if !ok {
+			spend = new(big.Int)
+			t.spends[*provider] = spend
+		}
+		spend.Add(spend, tx.TransactionFee())
+		t.counts[*provider]++
+	}
+	if tx.SenderAuth() != nil {
+		t.senders++
+	}
 }
 
 // Remove removes a transaction from the lookup.
@@ -1463,5 +1894,61 @@ func (t *txLookup) Remove(hash common.Hash) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	tx, ok := t.all[hash]
+	if !ok {
+		return
+	}
 	delete(t.all, hash)
+	if provider := tx.SignedProvider(t.signer); provider != nil {
+		if spend, ok := t.spends[*provider]; ok {
+			if spend.Sub(spend, tx.TransactionFee()).Sign() <= 0 {
+				delete(t.spends, *provider)
+			}
+		}
+		if t.counts[*provider]--; t.counts[*provider] <= 0 {
+			delete(t.counts, *provider)
+		}
+	}
+	if tx.SenderAuth() != nil {
+		t.senders--
+	}
+}
+
+// ProviderSpend returns the sum of the fees provider committed to pay for the transactions in the lookup.
+func (t *txLookup) ProviderSpend(provider common.Address) *big.Int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if spend, ok := t.spends[provider]; ok {
+		return new(big.Int).Set(spend)
+	}
+	return new(big.Int)
+}
+
+// ProviderSlots returns the number of transactions in the lookup sponsored by provider.
+func (t *txLookup) ProviderSlots(provider common.Address) int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.counts[provider]
+}
+
+// ContractSenderSlots returns the number of transactions in the lookup sent by contract accounts.
+func (t *txLookup) ContractSenderSlots() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.senders
+}
+
+// ProviderSpends returns the fees committed by every provider with transactions in the lookup.
+func (t *txLookup) ProviderSpends() map[common.Address]*big.Int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	spends := make(map[common.Address]*big.Int, len(t.spends))
+	for provider, spend := range t.spends {
+		spends[provider] = new(big.Int).Set(spend)
+	}
+	return spends
 }
@@ -258,6 +258,52 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+// Tests that the pool follows the gas price scheduled in the state of each new
+// chain head, raising its price threshold with it.
+func TestTransactionPoolScheduledGasPrice(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	price := big.NewInt(2 * params.GasPriceConfig)
+	statedb := pool.currentState
+	statedb.SetState(types.GasPriceManagerAddress, gasPriceScheduleKey, common.BigToHash(big.NewInt(1)))
+	statedb.SetState(types.GasPriceManagerAddress, slotAt(gasPriceScheduleKey, 1), common.BigToHash(big.NewInt(1)))
+	statedb.SetState(types.GasPriceManagerAddress, slotAt(gasPriceScheduleKey, 2), common.BigToHash(price))
+
+	pool.chain.(*testBlockChain).chainHeadFeed.Send(ChainHeadEvent{Block: pool.chain.CurrentBlock()})
+	for deadline := time.Now().Add(time.Second); pool.GasPrice().Cmp(price) != 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool gas price mismatch: have %v, want %v", pool.GasPrice(), price)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if have := pool.ScheduledGasPrice(); have.Cmp(price) != 0 {
+		t.Errorf("scheduled gas price mismatch: have %v, want %v", have, price)
+	}
+}
+
+// Tests that the pool rejects gas price changes before the gas price fork.
+func TestTransactionPoolGasPriceChangeFork(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	config := *pool.chainconfig
+	config.GasPriceBlock = big.NewInt(int64(pool.pendingNumber) + 1)
+	pool.chainconfig = &config
+
+	data, _ := types.NewGasPriceChange(big.NewInt(2), 10).Encode()
+	tx, _ := types.SignTx(types.NewTransaction(0, types.GasPriceManagerAddress, big.NewInt(0), 100000, big.NewInt(params.GasPriceConfig), data), types.HomesteadSigner{}, key)
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+
+	if err := pool.AddRemote(tx); err != ErrGasPriceChangesInactive {
+		t.Fatalf("gas price change error mismatch: have %v, want %v", err, ErrGasPriceChangesInactive)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 
@@ -1441,3 +1487,284 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.AddRemotes(batch)
 	}
 }
+
+// Tests that the pool tracks the fees a provider committed to across the
+// transactions of all senders, and drops transactions it can no longer pay for.
+func TestTransactionProviderSpends(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	var (
+		providerKey, _ = crypto.GenerateKey()
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		owner          = common.HexToAddress("0x2000")
+		contract       = common.HexToAddress("0x1000")
+		fee            = new(big.Int).Mul(big.NewInt(100000), big.NewInt(params.GasPriceConfig))
+	)
+	pool.currentState.CreateAccount(contract, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+	pool.currentState.SetCode(contract, []byte{0x60, 0x00})
+	pool.currentState.SetBalance(provider, new(big.Int).Mul(fee, big.NewInt(3)))
+
+	sponsored := func(nonce uint64, key *ecdsa.PrivateKey) *types.Transaction {
+		tx := types.NewTransaction(nonce, contract, big.NewInt(0), 100000, big.NewInt(params.GasPriceConfig), nil)
+		tx, _ = types.SignTx(tx, types.HomesteadSigner{}, key)
+		tx, _ = types.ProviderSignTx(tx, types.HomesteadSigner{}, providerKey)
+		return tx
+	}
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	// each transaction is payable on its own, but the provider can only pay for three of them
+	for i := 0; i < 3; i++ {
+		if err := pool.AddRemote(sponsored(0, keys[i])); err != nil {
+			t.Fatalf("failed to add sponsored transaction %d: %v", i, err)
+		}
+	}
+	if err := pool.AddRemote(sponsored(0, keys[3])); err != ErrProviderOvercommitted {
+		t.Fatalf("overcommitting transaction error mismatch: have %v, want %v", err, ErrProviderOvercommitted)
+	}
+	// the fee of a pending transaction being replaced isn't counted twice
+	replacement := types.NewTransaction(0, contract, big.NewInt(0), 90000, big.NewInt(params.GasPriceConfig), nil)
+	replacement, _ = types.SignTx(replacement, types.HomesteadSigner{}, keys[0])
+	replacement, _ = types.ProviderSignTx(replacement, types.HomesteadSigner{}, providerKey)
+	if err := pool.AddRemote(replacement); err != ErrSameNonce {
+		t.Fatalf("pending replacement error mismatch: have %v, want %v", err, ErrSameNonce)
+	}
+	if spend := pool.ProviderSpends()[provider]; spend.Cmp(new(big.Int).Mul(fee, big.NewInt(3))) != 0 {
+		t.Fatalf("provider spend mismatch: have %v, want %v", spend, new(big.Int).Mul(fee, big.NewInt(3)))
+	}
+	// once the provider spent part of its balance, the pool keeps only what it can pay for
+	pool.currentState.SetBalance(provider, new(big.Int).Add(fee, big.NewInt(1)))
+	pool.lockedReset(nil, nil)
+
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if spend := pool.ProviderSpends()[provider]; spend.Cmp(fee) != 0 {
+		t.Fatalf("provider spend mismatch: have %v, want %v", spend, fee)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// providers can only sponsor a limited number of transactions
+	pool.config.ProviderSlots = 1
+	if err := pool.AddRemote(sponsored(0, keys[3])); err != ErrProviderSlots {
+		t.Fatalf("provider slots error mismatch: have %v, want %v", err, ErrProviderSlots)
+	}
+}
+
+// Tests that sponsored transactions are accepted once co-signed by the provider
+// they name, and that the provider pays for them.
+func TestTransactionSponsoredType(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	var (
+		providerKey, _ = crypto.GenerateKey()
+		otherKey, _    = crypto.GenerateKey()
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		owner          = common.HexToAddress("0x2000")
+		contract       = common.HexToAddress("0x1000")
+		signer         = types.NewEIP155Signer(params.TestChainConfig.ChainID)
+	)
+	pool.currentState.CreateAccount(contract, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+	pool.currentState.SetCode(contract, []byte{0x60, 0x00})
+	pool.currentState.SetBalance(provider, big.NewInt(1000000000000000000))
+
+	tx, _ := types.SignTx(types.NewSponsoredTransaction(params.TestChainConfig.ChainID, 0, contract, provider, big.NewInt(0), 100000, big.NewInt(params.GasPriceConfig), nil), signer, key)
+	if err := pool.AddRemote(tx); err != ErrInvalidProvider {
+		t.Fatalf("unsigned provider error mismatch: have %v, want %v", err, ErrInvalidProvider)
+	}
+	forged, _ := types.ProviderSignTx(tx, signer, otherKey)
+	if err := pool.AddRemote(forged); err != ErrInvalidProvider {
+		t.Fatalf("forged provider error mismatch: have %v, want %v", err, ErrInvalidProvider)
+	}
+	signed, _ := types.ProviderSignTx(tx, signer, providerKey)
+	if err := pool.AddRemote(signed); err != nil {
+		t.Fatalf("failed to add sponsored transaction: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if spend := pool.ProviderSpends()[provider]; spend == nil || spend.Cmp(signed.TransactionFee()) != 0 {
+		t.Fatalf("provider spend mismatch: have %v, want %v", spend, signed.TransactionFee())
+	}
+}
+
+// Tests that a full pool makes room for accounts using less than their fair share
+// by evicting from the account using the most slots.
+func TestTransactionFairShare(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 4
+	config.GlobalQueue = 1
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000000000000))
+	}
+	// The first account fills the pool, the second one takes a slot from it
+	for i := 0; i < 5; i++ {
+		if err := pool.AddRemote(transaction(uint64(i), 100000, keys[0])); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if err := pool.AddRemote(transaction(0, 100000, keys[1])); err != nil {
+		t.Fatalf("failed to add transaction of new account: %v", err)
+	}
+	if slots := pool.slots(crypto.PubkeyToAddress(keys[0].PublicKey)); slots != 4 {
+		t.Fatalf("slots of the flooding account mismatch: have %d, want %d", slots, 4)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Once accounts are balanced, no account may evict another one
+	if err := pool.AddRemote(transaction(1, 100000, keys[1])); err != nil {
+		t.Fatalf("failed to add transaction of new account: %v", err)
+	}
+	if err := pool.AddRemote(transaction(2, 100000, keys[1])); err != ErrTxPoolFull {
+		t.Fatalf("balanced pool error mismatch: have %v, want %v", err, ErrTxPoolFull)
+	}
+	// Priority accounts always make room
+	pool.priority.add(crypto.PubkeyToAddress(keys[2].PublicKey))
+	if err := pool.AddRemote(transaction(0, 100000, keys[2])); err != nil {
+		t.Fatalf("failed to add transaction of priority account: %v", err)
+	}
+	if slots := pool.slots(crypto.PubkeyToAddress(keys[0].PublicKey)); slots != 2 {
+		t.Fatalf("slots of the flooding account mismatch: have %d, want %d", slots, 2)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that scheduled transactions are held in the queue until their window starts
+// and dropped once it ended, and that local ones survive restarts in the journal.
+func TestTransactionScheduled(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	file.Close()
+	os.Remove(journal)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = journal
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+
+	var (
+		signer    = types.NewEIP155Signer(params.TestChainConfig.ChainID)
+		head      = func(number int64) *types.Header { return &types.Header{Number: big.NewInt(number), GasLimit: 1000000} }
+		scheduled = func(nonce, validAfter, validBefore uint64) *types.Transaction {
+			tx := types.NewScheduledTransaction(params.TestChainConfig.ChainID, nonce, &common.Address{}, big.NewInt(100), 100000, big.NewInt(params.GasPriceConfig), nil, validAfter, validBefore, nil)
+			tx, _ = types.SignTx(tx, signer, key)
+			return tx
+		}
+	)
+	if err := pool.AddRemote(scheduled(0, 0, 1)); err != ErrTxExpired {
+		t.Fatalf("expired transaction error mismatch: have %v, want %v", err, ErrTxExpired)
+	}
+	if err := pool.AddRemote(scheduled(0, config.ScheduleHorizon+2, 0)); err != ErrScheduleHorizon {
+		t.Fatalf("distant transaction error mismatch: have %v, want %v", err, ErrScheduleHorizon)
+	}
+	if err := pool.AddLocal(scheduled(0, 5, 10)); err != nil {
+		t.Fatalf("failed to add scheduled transaction: %v", err)
+	}
+	if err := pool.AddLocal(scheduled(1, 0, 8)); err != nil {
+		t.Fatalf("failed to add scheduled transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 2 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 0, 2)
+	}
+	// Restart the pool and ensure the held transactions were journaled
+	pool.Stop()
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 2 {
+		t.Fatalf("journaled pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 0, 2)
+	}
+	// Promote them once the window of the first one starts
+	pool.lockedReset(nil, head(4))
+	if pending, queued := pool.Stats(); pending != 2 || queued != 0 {
+		t.Fatalf("promoted pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 2, 0)
+	}
+	// Drop the second one once its window ended
+	pool.lockedReset(nil, head(7))
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("expired pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 1, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that only the scheduled transactions themselves are exempt from the
+// lifetime eviction of queued transactions, not their whole account.
+func TestTransactionScheduledLifetime(t *testing.T) {
+	// Reduce the eviction interval to a testable amount
+	defer func(old time.Duration) { evictionInterval = old }(evictionInterval)
+	evictionInterval = time.Second
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Lifetime = time.Second
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainID)
+	scheduled, _ := types.SignTx(types.NewScheduledTransaction(params.TestChainConfig.ChainID, 0, &common.Address{}, big.NewInt(100), 100000, big.NewInt(params.GasPriceConfig), nil, 100, 0, nil), signer, key)
+	if err := pool.AddRemote(scheduled); err != nil {
+		t.Fatalf("failed to add scheduled transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(1, 100000, big.NewInt(params.GasPriceConfig), key)); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 2 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 0, 2)
+	}
+	// Wait a bit for eviction to run and ensure only the scheduled one remains
+	time.Sleep(2 * config.Lifetime)
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 1 {
+		t.Fatalf("evicted pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 0, 1)
+	}
+	if pool.Get(scheduled.Hash()) == nil {
+		t.Fatalf("scheduled transaction evicted")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
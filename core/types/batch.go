@@ -0,0 +1,111 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+)
+
+//go:generate gencodec -type BatchCall -field-override batchCallMarshaling -out gen_batch_call_json.go
+//go:generate gencodec -type CallResult -field-override callResultMarshaling -out gen_call_result_json.go
+
+// BatchCallAddress is the system address batch transactions are sent to. The calls of
+// a batch are executed natively by the state transition one after the other, the
+// address itself never receives any value.
+var BatchCallAddress = common.HexToAddress("0x0000000000000000000000000000000000000e03")
+
+// BatchCall is a single call of a batch transaction.
+type BatchCall struct {
+	To    common.Address `json:"to"    gencodec:"required"`
+	Value *big.Int       `json:"value" gencodec:"required"`
+	Data  []byte         `json:"input" gencodec:"required"`
+}
+
+type batchCallMarshaling struct {
+	Value *hexutil.Big
+	Data  hexutil.Bytes
+}
+
+// CallResult is the outcome of a single call of a batch transaction. The calls of a
+// batch are atomic, so the results end with the first failing call, if any.
+type CallResult struct {
+	Status  uint64 `json:"status"  gencodec:"required"`
+	GasUsed uint64 `json:"gasUsed" gencodec:"required"`
+}
+
+type callResultMarshaling struct {
+	Status  hexutil.Uint64
+	GasUsed hexutil.Uint64
+}
+
+// batchTxdata is the payload of BatchTxType transactions. A batch is signed once and
+// uses a single nonce for all of its calls. If it names a provider, the provider pays
+// the gas and must co-sign the batch including the sender signature, like for
+// sponsored transactions.
+type batchTxdata struct {
+	ChainID      *big.Int
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     uint64
+	Calls        []BatchCall
+	Provider     *common.Address `rlp:"nil"`
+
+	// Sender signature values
+	V, R, S *big.Int
+
+	// Provider signature values
+	PV, PR, PS *big.Int
+}
+
+// NewBatchTransaction creates a BatchTxType transaction running the given calls in
+// order. A non-nil provider pays the gas once it co-signed the sender-signed batch.
+func NewBatchTransaction(chainID *big.Int, nonce uint64, calls []BatchCall, gasLimit uint64, gasPrice *big.Int, provider *common.Address) *Transaction {
+	to := BatchCallAddress
+	tx := newTransaction(nonce, &to, nil, gasLimit, gasPrice, nil)
+	tx.data.Type = BatchTxType
+	tx.data.ChainID = new(big.Int)
+	if chainID != nil {
+		tx.data.ChainID.Set(chainID)
+	}
+	tx.data.Calls = make([]BatchCall, len(calls))
+	for i, call := range calls {
+		tx.data.Calls[i] = BatchCall{To: call.To, Value: new(big.Int), Data: common.CopyBytes(call.Data)}
+		if call.Value != nil {
+			tx.data.Calls[i].Value.Set(call.Value)
+		}
+	}
+	tx.data.Amount = batchValue(tx.data.Calls)
+	if provider != nil {
+		cpy := *provider
+		tx.data.Provider = &cpy
+	}
+	tx.data.PV, tx.data.PR, tx.data.PS = new(big.Int), new(big.Int), new(big.Int)
+	return tx
+}
+
+// Calls returns the calls of a batch transaction, nil for other transactions.
+func (tx *Transaction) Calls() []BatchCall {
+	if tx.data.Calls == nil {
+		return nil
+	}
+	calls := make([]BatchCall, len(tx.data.Calls))
+	copy(calls, tx.data.Calls)
+	return calls
+}
+
+// IsBatch returns true if the transaction carries a batch of calls
+func (tx *Transaction) IsBatch() bool {
+	return tx.data.Type == BatchTxType
+}
+
+// batchValue returns the total value transferred by the calls.
+func batchValue(calls []BatchCall) *big.Int {
+	value := new(big.Int)
+	for _, call := range calls {
+		if call.Value != nil {
+			value.Add(value, call.Value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// contractTxdata is the payload of ContractTxType transactions, which are sent by a
+// contract account instead of an externally owned one. They carry no signature: the
+// sender contract authorizes them with its validation function, which is given the
+// hash of the payload without Auth and the Auth data, e.g. the signatures of the
+// owners of a multisig wallet.
+type contractTxdata struct {
+	ChainID      *big.Int
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     uint64
+	Sender       common.Address
+	Recipient    *common.Address `rlp:"nil"` // nil means contract creation
+	Amount       *big.Int
+	Payload      []byte
+	Auth         []byte
+}
+
+// SenderAuth is the authorization of a transaction sent by a contract account,
+// checked by the validation function of the contract.
+type SenderAuth struct {
+	Hash common.Hash // Hash of the transaction, excluding the authorization data
+	Data []byte      // Authorization data passed to the validation function
+}
+
+// NewContractTransaction creates a ContractTxType transaction sent by the given
+// contract account, which pays its gas. The auth data is passed to the validation
+// function of the contract, it can be attached later using WithAuth.
+func NewContractTransaction(chainID *big.Int, sender common.Address, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, auth []byte) *Transaction {
+	tx := newTransaction(nonce, to, amount, gasLimit, gasPrice, data)
+	tx.data.Type = ContractTxType
+	tx.data.ChainID = new(big.Int)
+	if chainID != nil {
+		tx.data.ChainID.Set(chainID)
+	}
+	tx.data.Sender = &sender
+	tx.data.Auth = common.CopyBytes(auth)
+	tx.data.PV, tx.data.PR, tx.data.PS = new(big.Int), new(big.Int), new(big.Int)
+	return tx
+}
+
+// WithAuth returns a copy of the contract sent transaction carrying the given
+// authorization data. The hash to authorize doesn't depend on it.
+func (tx *Transaction) WithAuth(auth []byte) *Transaction {
+	cpy := &Transaction{data: tx.data}
+	cpy.data.Auth = common.CopyBytes(auth)
+	return cpy
+}
+
+// SenderAuth returns the authorization of a transaction sent by a contract account,
+// nil for signed transactions.
+func (tx *Transaction) SenderAuth() *SenderAuth {
+	if tx.data.Type != ContractTxType {
+		return nil
+	}
+	return &SenderAuth{Hash: typedSenderHash(tx), Data: common.CopyBytes(tx.data.Auth)}
+}
@@ -0,0 +1,124 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// EnterpriseManagerAddress is the system address enterprise contract owners send their
+// management operations to. Transactions to this address are executed natively by the
+// state transition instead of the EVM.
+var EnterpriseManagerAddress = common.HexToAddress("0x0000000000000000000000000000000000000e01")
+
+var (
+	// ErrInvalidEnterpriseOp is returned if the payload sent to EnterpriseManagerAddress can not be decoded
+	ErrInvalidEnterpriseOp = errors.New("invalid enterprise contract operation")
+)
+
+// EnterpriseOpType is the kind of management operation an owner applies to its enterprise contract
+type EnterpriseOpType uint8
+
+const (
+	// EnterpriseOpAddProvider adds Address to the providers of Contract
+	EnterpriseOpAddProvider EnterpriseOpType = iota + 1
+	// EnterpriseOpRemoveProvider removes Address from the providers of Contract
+	EnterpriseOpRemoveProvider
+	// EnterpriseOpTransferOwnership makes Address the new owner of Contract
+	EnterpriseOpTransferOwnership
+	// EnterpriseOpRenounceOwnership leaves Contract without owner, Address is ignored
+	EnterpriseOpRenounceOwnership
+	// EnterpriseOpSetPolicy sets the spending policy of the sending provider on Contract,
+	// a nil Policy removes it. Address is ignored
+	EnterpriseOpSetPolicy
+)
+
+// String implements fmt.Stringer
+func (t EnterpriseOpType) String() string {
+	switch t {
+	case EnterpriseOpAddProvider:
+		return "addProvider"
+	case EnterpriseOpRemoveProvider:
+		return "removeProvider"
+	case EnterpriseOpTransferOwnership:
+		return "transferOwnership"
+	case EnterpriseOpRenounceOwnership:
+		return "renounceOwnership"
+	case EnterpriseOpSetPolicy:
+		return "setPolicy"
+	default:
+		return "unknown"
+	}
+}
+
+// ProviderPolicy limits what a provider pays for the transactions it co-signs to an enterprise contract.
+// Zero or nil limits mean no limit. The fee limits apply per budget period, whose length is
+// Period blocks, a Period of 0 makes every block a budget period of its own.
+type ProviderPolicy struct {
+	MaxGasPerTx     uint64           // gas limit of a single sponsored transaction
+	MaxFeePerPeriod *big.Int         // total fee paid by the provider in a period
+	MaxFeePerSender *big.Int         // fee paid by the provider for a single sender in a period
+	Period          uint64           // length of a budget period in blocks, 0 for periods of a single block
+	AllowedSenders  []common.Address // senders the provider pays for, empty allows any
+	AllowedMethods  [][4]byte        // method selectors the provider pays for, empty allows any
+}
+
+// PeriodOf returns the index of the budget period the given block belongs to
+func (p *ProviderPolicy) PeriodOf(blockNumber uint64) uint64 {
+	if p.Period == 0 {
+		return blockNumber
+	}
+	return blockNumber / p.Period
+}
+
+// EnterpriseOp is an operation on the owner, providers or provider policies of an enterprise contract.
+// It is carried RLP-encoded in the payload of a transaction sent to EnterpriseManagerAddress.
+// Owner and provider operations must be signed by the owner, policies by the provider they belong to.
+type EnterpriseOp struct {
+	Type     EnterpriseOpType
+	Contract common.Address
+	Address  common.Address
+	Policy   *ProviderPolicy `rlp:"nil"`
+}
+
+// NewEnterpriseOp returns a new operation of the given type on contract
+func NewEnterpriseOp(opType EnterpriseOpType, contract, address common.Address) *EnterpriseOp {
+	return &EnterpriseOp{
+		Type:     opType,
+		Contract: contract,
+		Address:  address,
+	}
+}
+
+// NewSetPolicyOp returns an operation setting the policy of the sending provider on contract
+func NewSetPolicyOp(contract common.Address, policy *ProviderPolicy) *EnterpriseOp {
+	return &EnterpriseOp{
+		Type:     EnterpriseOpSetPolicy,
+		Contract: contract,
+		Policy:   policy,
+	}
+}
+
+// Encode returns the transaction payload of the operation
+func (op *EnterpriseOp) Encode() ([]byte, error) {
+	return rlp.EncodeToBytes(op)
+}
+
+// DecodeEnterpriseOp decodes a transaction payload sent to EnterpriseManagerAddress
+func DecodeEnterpriseOp(data []byte) (*EnterpriseOp, error) {
+	var op EnterpriseOp
+	if err := rlp.DecodeBytes(data, &op); err != nil {
+		return nil, ErrInvalidEnterpriseOp
+	}
+	if op.Type < EnterpriseOpAddProvider || op.Type > EnterpriseOpSetPolicy {
+		return nil, ErrInvalidEnterpriseOp
+	}
+	return &op, nil
+}
+
+// IsEnterpriseOp returns true if the transaction is a management operation on an enterprise contract
+func (tx *Transaction) IsEnterpriseOp() bool {
+	return tx.data.Recipient != nil && *tx.data.Recipient == EnterpriseManagerAddress
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// GasPriceManagerAddress is the system address the staking admin sends gas price changes to.
+// Transactions to this address are executed natively by the state transition instead of the EVM.
+var GasPriceManagerAddress = common.HexToAddress("0x0000000000000000000000000000000000000e02")
+
+var (
+	// ErrInvalidGasPriceChange is returned if the payload sent to GasPriceManagerAddress can not be decoded
+	ErrInvalidGasPriceChange = errors.New("invalid gas price change")
+)
+
+// GasPriceChange schedules Price to be the gas price of every transaction from block Activation on.
+type GasPriceChange struct {
+	Price      *big.Int
+	Activation uint64
+}
+
+// NewGasPriceChange returns a change of the gas price to price from block activation on
+func NewGasPriceChange(price *big.Int, activation uint64) *GasPriceChange {
+	return &GasPriceChange{
+		Price:      price,
+		Activation: activation,
+	}
+}
+
+// Encode returns the transaction payload of the change
+func (c *GasPriceChange) Encode() ([]byte, error) {
+	return rlp.EncodeToBytes(c)
+}
+
+// DecodeGasPriceChange decodes a transaction payload sent to GasPriceManagerAddress
+func DecodeGasPriceChange(data []byte) (*GasPriceChange, error) {
+	var change GasPriceChange
+	if err := rlp.DecodeBytes(data, &change); err != nil {
+		return nil, ErrInvalidGasPriceChange
+	}
+	if change.Price == nil || change.Price.Sign() <= 0 {
+		return nil, ErrInvalidGasPriceChange
+	}
+	return &change, nil
+}
+
+// IsGasPriceChange returns true if the transaction schedules a new gas price
+func (tx *Transaction) IsGasPriceChange() bool {
+	return tx.data.Recipient != nil && *tx.data.Recipient == GasPriceManagerAddress
+}
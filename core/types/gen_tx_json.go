@@ -30,6 +30,13 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 		PS           *hexutil.Big    `json:"ps"`
 		Owner        *common.Address `json:"owner" rlp:"nil"`
 		Provider     *common.Address `json:"provider" rlp:"nil"`
+		Type         hexutil.Uint64  `json:"type,omitempty"    rlp:"-"`
+		ChainID      *hexutil.Big    `json:"chainId,omitempty" rlp:"-"`
+		Calls        []BatchCall     `json:"calls,omitempty" rlp:"-"`
+		ValidAfter   hexutil.Uint64  `json:"validAfterBlock,omitempty"  rlp:"-"`
+		ValidBefore  hexutil.Uint64  `json:"validBeforeBlock,omitempty" rlp:"-"`
+		Sender       *common.Address `json:"sender,omitempty" rlp:"-"`
+		Auth         hexutil.Bytes   `json:"auth,omitempty"   rlp:"-"`
 		Hash         *common.Hash    `json:"hash" rlp:"-"`
 	}
 	var enc txdata
@@ -48,6 +55,13 @@ func (t txdata) MarshalJSON() ([]byte, error) {
 	enc.Hash = t.Hash
 	enc.Provider = t.Provider
 	enc.Owner = t.Owner
+	enc.Type = hexutil.Uint64(t.Type)
+	enc.ChainID = (*hexutil.Big)(t.ChainID)
+	enc.Calls = t.Calls
+	enc.ValidAfter = hexutil.Uint64(t.ValidAfter)
+	enc.ValidBefore = hexutil.Uint64(t.ValidBefore)
+	enc.Sender = t.Sender
+	enc.Auth = t.Auth
 	return json.Marshal(&enc)
 }
 
@@ -68,6 +82,13 @@ func (t *txdata) UnmarshalJSON(input []byte) error {
 		PS           *hexutil.Big    `json:"ps"`
 		Owner        *common.Address `json:"owner" rlp:"nil"`
 		Provider     *common.Address `json:"provider" rlp:"nil"`
+		Type         *hexutil.Uint64 `json:"type,omitempty"    rlp:"-"`
+		ChainID      *hexutil.Big    `json:"chainId,omitempty" rlp:"-"`
+		Calls        []BatchCall     `json:"calls,omitempty" rlp:"-"`
+		ValidAfter   *hexutil.Uint64 `json:"validAfterBlock,omitempty"  rlp:"-"`
+		ValidBefore  *hexutil.Uint64 `json:"validBeforeBlock,omitempty" rlp:"-"`
+		Sender       *common.Address `json:"sender,omitempty" rlp:"-"`
+		Auth         *hexutil.Bytes  `json:"auth,omitempty"   rlp:"-"`
 		Hash         *common.Hash    `json:"hash" rlp:"-"`
 	}
 	var dec txdata
@@ -119,5 +140,26 @@ func (t *txdata) UnmarshalJSON(input []byte) error {
 
 	t.Provider = dec.Provider
 	t.Owner = dec.Owner
+	if dec.Type != nil {
+		t.Type = uint8(*dec.Type)
+	}
+	if dec.ChainID != nil {
+		t.ChainID = (*big.Int)(dec.ChainID)
+	}
+	if dec.Calls != nil {
+		t.Calls = dec.Calls
+	}
+	if dec.ValidAfter != nil {
+		t.ValidAfter = uint64(*dec.ValidAfter)
+	}
+	if dec.ValidBefore != nil {
+		t.ValidBefore = uint64(*dec.ValidBefore)
+	}
+	if dec.Sender != nil {
+		t.Sender = dec.Sender
+	}
+	if dec.Auth != nil {
+		t.Auth = *dec.Auth
+	}
 	return nil
 }
@@ -55,6 +55,9 @@ type Receipt struct {
 	Bloom             Bloom  `json:"logsBloom"         gencodec:"required"`
 	Logs              []*Log `json:"logs"              gencodec:"required"`
 
+	// Results of the calls of batch transactions, empty for other transactions
+	CallResults []*CallResult `json:"callResults,omitempty"`
+
 	// Implementation fields: These fields are added by geth when processing a transaction.
 	// They are stored in the chain database.
 	TxHash          common.Hash    `json:"transactionHash" gencodec:"required"`
@@ -78,12 +81,14 @@ type receiptMarshaling struct {
 	TransactionIndex  hexutil.Uint
 }
 
-// receiptRLP is the consensus encoding of a receipt.
+// receiptRLP is the consensus encoding of a receipt. The call results of batch
+// transactions trail the list, leaving the encoding of other receipts unchanged.
 type receiptRLP struct {
 	PostStateOrStatus []byte
 	CumulativeGasUsed uint64
 	Bloom             Bloom
 	Logs              []*Log
+	CallResults       []*CallResult `rlp:"tail"`
 }
 
 // storedReceiptRLP is the storage encoding of a receipt.
@@ -91,6 +96,7 @@ type storedReceiptRLP struct {
 	PostStateOrStatus []byte
 	CumulativeGasUsed uint64
 	Logs              []*LogForStorage
+	CallResults       []*CallResult `rlp:"tail"`
 }
 
 // v4StoredReceiptRLP is the storage encoding of a receipt used in database version 4.
@@ -130,7 +136,7 @@ func NewReceipt(root []byte, failed bool, cumulativeGasUsed uint64) *Receipt {
 // EncodeRLP implements rlp.Encoder, and flattens the consensus fields of a receipt
 // into an RLP stream. If no post state is present, byzantium fork is assumed.
 func (r *Receipt) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs})
+	return rlp.Encode(w, &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs, r.CallResults})
 }
 
 // DecodeRLP implements rlp.Decoder, and loads the consensus fields of a receipt
@@ -143,7 +149,7 @@ func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 	if err := r.setStatus(dec.PostStateOrStatus); err != nil {
 		return err
 	}
-	r.CumulativeGasUsed, r.Bloom, r.Logs = dec.CumulativeGasUsed, dec.Bloom, dec.Logs
+	r.CumulativeGasUsed, r.Bloom, r.Logs, r.CallResults = dec.CumulativeGasUsed, dec.Bloom, dec.Logs, dec.CallResults
 	return nil
 }
 
@@ -180,6 +186,7 @@ func (r *Receipt) Size() common.StorageSize {
 	for _, log := range r.Logs {
 		size += common.StorageSize(len(log.Topics)*common.HashLength + len(log.Data))
 	}
+	size += common.StorageSize(len(r.CallResults)) * common.StorageSize(unsafe.Sizeof(CallResult{}))
 	return size
 }
 
@@ -194,6 +201,7 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 		PostStateOrStatus: (*Receipt)(r).statusEncoding(),
 		CumulativeGasUsed: r.CumulativeGasUsed,
 		Logs:              make([]*LogForStorage, len(r.Logs)),
+		CallResults:       r.CallResults,
 	}
 	for i, log := range r.Logs {
 		enc.Logs[i] = (*LogForStorage)(log)
@@ -234,6 +242,7 @@ func decodeStoredReceiptRLP(r *ReceiptForStorage, blob []byte) error {
 	for i, log := range stored.Logs {
 		r.Logs[i] = (*Log)(log)
 	}
+	r.CallResults = stored.CallResults
 	r.Bloom = CreateBloom(Receipts{(*Receipt)(r)})
 
 	return nil
@@ -281,3 +281,48 @@ func clearComputedFieldsOnLog(t *testing.T, log *Log) {
 	log.TxIndex = math.MaxUint32
 	log.Index = math.MaxUint32
 }
+
+func TestReceiptCallResultsEncoding(t *testing.T) {
+	receipt := &Receipt{
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs:              []*Log{},
+	}
+	// Receipts without call results keep their encoding
+	legacy, err := rlp.EncodeToBytes([]interface{}{receipt.statusEncoding(), receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs})
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(receipt)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if !bytes.Equal(enc, legacy) {
+		t.Fatalf("encoding mismatch: have %x, want %x", enc, legacy)
+	}
+	receipt.CallResults = []*CallResult{
+		{Status: ReceiptStatusSuccessful, GasUsed: 100},
+		{Status: ReceiptStatusFailed, GasUsed: 200},
+	}
+	// Call results are part of both the consensus and the storage encodings
+	if enc, err = rlp.EncodeToBytes(receipt); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	dec := new(Receipt)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if !reflect.DeepEqual(dec.CallResults, receipt.CallResults) {
+		t.Fatalf("call results mismatch: have %v, want %v", dec.CallResults, receipt.CallResults)
+	}
+	if enc, err = rlp.EncodeToBytes((*ReceiptForStorage)(receipt)); err != nil {
+		t.Fatalf("storage encode error: %v", err)
+	}
+	stored := new(ReceiptForStorage)
+	if err := rlp.DecodeBytes(enc, stored); err != nil {
+		t.Fatalf("storage decode error: %v", err)
+	}
+	if !reflect.DeepEqual(stored.CallResults, receipt.CallResults) {
+		t.Fatalf("stored call results mismatch: have %v, want %v", stored.CallResults, receipt.CallResults)
+	}
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// scheduledTxdata is the payload of ScheduledTxType transactions. A scheduled
+// transaction can only be included in the blocks of its window, from ValidAfter up
+// to but excluding ValidBefore. A zero ValidBefore leaves the window open ended.
+// If it names a provider, the provider pays the gas and must co-sign the
+// transaction including the sender signature, like for sponsored transactions.
+type scheduledTxdata struct {
+	ChainID      *big.Int
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     uint64
+	Recipient    *common.Address `rlp:"nil"` // nil means contract creation
+	Amount       *big.Int
+	Payload      []byte
+	ValidAfter   uint64
+	ValidBefore  uint64
+	Provider     *common.Address `rlp:"nil"`
+
+	// Sender signature values
+	V, R, S *big.Int
+
+	// Provider signature values
+	PV, PR, PS *big.Int
+}
+
+// NewScheduledTransaction creates a ScheduledTxType transaction which can only be
+// included in blocks from validAfter up to but excluding validBefore, zero meaning
+// no expiry. A non-nil provider pays the gas once it co-signed the sender-signed
+// transaction.
+func NewScheduledTransaction(chainID *big.Int, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, validAfter, validBefore uint64, provider *common.Address) *Transaction {
+	tx := newTransaction(nonce, to, amount, gasLimit, gasPrice, data)
+	tx.data.Type = ScheduledTxType
+	tx.data.ChainID = new(big.Int)
+	if chainID != nil {
+		tx.data.ChainID.Set(chainID)
+	}
+	tx.data.ValidAfter, tx.data.ValidBefore = validAfter, validBefore
+	if provider != nil {
+		cpy := *provider
+		tx.data.Provider = &cpy
+	}
+	tx.data.PV, tx.data.PR, tx.data.PS = new(big.Int), new(big.Int), new(big.Int)
+	return tx
+}
+
+// ValidAfter returns the first block number a scheduled transaction can be
+// included in, zero for other transactions.
+func (tx *Transaction) ValidAfter() uint64 { return tx.data.ValidAfter }
+
+// ValidBefore returns the block number a scheduled transaction expires at, zero for
+// other transactions and scheduled ones without expiry.
+func (tx *Transaction) ValidBefore() uint64 { return tx.data.ValidBefore }
+
+// IsScheduled returns true if the transaction is only valid within a window of blocks
+func (tx *Transaction) IsScheduled() bool {
+	return tx.data.Type == ScheduledTxType
+}
+
+// NotYetValid returns true if the transaction cannot be included in the block with
+// the given number yet, but can be in a later one.
+func (tx *Transaction) NotYetValid(number uint64) bool {
+	return number < tx.data.ValidAfter
+}
+
+// Expired returns true if the transaction cannot be included in the block with the
+// given number nor in any later one.
+func (tx *Transaction) Expired(number uint64) bool {
+	return tx.data.ValidBefore != 0 && number >= tx.data.ValidBefore
+}
@@ -17,10 +17,12 @@
 package types
 
 import (
+	"bytes"
 	"container/heap"
 	"errors"
 	"io"
 	"math/big"
+	"sort"
 	"sync/atomic"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
@@ -32,7 +34,19 @@ import (
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
 
 var (
-	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
+	ErrInvalidSig         = errors.New("invalid transaction v, r, s values")
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	ErrEmptyTypedTx       = errors.New("empty typed transaction bytes")
+)
+
+// Transaction types. Legacy transactions are encoded as a plain RLP list, typed
+// transactions as an envelope of the type byte followed by the RLP encoded payload.
+const (
+	LegacyTxType    = 0x00
+	SponsoredTxType = 0x01
+	BatchTxType     = 0x02
+	ScheduledTxType = 0x03
+	ContractTxType  = 0x04
 )
 
 // CreateAccountOption contain extra parameter for Account creation
@@ -44,9 +58,10 @@ type CreateAccountOption struct {
 type Transaction struct {
 	data txdata
 	// caches
-	hash atomic.Value
-	size atomic.Value
-	from atomic.Value
+	hash     atomic.Value
+	size     atomic.Value
+	from     atomic.Value
+	provider atomic.Value
 }
 
 type txdataNormal struct {
@@ -106,10 +121,48 @@ type txdata struct {
 	PR *big.Int `json:"pr"       rlp:"nil"`
 	PS *big.Int `json:"ps"       rlp:"nil"`
 
+	// Envelope type and chain id of typed transactions, neither is part of the
+	// legacy encoding.
+	Type    uint8    `json:"type,omitempty"    rlp:"-"`
+	ChainID *big.Int `json:"chainId,omitempty" rlp:"-"`
+
+	// Calls of batch transactions, which are sent to BatchCallAddress and
+	// transfer the total value of their calls.
+	Calls []BatchCall `json:"calls,omitempty" rlp:"-"`
+
+	// Block window of scheduled transactions.
+	ValidAfter  uint64 `json:"validAfterBlock,omitempty"  rlp:"-"`
+	ValidBefore uint64 `json:"validBeforeBlock,omitempty" rlp:"-"`
+
+	// Contract account sending the transaction and the data authorizing it, for
+	// transactions validated by their sender contract instead of a signature.
+	Sender *common.Address `json:"sender,omitempty" rlp:"-"`
+	Auth   []byte          `json:"auth,omitempty"   rlp:"-"`
+
 	// This is only used when marshaling to JSON.
 	Hash *common.Hash `json:"hash" rlp:"-"`
 }
 
+// sponsoredTxdata is the payload of SponsoredTxType transactions. The gas is
+// paid by the named provider, who co-signs the transaction including the sender
+// signature. V and PV are the y parities of the signatures.
+type sponsoredTxdata struct {
+	ChainID      *big.Int
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     uint64
+	Recipient    common.Address // sponsored transactions can not create contracts
+	Amount       *big.Int
+	Payload      []byte
+	Provider     common.Address
+
+	// Sender signature values
+	V, R, S *big.Int
+
+	// Provider signature values
+	PV, PR, PS *big.Int
+}
+
 type txdataMarshaling struct {
 	AccountNonce hexutil.Uint64
 	Price        *hexutil.Big
@@ -119,6 +172,8 @@ type txdataMarshaling struct {
 	V            *hexutil.Big
 	R            *hexutil.Big
 	S            *hexutil.Big
+	Type         hexutil.Uint64
+	ChainID      *hexutil.Big
 }
 
 func NewTransaction(nonce uint64, to common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte) *Transaction {
@@ -135,6 +190,20 @@ func NewContractCreation(nonce uint64, amount *big.Int, gasLimit uint64, gasPric
 	return tx
 }
 
+// NewSponsoredTransaction creates a SponsoredTxType transaction, whose gas is paid
+// by the given provider once it co-signed the sender-signed transaction.
+func NewSponsoredTransaction(chainID *big.Int, nonce uint64, to common.Address, provider common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte) *Transaction {
+	tx := newTransaction(nonce, &to, amount, gasLimit, gasPrice, data)
+	tx.data.Type = SponsoredTxType
+	tx.data.ChainID = new(big.Int)
+	if chainID != nil {
+		tx.data.ChainID.Set(chainID)
+	}
+	tx.data.Provider = &provider
+	tx.data.PV, tx.data.PR, tx.data.PS = new(big.Int), new(big.Int), new(big.Int)
+	return tx
+}
+
 func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte) *Transaction {
 	if len(data) > 0 {
 		data = common.CopyBytes(data)
@@ -160,19 +229,28 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 	return &Transaction{data: d}
 }
 
+// Type returns the envelope type of the transaction, LegacyTxType for legacy ones.
+func (tx *Transaction) Type() uint8 {
+	return tx.data.Type
+}
+
 // ChainId returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.data.Type != LegacyTxType {
+		return new(big.Int).Set(tx.data.ChainID)
+	}
 	return deriveChainId(tx.data.V)
 }
 
 // Protected returns whether the transaction is protected from replay protection.
+// Typed transactions always carry their chain id.
 func (tx *Transaction) Protected() bool {
-	return isProtectedV(tx.data.V)
+	return tx.data.Type != LegacyTxType || isProtectedV(tx.data.V)
 }
 
 // ProviderProtected returns whether the transaction is protected from replay protection.
 func (tx *Transaction) ProviderProtected() bool {
-	return isProtectedV(tx.data.PV)
+	return tx.data.Type != LegacyTxType || isProtectedV(tx.data.PV)
 }
 
 func isProtectedV(V *big.Int) bool {
@@ -184,16 +262,32 @@ func isProtectedV(V *big.Int) bool {
 	return true
 }
 
-// EncodeRLP implements rlp.Encoder
+// EncodeRLP implements rlp.Encoder. Typed transactions are encoded as an RLP
+// string holding their envelope.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.data.Type == LegacyTxType {
+		return rlp.Encode(w, &tx.data)
+	}
+	enc, err := tx.encodeTyped()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
 }
 
 // DecodeRLP implements rlp.Decoder
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	var (
-		err error
-	)
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.String {
+		enc, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		return tx.decodeTyped(enc)
+	}
 	raw, err := s.Raw()
 	lenStream := uint64(len(raw))
 
@@ -222,6 +316,229 @@ func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 	return err
 }
 
+// MarshalBinary returns the canonical encoding of the transaction, which is the
+// RLP list of legacy transactions and the envelope of typed ones.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.data.Type == LegacyTxType {
+		return rlp.EncodeToBytes(&tx.data)
+	}
+	return tx.encodeTyped()
+}
+
+// UnmarshalBinary decodes the canonical encoding of a transaction. Typed envelopes
+// wrapped into an RLP string, as found in blocks, are accepted as well.
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] < 0x80 {
+		return tx.decodeTyped(b)
+	}
+	return rlp.DecodeBytes(b, tx)
+}
+
+// encodeTyped returns the envelope of a typed transaction: the type byte followed
+// by the RLP encoding of the payload.
+func (tx *Transaction) encodeTyped() ([]byte, error) {
+	switch tx.data.Type {
+	case SponsoredTxType:
+		payload := sponsoredTxdata{
+			ChainID:      tx.data.ChainID,
+			AccountNonce: tx.data.AccountNonce,
+			Price:        tx.data.Price,
+			GasLimit:     tx.data.GasLimit,
+			Amount:       tx.data.Amount,
+			Payload:      tx.data.Payload,
+			V:            tx.data.V,
+			R:            tx.data.R,
+			S:            tx.data.S,
+			PV:           tx.data.PV,
+			PR:           tx.data.PR,
+			PS:           tx.data.PS,
+		}
+		if tx.data.Recipient != nil {
+			payload.Recipient = *tx.data.Recipient
+		}
+		if tx.data.Provider != nil {
+			payload.Provider = *tx.data.Provider
+		}
+		enc, err := rlp.EncodeToBytes(&payload)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{SponsoredTxType}, enc...), nil
+	case BatchTxType:
+		payload := batchTxdata{
+			ChainID:      tx.data.ChainID,
+			AccountNonce: tx.data.AccountNonce,
+			Price:        tx.data.Price,
+			GasLimit:     tx.data.GasLimit,
+			Calls:        tx.data.Calls,
+			Provider:     tx.data.Provider,
+			V:            tx.data.V,
+			R:            tx.data.R,
+			S:            tx.data.S,
+			PV:           tx.data.PV,
+			PR:           tx.data.PR,
+			PS:           tx.data.PS,
+		}
+		enc, err := rlp.EncodeToBytes(&payload)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{BatchTxType}, enc...), nil
+	case ScheduledTxType:
+		payload := scheduledTxdata{
+			ChainID:      tx.data.ChainID,
+			AccountNonce: tx.data.AccountNonce,
+			Price:        tx.data.Price,
+			GasLimit:     tx.data.GasLimit,
+			Recipient:    tx.data.Recipient,
+			Amount:       tx.data.Amount,
+			Payload:      tx.data.Payload,
+			ValidAfter:   tx.data.ValidAfter,
+			ValidBefore:  tx.data.ValidBefore,
+			Provider:     tx.data.Provider,
+			V:            tx.data.V,
+			R:            tx.data.R,
+			S:            tx.data.S,
+			PV:           tx.data.PV,
+			PR:           tx.data.PR,
+			PS:           tx.data.PS,
+		}
+		enc, err := rlp.EncodeToBytes(&payload)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{ScheduledTxType}, enc...), nil
+	case ContractTxType:
+		payload := contractTxdata{
+			ChainID:      tx.data.ChainID,
+			AccountNonce: tx.data.AccountNonce,
+			Price:        tx.data.Price,
+			GasLimit:     tx.data.GasLimit,
+			Sender:       *tx.data.Sender,
+			Recipient:    tx.data.Recipient,
+			Amount:       tx.data.Amount,
+			Payload:      tx.data.Payload,
+			Auth:         tx.data.Auth,
+		}
+		enc, err := rlp.EncodeToBytes(&payload)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{ContractTxType}, enc...), nil
+	}
+	return nil, ErrTxTypeNotSupported
+}
+
+// decodeTyped decodes the envelope of a typed transaction.
+func (tx *Transaction) decodeTyped(b []byte) error {
+	if len(b) == 0 {
+		return ErrEmptyTypedTx
+	}
+	switch b[0] {
+	case SponsoredTxType:
+		var payload sponsoredTxdata
+		if err := rlp.DecodeBytes(b[1:], &payload); err != nil {
+			return err
+		}
+		tx.data = txdata{
+			Type:         SponsoredTxType,
+			ChainID:      payload.ChainID,
+			AccountNonce: payload.AccountNonce,
+			Price:        payload.Price,
+			GasLimit:     payload.GasLimit,
+			Recipient:    &payload.Recipient,
+			Amount:       payload.Amount,
+			Payload:      payload.Payload,
+			Provider:     &payload.Provider,
+			V:            payload.V,
+			R:            payload.R,
+			S:            payload.S,
+			PV:           payload.PV,
+			PR:           payload.PR,
+			PS:           payload.PS,
+		}
+		tx.size.Store(common.StorageSize(rlp.ListSize(uint64(len(b)))))
+		return nil
+	case BatchTxType:
+		var payload batchTxdata
+		if err := rlp.DecodeBytes(b[1:], &payload); err != nil {
+			return err
+		}
+		to := BatchCallAddress
+		tx.data = txdata{
+			Type:         BatchTxType,
+			ChainID:      payload.ChainID,
+			AccountNonce: payload.AccountNonce,
+			Price:        payload.Price,
+			GasLimit:     payload.GasLimit,
+			Recipient:    &to,
+			Amount:       batchValue(payload.Calls),
+			Calls:        payload.Calls,
+			Provider:     payload.Provider,
+			V:            payload.V,
+			R:            payload.R,
+			S:            payload.S,
+			PV:           payload.PV,
+			PR:           payload.PR,
+			PS:           payload.PS,
+		}
+		tx.size.Store(common.StorageSize(rlp.ListSize(uint64(len(b)))))
+		return nil
+	case ScheduledTxType:
+		var payload scheduledTxdata
+		if err := rlp.DecodeBytes(b[1:], &payload); err != nil {
+			return err
+		}
+		tx.data = txdata{
+			Type:         ScheduledTxType,
+			ChainID:      payload.ChainID,
+			AccountNonce: payload.AccountNonce,
+			Price:        payload.Price,
+			GasLimit:     payload.GasLimit,
+			Recipient:    payload.Recipient,
+			Amount:       payload.Amount,
+			Payload:      payload.Payload,
+			ValidAfter:   payload.ValidAfter,
+			ValidBefore:  payload.ValidBefore,
+			Provider:     payload.Provider,
+			V:            payload.V,
+			R:            payload.R,
+			S:            payload.S,
+			PV:           payload.PV,
+			PR:           payload.PR,
+			PS:           payload.PS,
+		}
+		tx.size.Store(common.StorageSize(rlp.ListSize(uint64(len(b)))))
+		return nil
+	case ContractTxType:
+		var payload contractTxdata
+		if err := rlp.DecodeBytes(b[1:], &payload); err != nil {
+			return err
+		}
+		tx.data = txdata{
+			Type:         ContractTxType,
+			ChainID:      payload.ChainID,
+			AccountNonce: payload.AccountNonce,
+			Price:        payload.Price,
+			GasLimit:     payload.GasLimit,
+			Sender:       &payload.Sender,
+			Recipient:    payload.Recipient,
+			Amount:       payload.Amount,
+			Payload:      payload.Payload,
+			Auth:         payload.Auth,
+			V:            new(big.Int),
+			R:            new(big.Int),
+			S:            new(big.Int),
+			PV:           new(big.Int),
+			PR:           new(big.Int),
+			PS:           new(big.Int),
+		}
+		tx.size.Store(common.StorageSize(rlp.ListSize(uint64(len(b)))))
+		return nil
+	}
+	return ErrTxTypeNotSupported
+}
+
 // MarshalJSON encodes the web3 RPC transaction format.
 func (tx *Transaction) MarshalJSON() ([]byte, error) {
 	hash := tx.Hash()
@@ -236,11 +553,61 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 	if err := dec.UnmarshalJSON(input); err != nil {
 		return err
 	}
-
+	// Only contract transactions name their sender, all others are signed by it
+	if dec.Type != ContractTxType {
+		dec.Sender, dec.Auth = nil, nil
+	}
+	switch dec.Type {
+	case LegacyTxType:
+	case SponsoredTxType:
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' for typed transaction")
+		}
+		if dec.Recipient == nil || dec.Provider == nil {
+			return errors.New("missing required field 'to' or 'provider' for sponsored transaction")
+		}
+		if dec.PV == nil || dec.PR == nil || dec.PS == nil {
+			dec.PV, dec.PR, dec.PS = new(big.Int), new(big.Int), new(big.Int)
+		}
+	case BatchTxType:
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' for typed transaction")
+		}
+		// The recipient and value of batches are derived from their calls
+		to := BatchCallAddress
+		dec.Recipient, dec.Amount, dec.Payload = &to, batchValue(dec.Calls), nil
+		if dec.PV == nil || dec.PR == nil || dec.PS == nil {
+			dec.PV, dec.PR, dec.PS = new(big.Int), new(big.Int), new(big.Int)
+		}
+	case ScheduledTxType:
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' for typed transaction")
+		}
+		if dec.PV == nil || dec.PR == nil || dec.PS == nil {
+			dec.PV, dec.PR, dec.PS = new(big.Int), new(big.Int), new(big.Int)
+		}
+	case ContractTxType:
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' for typed transaction")
+		}
+		if dec.Sender == nil {
+			return errors.New("missing required field 'sender' for contract transaction")
+		}
+		// Contract transactions are authorized by their sender, not signed
+		dec.V, dec.R, dec.S = new(big.Int), new(big.Int), new(big.Int)
+		dec.PV, dec.PR, dec.PS = new(big.Int), new(big.Int), new(big.Int)
+	default:
+		return ErrTxTypeNotSupported
+	}
 	withSignature := dec.V.Sign() != 0 || dec.R.Sign() != 0 || dec.S.Sign() != 0
 	if withSignature {
 		var V byte
-		if isProtectedV(dec.V) {
+		if dec.Type != LegacyTxType {
+			if dec.V.BitLen() > 1 {
+				return ErrInvalidSig
+			}
+			V = byte(dec.V.Uint64())
+		} else if isProtectedV(dec.V) {
 			chainID := deriveChainId(dec.V).Uint64()
 			V = byte(dec.V.Uint64() - 35 - 2*chainID)
 		} else {
@@ -272,13 +639,19 @@ func (tx *Transaction) To() *common.Address {
 	return &to
 }
 
-// Hash hashes the RLP encoding of tx.
-// It uniquely identifies the transaction.
+// Hash hashes the RLP encoding of legacy transactions and the envelope of typed
+// ones. It uniquely identifies the transaction.
 func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.data.Type == LegacyTxType {
+		v = rlpHash(tx)
+	} else {
+		enc, _ := tx.encodeTyped()
+		v = crypto.Keccak256Hash(enc)
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -290,7 +663,7 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	rlp.Encode(&c, tx)
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
@@ -308,10 +681,14 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 		to:         tx.data.Recipient,
 		amount:     tx.data.Amount,
 		data:       tx.data.Payload,
-		owner:      tx.data.Owner,
-		provider:   tx.data.Provider,
+		calls:      tx.data.Calls,
+		senderAuth: tx.SenderAuth(),
 		checkNonce: true,
 	}
+	// Owner and provider of legacy transactions are the options of contract creations
+	if tx.data.Type == LegacyTxType {
+		msg.owner, msg.provider = tx.data.Owner, tx.data.Provider
+	}
 
 	var err error
 	msg.from, err = Sender(s, tx)
@@ -424,9 +801,10 @@ func (s Transactions) Len() int { return len(s) }
 // Swap swaps the i'th and the j'th element in s.
 func (s Transactions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-// GetRlp implements Rlpable and returns the i'th element of s in rlp.
+// GetRlp implements Rlpable and returns the canonical encoding of the i'th
+// element of s, which is the envelope of typed transactions.
 func (s Transactions) GetRlp(i int) []byte {
-	enc, _ := rlp.EncodeToBytes(s[i])
+	enc, _ := s[i].MarshalBinary()
 	return enc
 }
 
@@ -539,6 +917,121 @@ func (t *TransactionsByPriceAndNonce) Pop() {
 	heap.Pop(&t.heads)
 }
 
+// fairLane holds the senders whose transactions are paid by the same account.
+type fairLane struct {
+	payer   common.Address
+	senders []common.Address
+	next    int // index of the sender to serve next
+}
+
+// TransactionsByFairShare represents a set of transactions that can return
+// transactions in a round-robin order across the accounts paying for them, then
+// across the senders sharing a payer, while honouring the nonce order of every
+// sender. As every transaction pays the same gas price, this keeps a single
+// account or provider from filling a block.
+type TransactionsByFairShare struct {
+	txs    map[common.Address]Transactions // Per account nonce-sorted list of transactions
+	counts map[common.Address]int          // Number of transactions returned per account
+	lanes  []*fairLane                     // Senders grouped by payer, sorted by payer address
+	next   int                             // index of the lane to serve next
+	limit  int                             // Maximum number of transactions per account, 0 for no limit
+	signer Signer                          // Signer for the set of transactions
+}
+
+// NewTransactionsByFairShare creates a transaction set that retrieves transactions
+// in a fair, nonce-honouring way. Senders are grouped by the payer of their first
+// transaction, which is the provider for sponsored transactions and the sender
+// otherwise. At most limit transactions are returned per sender, 0 means no limit.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// it after providing it to the constructor.
+func NewTransactionsByFairShare(signer Signer, txs map[common.Address]Transactions, limit int) *TransactionsByFairShare {
+	set := &TransactionsByFairShare{
+		txs:    make(map[common.Address]Transactions, len(txs)),
+		counts: make(map[common.Address]int),
+		limit:  limit,
+		signer: signer,
+	}
+	lanes := make(map[common.Address]*fairLane)
+	for _, accTxs := range txs {
+		if len(accTxs) == 0 {
+			continue
+		}
+		// Ensure the sender address is from the signer
+		acc, _ := Sender(signer, accTxs[0])
+		set.txs[acc] = accTxs
+
+		payer := acc
+		if provider, err := Provider(signer, accTxs[0]); err == nil && provider != nil {
+			payer = *provider
+		}
+		lane, ok := lanes[payer]
+		if !ok {
+			lane = &fairLane{payer: payer}
+			lanes[payer] = lane
+			set.lanes = append(set.lanes, lane)
+		}
+		lane.senders = append(lane.senders, acc)
+	}
+	sort.Slice(set.lanes, func(i, j int) bool {
+		return bytes.Compare(set.lanes[i].payer[:], set.lanes[j].payer[:]) < 0
+	})
+	for _, lane := range set.lanes {
+		senders := lane.senders
+		sort.Slice(senders, func(i, j int) bool {
+			return bytes.Compare(senders[i][:], senders[j][:]) < 0
+		})
+	}
+	return set
+}
+
+// Peek returns the next transaction in the round-robin order.
+func (t *TransactionsByFairShare) Peek() *Transaction {
+	if len(t.lanes) == 0 {
+		return nil
+	}
+	lane := t.lanes[t.next]
+	return t.txs[lane.senders[lane.next]][0]
+}
+
+// Shift consumes the current transaction and moves on to the next payer, the
+// sender of the transaction is dropped once it has no more transactions or has
+// reached the limit.
+func (t *TransactionsByFairShare) Shift() {
+	lane := t.lanes[t.next]
+	acc := lane.senders[lane.next]
+	t.txs[acc] = t.txs[acc][1:]
+	t.counts[acc]++
+	if len(t.txs[acc]) == 0 || (t.limit > 0 && t.counts[acc] >= t.limit) {
+		t.Pop()
+		return
+	}
+	lane.next = (lane.next + 1) % len(lane.senders)
+	t.next = (t.next + 1) % len(t.lanes)
+}
+
+// Pop removes the current transaction along with all the next ones from the same
+// account. This should be used when a transaction cannot be executed and hence
+// all subsequent ones should be discarded from the same account.
+func (t *TransactionsByFairShare) Pop() {
+	lane := t.lanes[t.next]
+	delete(t.txs, lane.senders[lane.next])
+	lane.senders = append(lane.senders[:lane.next], lane.senders[lane.next+1:]...)
+	if len(lane.senders) == 0 {
+		// the following lane takes the place of the removed one
+		t.lanes = append(t.lanes[:t.next], t.lanes[t.next+1:]...)
+	} else {
+		// the following sender takes the place of the removed one
+		lane.next %= len(lane.senders)
+		t.next++
+	}
+	if len(t.lanes) == 0 {
+		t.next = 0
+		return
+	}
+	t.next %= len(t.lanes)
+}
+
 // Message is a fully derived transaction and implements core.Message
 //
 // NOTE: In a future PR this will be removed.
@@ -553,6 +1046,8 @@ type Message struct {
 	gasLimit   uint64
 	gasPrice   *big.Int
 	data       []byte
+	calls      []BatchCall
+	senderAuth *SenderAuth
 	checkNonce bool
 	gasPayer   common.Address
 }
@@ -581,4 +1076,6 @@ func (m Message) Value() *big.Int           { return m.amount }
 func (m Message) Gas() uint64               { return m.gasLimit }
 func (m Message) Nonce() uint64             { return m.nonce }
 func (m Message) Data() []byte              { return m.data }
+func (m Message) Calls() []BatchCall        { return m.calls }
+func (m Message) SenderAuth() *SenderAuth   { return m.senderAuth }
 func (m Message) CheckNonce() bool          { return m.checkNonce }
@@ -28,7 +28,9 @@ import (
 )
 
 var (
-	ErrInvalidChainId = errors.New("invalid chain id for signer")
+	ErrInvalidChainId     = errors.New("invalid chain id for signer")
+	ErrMissingProviderSig = errors.New("sponsored transaction is not signed by its provider")
+	ErrProviderMismatch   = errors.New("provider signature does not match the named provider")
 )
 
 // sigCache is used to cache the derived sender and contains
@@ -54,7 +56,7 @@ func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 
 // ProviderSignTx signs the transaction using the given signer and private key
 func ProviderSignTx(tx *Transaction, s Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
-	h := s.Hash(tx)
+	h := s.ProviderHash(tx)
 	sig, err := crypto.Sign(h[:], prv)
 	if err != nil {
 		return nil, err
@@ -99,15 +101,26 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 }
 
 // Provider returns the address derived from the signature (V, R, S) using secp256k1
-// If there is no provider signature, it will return nil address pointer and nill error.
+// If there is no provider signature, it will return nil address pointer and nill error,
+// unless the transaction is a sponsored one, which must be signed by its provider.
 func Provider(signer Signer, tx *Transaction) (*common.Address, error) {
-	// Not caching provider for now
 	// Short circuit
 	if (tx.data.PV == nil || tx.data.PV.Cmp(big.NewInt(0)) == 0) &&
 		(tx.data.PR == nil || tx.data.PR.Cmp(big.NewInt(0)) == 0) &&
 		(tx.data.PS == nil || tx.data.PS.Cmp(big.NewInt(0)) == 0) {
+		if tx.data.Type != LegacyTxType && tx.data.Provider != nil {
+			return nil, ErrMissingProviderSig
+		}
 		return nil, nil
 	}
+	if sc := tx.provider.Load(); sc != nil {
+		sigCache := sc.(sigCache)
+		// the cache is only valid for the signer used to derive the provider
+		if sigCache.signer.Equal(signer) {
+			provider := sigCache.from
+			return &provider, nil
+		}
+	}
 	provider, err := signer.Provider(tx)
 	if err != nil {
 		return nil, err
@@ -116,6 +129,7 @@ func Provider(signer Signer, tx *Transaction) (*common.Address, error) {
 	if provider == (common.Address{}) {
 		return nil, nil
 	}
+	tx.provider.Store(sigCache{signer: signer, from: provider})
 	return &provider, nil
 }
 
@@ -131,10 +145,11 @@ type Signer interface {
 	SignatureValues(tx *Transaction, sig []byte) (r, s, v *big.Int, err error)
 	// Hash returns the hash to be signed.
 	Hash(tx *Transaction) common.Hash
+	// ProviderHash returns the hash to be signed by the provider. It covers
+	// the sender signature of sponsored transactions.
+	ProviderHash(tx *Transaction) common.Hash
 	// Equal returns true if the given signer is the same as the receiver.
 	Equal(Signer) bool
-
-	//TODO: Implement HashWithSenderSignature
 }
 
 // EIP155Transaction implements Signer using the EIP155 rules.
@@ -157,9 +172,25 @@ func (s EIP155Signer) Equal(s2 Signer) bool {
 	return ok && eip155.chainId.Cmp(s.chainId) == 0
 }
 
-var big8 = big.NewInt(8)
+var (
+	big8  = big.NewInt(8)
+	big27 = big.NewInt(27)
+)
 
 func (s EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		if tx.ChainId().Cmp(s.chainId) != 0 {
+			return common.Address{}, ErrInvalidChainId
+		}
+		// Contract senders authorize their transactions when they are executed
+		if tx.Type() == ContractTxType {
+			if tx.data.Sender == nil {
+				return common.Address{}, ErrInvalidSig
+			}
+			return *tx.data.Sender, nil
+		}
+		return recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, new(big.Int).Add(tx.data.V, big27), true)
+	}
 	if !tx.Protected() {
 		return HomesteadSigner{}.Sender(tx)
 	}
@@ -172,7 +203,21 @@ func (s EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
 }
 
 //Provider return the Address of provider based on PV, PS, PR
+// Sponsored transactions must be signed by the provider they name.
 func (s EIP155Signer) Provider(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		if tx.ChainId().Cmp(s.chainId) != 0 {
+			return common.Address{}, ErrInvalidChainId
+		}
+		provider, err := recoverPlain(s.ProviderHash(tx), tx.data.PR, tx.data.PS, new(big.Int).Add(tx.data.PV, big27), true)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if tx.data.Provider == nil || provider != *tx.data.Provider {
+			return common.Address{}, ErrProviderMismatch
+		}
+		return provider, nil
+	}
 	if !tx.ProviderProtected() {
 		return HomesteadSigner{}.Provider(tx)
 	}
@@ -187,6 +232,13 @@ func (s EIP155Signer) Provider(tx *Transaction) (common.Address, error) {
 // SignatureValues returns signature values. This signature
 // needs to be in the [R || S || V] format where V is 0 or 1.
 func (s EIP155Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() != LegacyTxType {
+		if tx.ChainId().Cmp(s.chainId) != 0 {
+			return nil, nil, nil, ErrInvalidChainId
+		}
+		R, S, V = decodeSignature(sig)
+		return R, S, V, nil
+	}
 	R, S, V, err = HomesteadSigner{}.SignatureValues(tx, sig)
 	if err != nil {
 		return nil, nil, nil, err
@@ -201,6 +253,9 @@ func (s EIP155Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
+	if tx.Type() != LegacyTxType {
+		return typedSenderHash(tx)
+	}
 	if tx.data.Provider == nil {
 		return rlpHash([]interface{}{
 			tx.data.AccountNonce,
@@ -226,6 +281,15 @@ func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
 
 }
 
+// ProviderHash returns the hash to be signed by the provider. It is the sender
+// hash for legacy transactions.
+func (s EIP155Signer) ProviderHash(tx *Transaction) common.Hash {
+	if tx.Type() != LegacyTxType {
+		return typedProviderHash(tx)
+	}
+	return s.Hash(tx)
+}
+
 // HomesteadTransaction implements TransactionInterface using the
 // homestead rules.
 type HomesteadSigner struct{ FrontierSigner }
@@ -243,11 +307,17 @@ func (hs HomesteadSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v
 
 //Provider return the Address of provider based on PV, PS, PR
 func (hs HomesteadSigner) Provider(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	return recoverPlain(hs.Hash(tx), tx.data.PR, tx.data.PS, tx.data.PV, true)
 
 }
 
 func (hs HomesteadSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	return recoverPlain(hs.Hash(tx), tx.data.R, tx.data.S, tx.data.V, true)
 }
 
@@ -261,18 +331,20 @@ func (s FrontierSigner) Equal(s2 Signer) bool {
 // SignatureValues returns signature values. This signature
 // needs to be in the [R || S || V] format where V is 0 or 1.
 func (fs FrontierSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v *big.Int, err error) {
-	if len(sig) != 65 {
-		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	if tx.Type() != LegacyTxType {
+		return nil, nil, nil, ErrTxTypeNotSupported
 	}
-	r = new(big.Int).SetBytes(sig[:32])
-	s = new(big.Int).SetBytes(sig[32:64])
-	v = new(big.Int).SetBytes([]byte{sig[64] + 27})
+	r, s, v = decodeSignature(sig)
+	v.Add(v, big27)
 	return r, s, v, nil
 }
 
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
+	if tx.Type() != LegacyTxType {
+		return typedSenderHash(tx)
+	}
 	if tx.data.Provider == nil {
 		return rlpHash([]interface{}{
 			tx.data.AccountNonce,
@@ -296,14 +368,139 @@ func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
 	})
 }
 
+// ProviderHash returns the hash to be signed by the provider. It is the sender
+// hash for legacy transactions.
+func (fs FrontierSigner) ProviderHash(tx *Transaction) common.Hash {
+	if tx.Type() != LegacyTxType {
+		return typedProviderHash(tx)
+	}
+	return fs.Hash(tx)
+}
+
 func (fs FrontierSigner) Provider(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	return recoverPlain(fs.Hash(tx), tx.data.PR, tx.data.PS, tx.data.PV, false)
 }
 
 func (fs FrontierSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	return recoverPlain(fs.Hash(tx), tx.data.R, tx.data.S, tx.data.V, false)
 }
 
+// typedSenderHash returns the hash the sender of a typed transaction signs: the
+// type byte followed by the RLP encoding of every field but the signatures.
+func typedSenderHash(tx *Transaction) common.Hash {
+	switch tx.data.Type {
+	case BatchTxType:
+		return prefixedRlpHash(tx.data.Type, []interface{}{
+			tx.data.ChainID,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.Calls,
+			tx.data.Provider,
+		})
+	case ScheduledTxType:
+		return prefixedRlpHash(tx.data.Type, []interface{}{
+			tx.data.ChainID,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.Recipient,
+			tx.data.Amount,
+			tx.data.Payload,
+			tx.data.ValidAfter,
+			tx.data.ValidBefore,
+			tx.data.Provider,
+		})
+	case ContractTxType:
+		return prefixedRlpHash(tx.data.Type, []interface{}{
+			tx.data.ChainID,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.Sender,
+			tx.data.Recipient,
+			tx.data.Amount,
+			tx.data.Payload,
+		})
+	}
+	return prefixedRlpHash(tx.data.Type, []interface{}{
+		tx.data.ChainID,
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		tx.data.Provider,
+	})
+}
+
+// typedProviderHash returns the hash the provider of a typed transaction signs,
+// which extends the sender hash with the sender signature. The provider thus
+// commits to the exact transaction it pays for.
+func typedProviderHash(tx *Transaction) common.Hash {
+	switch tx.data.Type {
+	case BatchTxType:
+		return prefixedRlpHash(tx.data.Type, []interface{}{
+			tx.data.ChainID,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.Calls,
+			tx.data.Provider,
+			tx.data.V,
+			tx.data.R,
+			tx.data.S,
+		})
+	case ScheduledTxType:
+		return prefixedRlpHash(tx.data.Type, []interface{}{
+			tx.data.ChainID,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.Recipient,
+			tx.data.Amount,
+			tx.data.Payload,
+			tx.data.ValidAfter,
+			tx.data.ValidBefore,
+			tx.data.Provider,
+			tx.data.V,
+			tx.data.R,
+			tx.data.S,
+		})
+	}
+	return prefixedRlpHash(tx.data.Type, []interface{}{
+		tx.data.ChainID,
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		tx.data.Provider,
+		tx.data.V,
+		tx.data.R,
+		tx.data.S,
+	})
+}
+
+// decodeSignature splits a signature in the [R || S || V] format where V is 0 or 1.
+func decodeSignature(sig []byte) (r, s, v *big.Int) {
+	if len(sig) != 65 {
+		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	}
+	r = new(big.Int).SetBytes(sig[:32])
+	s = new(big.Int).SetBytes(sig[32:64])
+	v = new(big.Int).SetBytes([]byte{sig[64]})
+	return r, s, v
+}
+
 func recoverPlain(sighash common.Hash, R, S, Vb *big.Int, homestead bool) (common.Address, error) {
 	if Vb == nil || Vb.BitLen() > 8 {
 		return common.Address{}, ErrInvalidSig
@@ -136,3 +136,59 @@ func TestChainId(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+func TestSponsoredTransactionSigning(t *testing.T) {
+	senderKey, _ := crypto.GenerateKey()
+	providerKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(senderKey.PublicKey)
+	provider := crypto.PubkeyToAddress(providerKey.PublicKey)
+
+	signer := NewEIP155Signer(big.NewInt(18))
+	tx, err := SignTx(NewSponsoredTransaction(big.NewInt(18), 0, common.Address{1}, provider, new(big.Int), 50000, big.NewInt(1), nil), signer, senderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := Sender(signer, tx); err != nil || from != sender {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", from, err, sender)
+	}
+	// Sponsored transactions are only valid once co-signed by the named provider
+	if _, err := Provider(signer, tx); err != ErrMissingProviderSig {
+		t.Fatalf("missing provider signature error mismatch: have %v, want %v", err, ErrMissingProviderSig)
+	}
+	forged, err := ProviderSignTx(tx, signer, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Provider(signer, forged); err != ErrProviderMismatch {
+		t.Fatalf("forged provider error mismatch: have %v, want %v", err, ErrProviderMismatch)
+	}
+	signed, err := ProviderSignTx(tx, signer, providerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, err := Provider(signer, signed); err != nil || *have != provider {
+		t.Fatalf("provider mismatch: have %v (%v), want %x", have, err, provider)
+	}
+	// The provider signs over the sender signature, re-signing invalidates it
+	resigned, err := SignTx(signed, signer, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Provider(signer, resigned); err == nil {
+		t.Fatal("provider signature valid for another sender signature")
+	}
+	if signer.ProviderHash(tx) == signer.Hash(tx) {
+		t.Fatal("provider and sender hashes of sponsored transactions must differ")
+	}
+	// Typed transactions are bound to their chain and to EIP155 signers
+	if _, err := Sender(NewEIP155Signer(big.NewInt(19)), signed); err != ErrInvalidChainId {
+		t.Fatalf("chain id error mismatch: have %v, want %v", err, ErrInvalidChainId)
+	}
+	if _, err := Sender(HomesteadSigner{}, signed); err != ErrTxTypeNotSupported {
+		t.Fatalf("homestead error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	if _, err := SignTx(tx, FrontierSigner{}, senderKey); err != ErrTxTypeNotSupported {
+		t.Fatalf("frontier error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
@@ -177,6 +177,70 @@ func TestTransactionPriceNonceSort(t *testing.T) {
 	}
 }
 
+// Tests that the fair share set serves payers and their senders in turn, honouring
+// nonces and the per account limit.
+func TestTransactionFairShareSort(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	providerKey, _ := crypto.GenerateKey()
+	provider := crypto.PubkeyToAddress(providerKey.PublicKey)
+
+	signer := HomesteadSigner{}
+	// The first two accounts send their own transactions, the last two are sponsored by the same provider
+	groups := map[common.Address]Transactions{}
+	for i, key := range keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		for nonce := uint64(0); nonce < 5; nonce++ {
+			tx, _ := SignTx(NewTransaction(nonce, common.Address{}, big.NewInt(100), 100, big.NewInt(1), nil), signer, key)
+			if i >= 2 {
+				tx, _ = ProviderSignTx(tx, signer, providerKey)
+			}
+			groups[addr] = append(groups[addr], tx)
+		}
+	}
+	txset := NewTransactionsByFairShare(signer, groups, 3)
+
+	var (
+		txs    = Transactions{}
+		counts = make(map[common.Address]int)
+		nonces = make(map[common.Address]uint64)
+	)
+	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
+		from, _ := Sender(signer, tx)
+		if tx.Nonce() != nonces[from] {
+			t.Errorf("invalid nonce ordering: have %d, want %d", tx.Nonce(), nonces[from])
+		}
+		nonces[from]++
+		counts[from]++
+		txs = append(txs, tx)
+		txset.Shift()
+	}
+	if len(txs) != 4*3 {
+		t.Errorf("expected %d transactions, found %d", 4*3, len(txs))
+	}
+	for addr, count := range counts {
+		if count != 3 {
+			t.Errorf("account %x: expected %d transactions, found %d", addr[:4], 3, count)
+		}
+	}
+	// Every round serves each of the three payers once
+	for round := 0; round < 3; round++ {
+		payers := make(map[common.Address]bool)
+		for _, tx := range txs[round*3 : round*3+3] {
+			payer, _ := Sender(signer, tx)
+			if p := tx.SignedProvider(signer); p != nil {
+				payer = *p
+			}
+			payers[payer] = true
+		}
+		if len(payers) != 3 || !payers[provider] {
+			t.Errorf("round %d: expected 3 distinct payers including the provider, found %d", round, len(payers))
+		}
+	}
+}
+
 // TestTransactionJSON tests serializing/de-serializing to/from JSON.
 func TestTransactionJSON(t *testing.T) {
 	key, err := crypto.GenerateKey()
@@ -224,3 +288,249 @@ func TestTransactionJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestSponsoredTransactionEncode(t *testing.T) {
+	senderKey, _ := crypto.GenerateKey()
+	providerKey, _ := crypto.GenerateKey()
+	signer := NewEIP155Signer(big.NewInt(15))
+
+	tx := NewSponsoredTransaction(big.NewInt(15), 3, common.Address{1}, crypto.PubkeyToAddress(providerKey.PublicKey), big.NewInt(10), 50000, big.NewInt(2), []byte("abcdef"))
+	tx, err := SignTx(tx, signer, senderKey)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	if tx, err = ProviderSignTx(tx, signer, providerKey); err != nil {
+		t.Fatalf("could not co-sign transaction: %v", err)
+	}
+	// The canonical encoding is the envelope, hashed to identify the transaction
+	envelope, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if envelope[0] != SponsoredTxType {
+		t.Fatalf("envelope type mismatch: have %d, want %d", envelope[0], SponsoredTxType)
+	}
+	if have, want := tx.Hash(), crypto.Keccak256Hash(envelope); have != want {
+		t.Fatalf("hash mismatch: have %x, want %x", have, want)
+	}
+	// RLP wraps the envelope into a string, both decode into the same transaction
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("rlp encode error: %v", err)
+	}
+	if have := tx.Size(); int(have) != len(enc) {
+		t.Fatalf("size mismatch: have %v, want %d", have, len(enc))
+	}
+	for i, blob := range [][]byte{envelope, enc} {
+		parsed := new(Transaction)
+		if err := parsed.UnmarshalBinary(blob); err != nil {
+			t.Fatalf("test %d: decode error: %v", i, err)
+		}
+		if parsed.Hash() != tx.Hash() || parsed.Type() != SponsoredTxType || parsed.ChainId().Cmp(big.NewInt(15)) != 0 {
+			t.Fatalf("test %d: parsed tx differs from original tx, want %v, got %v", i, tx, parsed)
+		}
+		if provider, err := Provider(signer, parsed); err != nil || *provider != *tx.Provider() {
+			t.Fatalf("test %d: provider mismatch: have %v (%v), want %x", i, provider, err, tx.Provider())
+		}
+	}
+	// Legacy and typed transactions mix within blocks
+	legacy, _ := SignTx(NewTransaction(0, common.Address{2}, common.Big0, 21000, common.Big1, nil), signer, senderKey)
+	blob, err := rlp.EncodeToBytes(Transactions{legacy, tx})
+	if err != nil {
+		t.Fatalf("rlp encode error: %v", err)
+	}
+	var txs Transactions
+	if err := rlp.DecodeBytes(blob, &txs); err != nil {
+		t.Fatalf("rlp decode error: %v", err)
+	}
+	if len(txs) != 2 || txs[0].Hash() != legacy.Hash() || txs[1].Hash() != tx.Hash() {
+		t.Fatalf("decoded transactions mismatch")
+	}
+	if have, want := legacy.Type(), uint8(LegacyTxType); have != want {
+		t.Fatalf("legacy type mismatch: have %d, want %d", have, want)
+	}
+	if DeriveSha(txs) != DeriveSha(Transactions{legacy, tx}) {
+		t.Fatalf("transaction root mismatch")
+	}
+	// JSON carries the type and chain id of typed transactions
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	var parsed *Transaction
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if parsed.Hash() != tx.Hash() {
+		t.Errorf("parsed tx differs from original tx, want %v, got %v", tx, parsed)
+	}
+	// Unknown envelope types are rejected
+	if err := new(Transaction).UnmarshalBinary(append([]byte{0x7f}, envelope[1:]...)); err != ErrTxTypeNotSupported {
+		t.Fatalf("unknown type error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
+
+func TestBatchTransactionEncode(t *testing.T) {
+	senderKey, _ := crypto.GenerateKey()
+	providerKey, _ := crypto.GenerateKey()
+	provider := crypto.PubkeyToAddress(providerKey.PublicKey)
+	signer := NewEIP155Signer(big.NewInt(15))
+
+	calls := []BatchCall{
+		{To: common.Address{1}, Value: big.NewInt(10), Data: []byte("abc")},
+		{To: common.Address{1}, Value: big.NewInt(5)},
+	}
+	for i, sponsor := range []*common.Address{nil, &provider} {
+		tx, err := SignTx(NewBatchTransaction(big.NewInt(15), 3, calls, 80000, big.NewInt(2), sponsor), signer, senderKey)
+		if err != nil {
+			t.Fatalf("test %d: could not sign transaction: %v", i, err)
+		}
+		if sponsor != nil {
+			if tx, err = ProviderSignTx(tx, signer, providerKey); err != nil {
+				t.Fatalf("test %d: could not co-sign transaction: %v", i, err)
+			}
+		}
+		if *tx.To() != BatchCallAddress || tx.Value().Cmp(big.NewInt(15)) != 0 {
+			t.Fatalf("test %d: batch recipient or value mismatch: have %x %v", i, tx.To(), tx.Value())
+		}
+		envelope, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: encode error: %v", i, err)
+		}
+		if envelope[0] != BatchTxType {
+			t.Fatalf("test %d: envelope type mismatch: have %d, want %d", i, envelope[0], BatchTxType)
+		}
+		parsed := new(Transaction)
+		if err := parsed.UnmarshalBinary(envelope); err != nil {
+			t.Fatalf("test %d: decode error: %v", i, err)
+		}
+		if parsed.Hash() != tx.Hash() || !parsed.IsBatch() || len(parsed.Calls()) != len(calls) {
+			t.Fatalf("test %d: parsed tx differs from original tx, want %v, got %v", i, tx, parsed)
+		}
+		for j, call := range parsed.Calls() {
+			if call.To != calls[j].To || call.Value.Cmp(calls[j].Value) != 0 || !bytes.Equal(call.Data, calls[j].Data) {
+				t.Fatalf("test %d: call %d mismatch: have %v, want %v", i, j, call, calls[j])
+			}
+		}
+		if *parsed.To() != BatchCallAddress || parsed.Value().Cmp(tx.Value()) != 0 {
+			t.Fatalf("test %d: parsed recipient or value mismatch: have %x %v", i, parsed.To(), parsed.Value())
+		}
+		if from, err := Sender(signer, parsed); err != nil || from != crypto.PubkeyToAddress(senderKey.PublicKey) {
+			t.Fatalf("test %d: sender mismatch: have %x (%v)", i, from, err)
+		}
+		if sponsor != nil {
+			if have, err := Provider(signer, parsed); err != nil || *have != provider {
+				t.Fatalf("test %d: provider mismatch: have %v (%v), want %x", i, have, err, provider)
+			}
+		}
+		data, err := json.Marshal(tx)
+		if err != nil {
+			t.Fatalf("test %d: json.Marshal failed: %v", i, err)
+		}
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			t.Fatalf("test %d: json.Unmarshal failed: %v", i, err)
+		}
+		if parsed.Hash() != tx.Hash() {
+			t.Errorf("test %d: json parsed tx differs from original tx, want %v, got %v", i, tx, parsed)
+		}
+	}
+}
+
+func TestScheduledTransactionEncode(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := NewEIP155Signer(big.NewInt(15))
+
+	tx, err := SignTx(NewScheduledTransaction(big.NewInt(15), 1, &common.Address{1}, big.NewInt(10), 50000, big.NewInt(2), []byte("abc"), 100, 200, nil), signer, key)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	envelope, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if envelope[0] != ScheduledTxType {
+		t.Fatalf("envelope type mismatch: have %d, want %d", envelope[0], ScheduledTxType)
+	}
+	parsed := new(Transaction)
+	if err := parsed.UnmarshalBinary(envelope); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if parsed.Hash() != tx.Hash() || parsed.ValidAfter() != 100 || parsed.ValidBefore() != 200 {
+		t.Fatalf("parsed tx differs from original tx, want %v, got %v", tx, parsed)
+	}
+	if from, err := Sender(signer, parsed); err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("sender mismatch: have %x (%v)", from, err)
+	}
+	// The window is part of the signed payload
+	forged := &Transaction{data: parsed.data}
+	forged.data.ValidAfter = 0
+	if from, _ := Sender(signer, forged); from == crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("sender recovered from forged window")
+	}
+	for number, want := range map[uint64][2]bool{99: {true, false}, 100: {false, false}, 199: {false, false}, 200: {false, true}} {
+		if tx.NotYetValid(number) != want[0] || tx.Expired(number) != want[1] {
+			t.Errorf("block %d: window mismatch: have %v/%v, want %v/%v", number, tx.NotYetValid(number), tx.Expired(number), want[0], want[1])
+		}
+	}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if parsed.Hash() != tx.Hash() {
+		t.Errorf("json parsed tx differs from original tx, want %v, got %v", tx, parsed)
+	}
+}
+
+func TestContractTransactionEncode(t *testing.T) {
+	var (
+		sender = common.Address{0xaa}
+		signer = NewEIP155Signer(big.NewInt(15))
+	)
+	tx := NewContractTransaction(big.NewInt(15), sender, 1, &common.Address{1}, big.NewInt(10), 50000, big.NewInt(2), []byte("abc"), nil)
+	auth := tx.SenderAuth()
+	if auth == nil || auth.Hash != signer.Hash(tx) {
+		t.Fatalf("sender auth mismatch: have %v, want hash %x", auth, signer.Hash(tx))
+	}
+	// The authorization data doesn't change the hash it authorizes
+	tx = tx.WithAuth([]byte("signatures"))
+	if have := tx.SenderAuth(); have.Hash != auth.Hash || !bytes.Equal(have.Data, []byte("signatures")) {
+		t.Fatalf("authorized tx mismatch: have %x/%x, want %x/%x", have.Hash, have.Data, auth.Hash, []byte("signatures"))
+	}
+	envelope, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if envelope[0] != ContractTxType {
+		t.Fatalf("envelope type mismatch: have %d, want %d", envelope[0], ContractTxType)
+	}
+	parsed := new(Transaction)
+	if err := parsed.UnmarshalBinary(envelope); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if parsed.Hash() != tx.Hash() || !bytes.Equal(parsed.SenderAuth().Data, []byte("signatures")) {
+		t.Fatalf("parsed tx differs from original tx, want %v, got %v", tx, parsed)
+	}
+	// The sender is named by the transaction, not recovered from a signature
+	if from, err := Sender(signer, parsed); err != nil || from != sender {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", from, err, sender)
+	}
+	if _, err := Sender(NewEIP155Signer(big.NewInt(16)), parsed); err != ErrInvalidChainId {
+		t.Fatalf("chain id error mismatch: have %v, want %v", err, ErrInvalidChainId)
+	}
+	if provider, err := Provider(signer, parsed); err != nil || provider != nil {
+		t.Fatalf("provider mismatch: have %v (%v), want none", provider, err)
+	}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if parsed.Hash() != tx.Hash() {
+		t.Errorf("json parsed tx differs from original tx, want %v, got %v", tx, parsed)
+	}
+}
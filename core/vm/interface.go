@@ -34,6 +34,12 @@ type StateDB interface {
 	GetNonce(common.Address) uint64
 	SetNonce(common.Address, uint64)
 
+	GetOwner(common.Address) *common.Address
+	SetOwner(common.Address, *common.Address)
+	GetProviders(common.Address) []*common.Address
+	AddProvider(common.Address, common.Address)
+	RemoveProvider(common.Address, common.Address)
+
 	GetCodeHash(common.Address) common.Hash
 	GetCode(common.Address) []byte
 	SetCode(common.Address, []byte)
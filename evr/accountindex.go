@@ -0,0 +1,306 @@
+package evr
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+)
+
+const (
+	// accountIndexThrottling is the time to wait between processing two
+	// consecutive account index sections, preventing disk overload while
+	// indexing the history.
+	accountIndexThrottling = 20 * time.Millisecond
+
+	// maxAccountTransactions is the number of transactions an account history
+	// query returns at most, the rest of the range is left for the next page.
+	maxAccountTransactions = 1000
+)
+
+// Roles an account may play in a transaction, as recorded in the account index.
+const (
+	AccountRoleSender    uint8 = 1 << iota // Account signed and sent the transaction
+	AccountRoleRecipient                   // Account is the recipient, the created contract or a batch call target
+	AccountRoleProvider                    // Account paid the gas of the transaction as its provider
+	AccountRoleInternal                    // Account sent or received value within the execution
+
+	accountRolesAll = AccountRoleSender | AccountRoleRecipient | AccountRoleProvider | AccountRoleInternal
+)
+
+// accountRoleNames maps the roles to their names in the RPC API.
+var accountRoleNames = []struct {
+	role uint8
+	name string
+}{
+	{AccountRoleSender, "from"},
+	{AccountRoleRecipient, "to"},
+	{AccountRoleProvider, "provider"},
+	{AccountRoleInternal, "internal"},
+}
+
+// AccountIndexer implements a core.ChainIndexer, building up an index of the
+// transactions each account is involved in, be it as sender, recipient, gas
+// provider or party of a value transfer within the execution.
+type AccountIndexer struct {
+	chain   *core.BlockChain                                 // Blockchain to re-execute the blocks on for the internal transfers
+	stateAt func(block *types.Block) (*state.StateDB, error) // Retrieves the state of a block, regenerating it if unavailable
+	size    uint64                                           // section size to generate the account index for
+	db      evrdb.Database                                   // database instance to write index data and metadata into
+	entries map[common.Address][]rawdb.AccountIndexEntry     // Transactions of the section involving each account
+	section uint64                                           // Section is the section number being processed currently
+	head    common.Hash                                      // Head is the hash of the last header processed
+}
+
+// NewAccountIndexer returns a chain indexer that generates the account index for
+// the canonical chain. The states the blocks are re-executed on are retrieved
+// through stateAt, which regenerates the pruned ones.
+func NewAccountIndexer(chain *core.BlockChain, stateAt func(block *types.Block) (*state.StateDB, error), db evrdb.Database, size, confirms uint64) *core.ChainIndexer {
+	backend := &AccountIndexer{
+		chain:   chain,
+		stateAt: stateAt,
+		db:      db,
+		size:    size,
+	}
+	table := rawdb.NewTable(db, string(rawdb.AccountIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, confirms, accountIndexThrottling, "accountindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new account index section.
+func (a *AccountIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	a.entries, a.section, a.head = make(map[common.Address][]rawdb.AccountIndexEntry), section, common.Hash{}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the transactions of a new
+// header's block to the accounts involved in them.
+func (a *AccountIndexer) Process(ctx context.Context, header *types.Header) error {
+	a.head = header.Hash()
+	number := header.Number.Uint64()
+
+	block := a.chain.GetBlock(a.head, number)
+	if block == nil {
+		// The transactions of expired blocks can't be retrieved anyway
+		if rawdb.HistoryExpired(a.db, number) {
+			return nil
+		}
+		return fmt.Errorf("block #%d missing", number)
+	}
+	if len(block.Transactions()) == 0 {
+		return nil
+	}
+	// Fail the section rather than indexing the block without its internal
+	// transfers, it's retried once the state can be regenerated
+	transfers, err := a.transfers(block)
+	if err != nil {
+		return fmt.Errorf("failed to re-execute block #%d: %v", number, err)
+	}
+	signer := types.MakeSigner(a.chain.Config(), header.Number)
+	for i, tx := range block.Transactions() {
+		roles := make(map[common.Address]uint8)
+
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		roles[from] |= AccountRoleSender
+
+		switch {
+		case tx.To() == nil:
+			roles[crypto.CreateAddress(from, tx.Nonce())] |= AccountRoleRecipient
+		case *tx.To() == types.BatchCallAddress:
+			for _, call := range tx.Calls() {
+				roles[call.To] |= AccountRoleRecipient
+			}
+		default:
+			roles[*tx.To()] |= AccountRoleRecipient
+		}
+		if provider := tx.SignedProvider(signer); provider != nil {
+			roles[*provider] |= AccountRoleProvider
+		}
+		for account := range transfers[i] {
+			roles[account] |= AccountRoleInternal
+		}
+		for account, role := range roles {
+			a.entries[account] = append(a.entries[account], rawdb.AccountIndexEntry{
+				Number: number,
+				Index:  uint64(i),
+				Hash:   tx.Hash(),
+				Roles:  role,
+			})
+		}
+	}
+	return nil
+}
+
+// transfers re-executes the transactions of a block on top of its parent state,
+// returning the accounts sending or receiving value within the execution of each
+// one. The transfers of failed transactions are reverted, and not returned.
+func (a *AccountIndexer) transfers(block *types.Block) ([]map[common.Address]struct{}, error) {
+	parent := a.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, err := a.stateAt(parent)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		config    = a.chain.Config()
+		signer    = types.MakeSigner(config, block.Number())
+		transfers = make([]map[common.Address]struct{}, len(block.Transactions()))
+	)
+	for i, tx := range block.Transactions() {
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			return nil, err
+		}
+		tracer := &transferTracer{accounts: make(map[common.Address]struct{})}
+
+		vmctx := core.NewEVMContext(msg, block.Header(), a.chain, nil)
+		vmctx.Transfer = tracer.transfer
+
+		vmenv := vm.NewEVM(vmctx, statedb, config, vm.Config{Debug: true, Tracer: tracer})
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		_, _, failed, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+		if err != nil {
+			return nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
+		}
+		statedb.Finalise(config.IsEIP158(block.Number()))
+
+		if !failed {
+			transfers[i] = tracer.accounts
+		}
+	}
+	return transfers, nil
+}
+
+// Commit implements core.ChainIndexerBackend, finalizing the account index
+// section and writing it out into the database.
+func (a *AccountIndexer) Commit() error {
+	batch := a.db.NewBatch()
+	for account, entries := range a.entries {
+		rawdb.WriteAccountIndexEntries(batch, account, a.section, a.head, entries)
+		if batch.ValueSize() >= evrdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
+
+// transferTracer is a vm.Tracer collecting the accounts sending or receiving
+// value within the execution of a transaction, besides its top level call. The
+// value moved by calls and contract creations is captured by hooking into the
+// transfer function of the EVM, self destructs are captured by the tracer.
+type transferTracer struct {
+	depth    int                         // Call depth of the last executed opcode, zero for the top level
+	accounts map[common.Address]struct{} // Accounts sending or receiving value
+}
+
+// transfer implements vm.TransferFunc, recording the parties of non-zero value
+// transfers made by nested calls.
+func (t *transferTracer) transfer(db vm.StateDB, sender, recipient common.Address, amount *big.Int) {
+	if t.depth > 0 && amount.Sign() > 0 {
+		t.accounts[sender] = struct{}{}
+		t.accounts[recipient] = struct{}{}
+	}
+	core.Transfer(db, sender, recipient, amount)
+}
+
+func (t *transferTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+func (t *transferTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	t.depth = depth
+	if op == vm.SELFDESTRUCT && err == nil && env.StateDB.GetBalance(contract.Address()).Sign() > 0 {
+		t.accounts[contract.Address()] = struct{}{}
+		t.accounts[common.BigToAddress(stack.Back(0))] = struct{}{}
+	}
+	return nil
+}
+
+func (t *transferTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd resets the call depth once a top level call ends, so the transfer of
+// the next one, made before it executes any opcode, isn't taken as nested (e.g.
+// the calls of a batch).
+func (t *transferTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.depth = 0
+	return nil
+}
+
+// AccountTransaction is a transaction involving an account, as returned by the
+// account history queries.
+type AccountTransaction struct {
+	Hash             common.Hash    `json:"hash"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	Roles            []string       `json:"roles"`
+}
+
+// AccountTransactions is a page of the transactions involving an account.
+type AccountTransactions struct {
+	Transactions []*AccountTransaction `json:"transactions"`
+	Next         *hexutil.Uint64       `json:"next"` // Block to continue the query from, nil if the range is exhausted
+}
+
+// accountTransactions retrieves the transactions of the blocks in the range
+// [begin, end] in which the account plays any of the given roles, from the first
+// indexed blocks of the chain. Pages end at block boundaries, once they hold at
+// least maxAccountTransactions, or at the first block not yet indexed.
+func accountTransactions(db evrdb.Reader, size, indexed uint64, account common.Address, roles uint8, begin, end uint64) *AccountTransactions {
+	result := &AccountTransactions{Transactions: []*AccountTransaction{}}
+	if begin >= indexed {
+		next := hexutil.Uint64(begin)
+		result.Next = &next
+		return result
+	}
+	if end >= indexed {
+		next := hexutil.Uint64(indexed)
+		result.Next = &next
+		end = indexed - 1
+	}
+	for section := begin / size; section <= end/size; section++ {
+		head := rawdb.ReadCanonicalHash(db, (section+1)*size-1)
+		for _, entry := range rawdb.ReadAccountIndexEntries(db, account, section, head) {
+			if entry.Number < begin || entry.Number > end || entry.Roles&roles == 0 {
+				continue
+			}
+			if n := len(result.Transactions); n >= maxAccountTransactions && uint64(result.Transactions[n-1].BlockNumber) != entry.Number {
+				next := hexutil.Uint64(entry.Number)
+				result.Next = &next
+				return result
+			}
+			tx := &AccountTransaction{
+				Hash:             entry.Hash,
+				BlockNumber:      hexutil.Uint64(entry.Number),
+				TransactionIndex: hexutil.Uint64(entry.Index),
+				Roles:            []string{},
+			}
+			for _, role := range accountRoleNames {
+				if entry.Roles&role.role != 0 {
+					tx.Roles = append(tx.Roles, role.name)
+				}
+			}
+			result.Transactions = append(result.Transactions, tx)
+		}
+	}
+	return result
+}
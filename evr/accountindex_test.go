@@ -0,0 +1,177 @@
+package evr
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestAccountIndexer(t *testing.T) {
+	var (
+		db             = rawdb.NewMemoryDatabase()
+		gendb          = rawdb.NewMemoryDatabase()
+		key, _         = crypto.GenerateKey()
+		sender         = crypto.PubkeyToAddress(key.PublicKey)
+		providerKey, _ = crypto.GenerateKey()
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		recipient      = common.Address{0x01}
+		beneficiary    = common.Address{0x02}
+		batchRecipient = common.Address{0x03}
+		gspec          = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{
+			sender:   {Balance: big.NewInt(1000000000000000000)},
+			provider: {Balance: big.NewInt(1000000000000000000)},
+		}}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+		price   = big.NewInt(params.GasPriceConfig)
+		size    = uint64(8)
+	)
+	// Forwarder contract, sending the value it's called with to the beneficiary
+	runtime := append(append(common.FromHex("0x60006000600060003473"), beneficiary.Bytes()...), common.FromHex("0x5af100")...)
+	code := append(common.FromHex("0x602180600b6000396000f3"), runtime...)
+	forwarder := crypto.CreateAddress(sender, 1)
+
+	sign := func(tx *types.Transaction) *types.Transaction {
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		return signed
+	}
+	var txs []*types.Transaction
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, int(size), func(i int, gen *core.BlockGen) {
+		var tx *types.Transaction
+		switch i {
+		case 0:
+			tx = sign(types.NewTransaction(gen.TxNonce(sender), recipient, big.NewInt(1), params.TxGas, price, nil))
+		case 1:
+			tx = sign(types.NewContractCreation(gen.TxNonce(sender), big.NewInt(0), 200000, price, code))
+		case 2:
+			tx = sign(types.NewTransaction(gen.TxNonce(sender), forwarder, big.NewInt(5), 100000, price, nil))
+		case 3:
+			var err error
+			tx = sign(types.NewTransaction(gen.TxNonce(sender), recipient, big.NewInt(1), params.TxGas, price, nil))
+			if tx, err = types.ProviderSignTx(tx, signer, providerKey); err != nil {
+				t.Fatalf("failed to sign transaction as provider: %v", err)
+			}
+		case 4:
+			// The transfer of the second call follows the nested one of the first
+			calls := []types.BatchCall{
+				{To: forwarder, Value: big.NewInt(5)},
+				{To: batchRecipient, Value: big.NewInt(1)},
+			}
+			tx = sign(types.NewBatchTransaction(gspec.Config.ChainID, gen.TxNonce(sender), calls, 200000, price, nil))
+		default:
+			return
+		}
+		gen.AddTx(tx)
+		txs = append(txs, tx)
+	})
+	gspec.MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Restart the chain, dropping the historical states to regenerate
+	chain.Stop()
+	chain, _ = core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	if _, err := chain.StateAt(blocks[1].Root()); err == nil {
+		t.Fatalf("historical state present")
+	}
+	regenStates := newRegenCache(regenStateCacheSize, regenStateCacheMemory)
+	indexStates := newRegenCache(regenStateCacheSize, regenStateCacheMemory)
+	evr := &Evrynet{blockchain: chain, chainDb: db, regenStates: regenStates}
+	stateAt := func(block *types.Block) (*state.StateDB, error) {
+		return evr.regenerateState(indexStates, block, size)
+	}
+	// Blocks whose state can't be regenerated fail the section, rather than being
+	// indexed without their internal transfers
+	unavailable := func(block *types.Block) (*state.StateDB, error) {
+		return evr.regenerateState(indexStates, block, 0)
+	}
+	indexer := &AccountIndexer{chain: chain, stateAt: unavailable, db: db, size: size}
+	if err := indexer.Reset(context.Background(), 0, common.Hash{}); err != nil {
+		t.Fatalf("failed to reset indexer: %v", err)
+	}
+	if err := indexer.Process(context.Background(), blocks[2].Header()); err == nil {
+		t.Fatalf("block indexed without its parent state")
+	}
+	indexer = &AccountIndexer{chain: chain, stateAt: stateAt, db: db, size: size}
+	if err := indexer.Reset(context.Background(), 0, common.Hash{}); err != nil {
+		t.Fatalf("failed to reset indexer: %v", err)
+	}
+	if err := indexer.Process(context.Background(), genesis.Header()); err != nil {
+		t.Fatalf("failed to process genesis: %v", err)
+	}
+	for _, block := range blocks[:size-1] {
+		if err := indexer.Process(context.Background(), block.Header()); err != nil {
+			t.Fatalf("failed to process block %d: %v", block.NumberU64(), err)
+		}
+	}
+	// The indexer keeps its regenerated states apart from the RPC ones
+	if indexStates.states.Len() == 0 || regenStates.states.Len() != 0 {
+		t.Fatalf("regenerated states mismatch: have %d indexer and %d RPC ones", indexStates.states.Len(), regenStates.states.Len())
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatalf("failed to commit section: %v", err)
+	}
+	type result struct {
+		tx    int
+		roles []string
+	}
+	tests := []struct {
+		account common.Address
+		roles   uint8
+		want    []result
+	}{
+		{sender, accountRolesAll, []result{{0, []string{"from"}}, {1, []string{"from"}}, {2, []string{"from"}}, {3, []string{"from"}}, {4, []string{"from"}}}},
+		{recipient, accountRolesAll, []result{{0, []string{"to"}}, {3, []string{"to"}}}},
+		{forwarder, accountRolesAll, []result{{1, []string{"to"}}, {2, []string{"to", "internal"}}, {4, []string{"to", "internal"}}}},
+		{forwarder, AccountRoleInternal, []result{{2, []string{"to", "internal"}}, {4, []string{"to", "internal"}}}},
+		{beneficiary, accountRolesAll, []result{{2, []string{"internal"}}, {4, []string{"internal"}}}},
+		{batchRecipient, accountRolesAll, []result{{4, []string{"to"}}}},
+		{provider, accountRolesAll, []result{{3, []string{"provider"}}}},
+		{provider, AccountRoleSender, nil},
+	}
+	for i, tt := range tests {
+		have := accountTransactions(db, size, size, tt.account, tt.roles, 0, size-1)
+		if have.Next != nil {
+			t.Errorf("test %d: next block present: %d", i, *have.Next)
+		}
+		if len(have.Transactions) != len(tt.want) {
+			t.Errorf("test %d: transaction count mismatch: have %d, want %d", i, len(have.Transactions), len(tt.want))
+			continue
+		}
+		for j, want := range tt.want {
+			tx := have.Transactions[j]
+			if tx.Hash != txs[want.tx].Hash() || uint64(tx.BlockNumber) != uint64(want.tx+1) || tx.TransactionIndex != 0 {
+				t.Errorf("test %d, transaction %d: mismatch: have %x in block %d, want %x in block %d", i, j, tx.Hash, tx.BlockNumber, txs[want.tx].Hash(), want.tx+1)
+			}
+			if !reflect.DeepEqual(tx.Roles, want.roles) {
+				t.Errorf("test %d, transaction %d: roles mismatch: have %v, want %v", i, j, tx.Roles, want.roles)
+			}
+		}
+	}
+	// Ranges reaching beyond the index continue from the first unindexed block
+	have := accountTransactions(db, size, size, recipient, accountRolesAll, 2, size+1)
+	if len(have.Transactions) != 1 || have.Next == nil || uint64(*have.Next) != size {
+		t.Errorf("partially indexed range mismatch: have %d transactions, next %v", len(have.Transactions), have.Next)
+	}
+	have = accountTransactions(db, size, size, recipient, accountRolesAll, size, size+1)
+	if len(have.Transactions) != 0 || have.Next == nil || uint64(*have.Next) != size {
+		t.Errorf("unindexed range mismatch: have %d transactions, next %v", len(have.Transactions), have.Next)
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/internal/evrapi"
+	"github.com/Evrynetlabs/evrynet-node/params"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 	"github.com/Evrynetlabs/evrynet-node/rpc"
 	"github.com/Evrynetlabs/evrynet-node/trie"
@@ -75,6 +76,86 @@ func (api *PublicEvrynetAPI) ChainId() hexutil.Uint64 {
 	return (hexutil.Uint64)(chainID.Uint64())
 }
 
+// IndexStatus is the progress of the indexes built over the chain in the
+// background.
+type IndexStatus struct {
+	Head           hexutil.Uint64  `json:"head"`           // Number of the chain head
+	TxIndexTail    *hexutil.Uint64 `json:"txIndexTail"`    // Oldest block whose transactions are indexed, nil if not yet known
+	TxLookupLimit  hexutil.Uint64  `json:"txLookupLimit"`  // Number of recent blocks to keep the transactions indexed of, 0 for all
+	BloomIndexed   hexutil.Uint64  `json:"bloomIndexed"`   // Number of blocks covered by the bloom bits index
+	LogIndex       bool            `json:"logIndex"`       // Whether the log index is maintained
+	LogIndexed     hexutil.Uint64  `json:"logIndexed"`     // Number of blocks covered by the log index
+	AccountIndex   bool            `json:"accountIndex"`   // Whether the account index is maintained
+	AccountIndexed hexutil.Uint64  `json:"accountIndexed"` // Number of blocks covered by the account index
+}
+
+// IndexStatus returns the progress of the transaction, bloom bits, log and
+// account indexes.
+func (api *PublicEvrynetAPI) IndexStatus() *IndexStatus {
+	status := &IndexStatus{
+		Head:          hexutil.Uint64(api.e.blockchain.CurrentBlock().NumberU64()),
+		TxLookupLimit: hexutil.Uint64(api.e.config.TxLookupLimit),
+		LogIndex:      api.e.logIndexer != nil,
+		AccountIndex:  api.e.accountIndexer != nil,
+	}
+	if tail := api.e.blockchain.TxIndexTail(); tail != nil {
+		status.TxIndexTail = (*hexutil.Uint64)(tail)
+	}
+	sections, _, _ := api.e.bloomIndexer.Sections()
+	status.BloomIndexed = hexutil.Uint64(sections * params.BloomBitsBlocks)
+
+	if api.e.logIndexer != nil {
+		sections, _, _ := api.e.logIndexer.Sections()
+		status.LogIndexed = hexutil.Uint64(sections * params.LogIndexBlocks)
+	}
+	if api.e.accountIndexer != nil {
+		sections, _, _ := api.e.accountIndexer.Sections()
+		status.AccountIndexed = hexutil.Uint64(sections * params.AccountIndexBlocks)
+	}
+	return status
+}
+
+// errAccountIndexDisabled is returned if the transactions of an account are
+// requested while the account index isn't maintained.
+var errAccountIndexDisabled = errors.New("account index not enabled")
+
+// GetTransactionsByAddress returns the transactions of the blocks in the range
+// [from, to] in which the given account plays the given role, one of "from",
+// "to", "provider" and "internal", or any role if omitted. Results are paged,
+// a non-nil next block in the response is where to continue the query from.
+// Only the blocks covered by the account index are searched, the next block
+// of a page reaching beyond them is the first one not yet indexed.
+func (api *PublicEvrynetAPI) GetTransactionsByAddress(address common.Address, from rpc.BlockNumber, to rpc.BlockNumber, role *string) (*AccountTransactions, error) {
+	if api.e.accountIndexer == nil {
+		return nil, errAccountIndexDisabled
+	}
+	roles := accountRolesAll
+	if role != nil && *role != "" {
+		roles = 0
+		for _, known := range accountRoleNames {
+			if known.name == *role {
+				roles = known.role
+			}
+		}
+		if roles == 0 {
+			return nil, fmt.Errorf("unknown account role %q", *role)
+		}
+	}
+	head := api.e.blockchain.CurrentBlock().NumberU64()
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 || uint64(number) > head {
+			return head
+		}
+		return uint64(number)
+	}
+	begin, end := resolve(from), resolve(to)
+	if begin > end {
+		return nil, fmt.Errorf("invalid block range %d..%d", begin, end)
+	}
+	sections, _, _ := api.e.accountIndexer.Sections()
+	return accountTransactions(api.e.chainDb, params.AccountIndexBlocks, sections*params.AccountIndexBlocks, address, roles, begin, end), nil
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -104,7 +104,17 @@ func (b *EvrAPIBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 		return nil, nil, errors.New("header not found")
 	}
 	stateDb, err := b.evr.BlockChain().StateAt(header.Root)
-	return stateDb, header, err
+	if err != nil {
+		// Regenerate missing historical states by re-executing their blocks
+		block := b.evr.blockchain.GetBlock(header.Hash(), header.Number.Uint64())
+		if block == nil {
+			return nil, header, err
+		}
+		if stateDb, err = b.evr.stateAtBlock(block, b.evr.config.StateReexec); err != nil {
+			return nil, header, err
+		}
+	}
+	return stateDb, header, nil
 }
 
 func (b *EvrAPIBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
@@ -196,6 +206,10 @@ func (b *EvrAPIBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.evr.TxPool().Content()
 }
 
+func (b *EvrAPIBackend) TxPoolProviderSpends() map[common.Address]*big.Int {
+	return b.evr.TxPool().ProviderSpends()
+}
+
 func (b *EvrAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.evr.TxPool().SubscribeNewTxsEvent(ch)
 }
@@ -237,6 +251,14 @@ func (b *EvrAPIBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, sections
 }
 
+func (b *EvrAPIBackend) LogIndexStatus() (uint64, uint64) {
+	if b.evr.logIndexer == nil {
+		return params.LogIndexBlocks, 0
+	}
+	sections, _, _ := b.evr.logIndexer.Sections()
+	return params.LogIndexBlocks, sections
+}
+
 func (b *EvrAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	for i := 0; i < bloomFilterThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.evr.bloomRequests)
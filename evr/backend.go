@@ -36,6 +36,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/bloombits"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/core/vm"
 	"github.com/Evrynetlabs/evrynet-node/event"
@@ -81,8 +82,11 @@ type Evrynet struct {
 	engine         consensus.Engine
 	accountManager *accounts.Manager
 
-	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
-	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	bloomRequests  chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
+	bloomIndexer   *core.ChainIndexer             // Bloom indexer operating during block imports
+	logIndexer     *core.ChainIndexer             // Log indexer operating during block imports, nil if disabled
+	accountIndexer *core.ChainIndexer             // Account indexer operating during block imports, nil if disabled
+	regenStates    *regenCache                    // Recently regenerated historical states, by block hash
 
 	APIBackend *EvrAPIBackend
 
@@ -141,6 +145,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
 	}
+	evr.regenStates = newRegenCache(regenStateCacheSize, regenStateCacheMemory)
+	if config.LogIndex {
+		evr.logIndexer = NewLogIndexer(chainDb, params.LogIndexBlocks, params.LogIndexConfirms)
+	}
 
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
 	var dbVer = "<nil>"
@@ -169,6 +177,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 			TrieDirtyLimit:      config.TrieDirtyCache,
 			TrieDirtyDisabled:   config.NoPruning,
 			TrieTimeLimit:       config.TrieTimeout,
+			SnapshotLimit:       config.SnapshotCache,
+			TriePruneRecent:     config.PruneRecent,
+			HistoryRetention:    config.HistoryRetention,
+			TxLookupLimit:       config.TxLookupLimit,
 		}
 	)
 	evr.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, evr.engine, vmConfig, evr.shouldPreserve)
@@ -182,6 +194,19 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	evr.bloomIndexer.Start(evr.blockchain)
+	if evr.logIndexer != nil {
+		evr.logIndexer.Start(evr.blockchain)
+	}
+	if config.AccountIndex {
+		// The indexer walks the chain on its own, keep its states apart from the
+		// ones regenerated for the RPC calls
+		indexStates := newRegenCache(regenStateCacheSize, regenStateCacheMemory)
+		stateAt := func(block *types.Block) (*state.StateDB, error) {
+			return evr.regenerateState(indexStates, block, config.StateReexec)
+		}
+		evr.accountIndexer = NewAccountIndexer(evr.blockchain, stateAt, chainDb, params.AccountIndexBlocks, params.AccountIndexConfirms)
+		evr.accountIndexer.Start(evr.blockchain)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
@@ -193,6 +218,18 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 	if evr.protocolManager, err = NewProtocolManager(chainConfig, config.SyncMode, config.NetworkId, evr.eventMux, evr.txPool, evr.engine, evr.blockchain, chainDb, cacheLimit, config.Whitelist); err != nil {
 		return nil, err
 	}
+	if config.SyncMode == downloader.CheckpointSync {
+		if chainConfig.Tendermint == nil {
+			return nil, errors.New("checkpoint sync requires the Tendermint consensus")
+		}
+		checkpoint := downloader.SyncCheckpoint{Hash: evr.blockchain.Genesis().Hash()}
+		if config.SyncCheckpoint != nil {
+			checkpoint = *config.SyncCheckpoint
+		}
+		if err := evr.protocolManager.downloader.SetSyncCheckpoint(checkpoint, config.Tendermint.Epoch, config.Tendermint.FixedValidators); err != nil {
+			return nil, err
+		}
+	}
 	evr.miner = miner.New(evr, &config.Miner, chainConfig, evr.EventMux(), evr.engine, evr.isLocalBlock)
 	evr.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
 
@@ -442,11 +479,8 @@ func (s *Evrynet) StartMining(threads int) error {
 	}
 	// If the miner was not running, initialize it
 	if !s.IsMining() {
-		// Propagate the initial price point to the transaction pool
-		s.lock.RLock()
-		price := s.gasPrice
-		s.lock.RUnlock()
-		s.txPool.SetGasPrice(price)
+		// Propagate the price point scheduled in the chain head state to the transaction pool
+		s.txPool.SetGasPrice(s.txPool.ScheduledGasPrice())
 
 		// Configure the local mining address
 		eb, err := s.Etherbase()
@@ -544,6 +578,12 @@ func (s *Evrynet) GetPm() *ProtocolManager {
 // Evrynet protocol.
 func (s *Evrynet) Stop() error {
 	s.bloomIndexer.Close()
+	if s.logIndexer != nil {
+		s.logIndexer.Close()
+	}
+	if s.accountIndexer != nil {
+		s.accountIndexer.Close()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 	s.protocolManager.Stop()
@@ -49,6 +49,8 @@ var DefaultConfig = Config{
 	TrieCleanCache: 256,
 	TrieDirtyCache: 256,
 	TrieTimeout:    60 * time.Minute,
+	SnapshotCache:  256,
+	StateReexec:    128,
 	Miner: miner.Config{
 		GasFloor: 8000000,
 		GasCeil:  8000000,
@@ -96,12 +98,39 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	GasPrice  *big.Int
 
-	NoPruning  bool // Whether to disable pruning and flush everything to disk
-	NoPrefetch bool // Whether to disable prefetching and only load state on demand
+	NoPruning   bool // Whether to disable pruning and flush everything to disk
+	NoPrefetch  bool // Whether to disable prefetching and only load state on demand
+	PruneRecent int  // Number of recently committed states to keep when deleting stale state from disk, 0 disables it
+
+	// Number of blocks to re-execute at most to regenerate a historical state
+	// missing from the database, starting from the nearest persisted one. 0
+	// serves the persisted states only.
+	StateReexec uint64
+
+	// Number of recent blocks to keep the bodies and receipts of, older ones
+	// being expired from the ancient store. 0 keeps the entire history.
+	HistoryRetention uint64 `toml:",omitempty"`
+
+	// Number of recent blocks to keep the transaction index of, older ones
+	// being unindexed in the background. 0 indexes the entire chain.
+	TxLookupLimit uint64 `toml:",omitempty"`
+
+	// Whether to maintain an index of the blocks holding the logs of each address
+	// and topic, speeding up the log filtering of given contracts or events.
+	LogIndex bool `toml:",omitempty"`
+
+	// Whether to maintain an index of the transactions each account is involved
+	// in, as sender, recipient, gas provider or party of an internal transfer.
+	// The internal transfers are found by re-executing the blocks, which requires
+	// the states of the entire chain, i.e. NoPruning.
+	AccountIndex bool `toml:",omitempty"`
 
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
+	// Trusted epoch header checkpoint sync starts from, the genesis if nil
+	SyncCheckpoint *downloader.SyncCheckpoint `toml:",omitempty"`
+
 	// Light client options
 	LightServ         int  `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightBandwidthIn  int  `toml:",omitempty"` // Incoming bandwidth limit for light servers
@@ -121,6 +150,7 @@ type Config struct {
 	TrieCleanCache int
 	TrieDirtyCache int
 	TrieTimeout    time.Duration
+	SnapshotCache  int // Megabytes of the state snapshot cache, 0 disables snapshots
 
 	// Mining options
 	Miner miner.Config
@@ -0,0 +1,223 @@
+package downloader
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+var (
+	errNoSyncCheckpoint  = errors.New("no trusted checkpoint to sync from")
+	errInvalidCheckpoint = errors.New("retrieved checkpoint header is not the trusted one")
+	errInvalidCommit     = errors.New("retrieved header without valid commits")
+)
+
+// SyncCheckpoint is the trusted Tendermint epoch header checkpoint sync starts from.
+// The validators written in an epoch header commit the blocks of the next epoch, so
+// trusting one epoch header is enough to verify the chain after it, epoch by epoch.
+type SyncCheckpoint struct {
+	Number uint64      `toml:",omitempty"` // Number of the trusted epoch header
+	Hash   common.Hash `toml:",omitempty"` // Hash of the trusted epoch header
+}
+
+// commitVerifier checks that headers are committed by a quorum of the validators of
+// their epoch, walking the epochs forward from a trusted epoch header.
+type commitVerifier struct {
+	epoch      uint64           // Number of blocks of an epoch
+	fixed      bool             // Whether the validators never change
+	number     uint64           // Number of the last trusted epoch header
+	validators []common.Address // Validators committing the blocks of the epoch after it
+}
+
+// newCommitVerifier creates a verifier trusting the validators written in the
+// given epoch header, or the fixed validators if any.
+func newCommitVerifier(epoch uint64, fixed []common.Address, trusted *types.Header) (*commitVerifier, error) {
+	if len(fixed) > 0 {
+		return &commitVerifier{epoch: epoch, fixed: true, number: trusted.Number.Uint64(), validators: fixed}, nil
+	}
+	validators, err := utils.GetValSetAddresses(trusted)
+	if err != nil {
+		return nil, err
+	}
+	return &commitVerifier{epoch: epoch, number: trusted.Number.Uint64(), validators: validators}, nil
+}
+
+// next returns the number of the next epoch header to walk to, the one committed by
+// the currently trusted validators.
+func (v *commitVerifier) next() uint64 {
+	return v.number + v.epoch
+}
+
+// verify checks the committed seals of a header of the currently trusted epoch.
+func (v *commitVerifier) verify(header *types.Header) error {
+	number := header.Number.Uint64()
+	if !v.fixed && (number <= v.number || number > v.next()) {
+		log.Debug("Header outside of the trusted epoch", "number", number, "epoch", v.number)
+		return errInvalidCommit
+	}
+	valSet := validator.NewSet(v.validators, tendermint.RoundRobin, int64(number))
+	if err := utils.VerifyCommittedSeals(header, valSet); err != nil {
+		log.Debug("Invalid committed seals", "number", number, "hash", header.Hash(), "err", err)
+		return errInvalidCommit
+	}
+	return nil
+}
+
+// advance verifies the next epoch header and trusts the validators written in it.
+func (v *commitVerifier) advance(header *types.Header) error {
+	if header.Number.Uint64() != v.next() {
+		log.Debug("Unexpected epoch header", "number", header.Number, "want", v.next())
+		return errInvalidCommit
+	}
+	if err := v.verify(header); err != nil {
+		return err
+	}
+	validators, err := utils.GetValSetAddresses(header)
+	if err != nil {
+		log.Debug("Epoch header without validators", "number", header.Number, "err", err)
+		return errInvalidCommit
+	}
+	v.number, v.validators = header.Number.Uint64(), validators
+	return nil
+}
+
+// verifyChain checks the committed seals of a contiguous chain of headers, trusting
+// the validators written in the epoch headers as it reaches them. The headers up to
+// the trusted one are linked to it by their hashes and are not checked.
+func (v *commitVerifier) verifyChain(headers []*types.Header) error {
+	for _, header := range headers {
+		var err error
+		switch number := header.Number.Uint64(); {
+		case number <= v.number:
+			continue
+		case !v.fixed && number == v.next():
+			err = v.advance(header)
+		default:
+			err = v.verify(header)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetSyncCheckpoint sets the trusted epoch header checkpoint sync starts from, with
+// the epoch length and fixed validators of the Tendermint chain.
+func (d *Downloader) SetSyncCheckpoint(checkpoint SyncCheckpoint, epoch uint64, fixed []common.Address) error {
+	if epoch == 0 || checkpoint.Number%epoch != 0 {
+		return errors.New("sync checkpoint is not an epoch header")
+	}
+	d.syncCheckpoint = &checkpoint
+	d.syncEpoch, d.syncValidators = epoch, fixed
+	return nil
+}
+
+// verifyCommits refuses peers whose chain isn't committed by the validators. It walks
+// the epoch headers of the peer from the trusted checkpoint, each committed by the
+// validators of the previous one, up to the epochs of the pivot and of the head, and
+// checks that both are committed too. The headers in between are checked against the
+// same validators as they are imported.
+func (d *Downloader) verifyCommits(p *peerConnection, head *types.Header, pivot uint64) error {
+	checkpoint := d.syncCheckpoint
+	if checkpoint == nil {
+		return errNoSyncCheckpoint
+	}
+	if head.Number.Uint64() <= checkpoint.Number {
+		p.log.Warn("Remote head below sync checkpoint", "number", head.Number, "checkpoint", checkpoint.Number)
+		return errUnsyncedPeer
+	}
+	trusted := d.lightchain.GetHeaderByHash(checkpoint.Hash)
+	if trusted == nil {
+		headers, err := d.fetchHeadersByNumber(p, checkpoint.Number, 1, 0)
+		if err != nil {
+			return err
+		}
+		if trusted = headers[0]; trusted.Hash() != checkpoint.Hash {
+			p.log.Warn("Checkpoint header mismatch", "number", checkpoint.Number, "hash", trusted.Hash(), "want", checkpoint.Hash)
+			return errInvalidCheckpoint
+		}
+	}
+	verifier, err := newCommitVerifier(d.syncEpoch, d.syncValidators, trusted)
+	if err != nil {
+		return err
+	}
+	// The headers are imported from the checkpoint on, so keep a copy of the verifier
+	// before it walks to the head. Walking replaces the validators, never modifies them.
+	commits := *verifier
+	d.syncCommits = &commits
+
+	targets := []*types.Header{head}
+	if pivot > checkpoint.Number && pivot < head.Number.Uint64() {
+		headers, err := d.fetchHeadersByNumber(p, pivot, 1, 0)
+		if err != nil {
+			return err
+		}
+		targets = []*types.Header{headers[0], head}
+	}
+	for _, target := range targets {
+		number := target.Number.Uint64()
+		for !verifier.fixed && verifier.next() < number {
+			count := (number-1-verifier.next())/verifier.epoch + 1
+			if count > uint64(MaxHeaderFetch) {
+				count = uint64(MaxHeaderFetch)
+			}
+			headers, err := d.fetchHeadersByNumber(p, verifier.next(), int(count), int(verifier.epoch-1))
+			if err != nil {
+				return err
+			}
+			for _, header := range headers {
+				if err := verifier.advance(header); err != nil {
+					return err
+				}
+			}
+		}
+		if err := verifier.verify(target); err != nil {
+			return err
+		}
+	}
+	p.log.Debug("Verified remote chain commits", "checkpoint", checkpoint.Number, "pivot", pivot, "head", head.Number)
+	return nil
+}
+
+// fetchHeadersByNumber retrieves a set of headers from the remote peer, failing if
+// the peer doesn't return all of them.
+func (d *Downloader) fetchHeadersByNumber(p *peerConnection, from uint64, count, skip int) ([]*types.Header, error) {
+	go p.peer.RequestHeadersByNumber(from, count, skip, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			headers := packet.(*headerPack).headers
+			if len(headers) != count {
+				p.log.Debug("Unexpected number of headers", "headers", len(headers), "want", count)
+				return nil, errBadPeer
+			}
+			return headers, nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for headers timed out", "elapsed", ttl)
+			return nil, errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
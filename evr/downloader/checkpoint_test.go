@@ -0,0 +1,182 @@
+package downloader
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+const testCommitEpoch = 4
+
+// committedChain is a Tendermint header chain whose validators change every epoch.
+type committedChain struct {
+	headers []*types.Header
+	keys    [][]*ecdsa.PrivateKey // Validator keys committing the blocks of each epoch
+}
+
+// newCommittedChain creates a chain of the given length, each header committed by
+// the given number of validators of its epoch, out of 4.
+func newCommittedChain(t *testing.T, length int, quorum func(number uint64) int) *committedChain {
+	chain := new(committedChain)
+	for i := 0; i <= length/testCommitEpoch; i++ {
+		keys := make([]*ecdsa.PrivateKey, 4)
+		for j := range keys {
+			keys[j], _ = crypto.GenerateKey()
+		}
+		chain.keys = append(chain.keys, keys)
+	}
+	payload, _ := rlp.EncodeToBytes(&types.TendermintExtra{})
+	for number := uint64(0); number < uint64(length); number++ {
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: big.NewInt(1),
+			MixDigest:  types.TendermintDigest,
+			Extra:      append(make([]byte, types.TendermintExtraVanity), payload...),
+		}
+		if number > 0 {
+			header.ParentHash = chain.headers[number-1].Hash()
+		}
+		// Epoch headers carry the validators of the next epoch
+		epoch := int(utils.GetCheckpointNumber(testCommitEpoch, number) / testCommitEpoch)
+		if number%testCommitEpoch == 0 {
+			var validators []common.Address
+			for _, key := range chain.keys[number/testCommitEpoch] {
+				validators = append(validators, crypto.PubkeyToAddress(key.PublicKey))
+			}
+			if err := utils.WriteValSet(header, validators); err != nil {
+				t.Fatalf("failed to write validators: %v", err)
+			}
+		}
+		if number > 0 {
+			var seals [][]byte
+			for _, key := range chain.keys[epoch][:quorum(number)] {
+				seal, err := crypto.Sign(crypto.Keccak256(utils.PrepareCommittedSeal(header.Hash())), key)
+				if err != nil {
+					t.Fatalf("failed to sign header: %v", err)
+				}
+				seals = append(seals, seal)
+			}
+			if err := utils.WriteCommittedSeals(header, seals); err != nil {
+				t.Fatalf("failed to write committed seals: %v", err)
+			}
+		}
+		chain.headers = append(chain.headers, header)
+	}
+	return chain
+}
+
+// commitTesterPeer serves the headers of a committed chain.
+type commitTesterPeer struct {
+	dl    *downloadTester
+	id    string
+	chain *committedChain
+}
+
+func (p *commitTesterPeer) Head() (common.Hash, *big.Int) {
+	head := p.chain.headers[len(p.chain.headers)-1]
+	return head.Hash(), new(big.Int).Add(head.Number, common.Big1)
+}
+
+func (p *commitTesterPeer) RequestHeadersByHash(common.Hash, int, int, bool) error {
+	panic("header requests by hash not supported")
+}
+
+func (p *commitTesterPeer) RequestHeadersByNumber(origin uint64, amount int, skip int, reverse bool) error {
+	var headers []*types.Header
+	for number := origin; len(headers) < amount && number < uint64(len(p.chain.headers)); number += uint64(skip) + 1 {
+		headers = append(headers, p.chain.headers[number])
+	}
+	go p.dl.downloader.DeliverHeaders(p.id, headers)
+	return nil
+}
+
+func (p *commitTesterPeer) RequestBodies([]common.Hash) error   { return nil }
+func (p *commitTesterPeer) RequestReceipts([]common.Hash) error { return nil }
+func (p *commitTesterPeer) RequestNodeData([]common.Hash) error { return nil }
+
+// verifyCommits runs the commit verification of checkpoint sync against a peer
+// serving the given chain, then the one of the headers imported from it.
+func verifyCommits(t *testing.T, chain *committedChain, checkpoint SyncCheckpoint, pivot uint64) error {
+	tester := newTester()
+	defer tester.terminate()
+
+	if err := tester.downloader.SetSyncCheckpoint(checkpoint, testCommitEpoch, nil); err != nil {
+		t.Fatalf("failed to set sync checkpoint: %v", err)
+	}
+	if err := tester.downloader.RegisterPeer("peer", 63, &commitTesterPeer{dl: tester, id: "peer", chain: chain}); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	tester.downloader.cancelCh = make(chan struct{})
+	head := chain.headers[len(chain.headers)-1]
+	if err := tester.downloader.verifyCommits(tester.downloader.peers.Peer("peer"), head, pivot); err != nil {
+		return err
+	}
+	// Import the headers in chunks, like the skeleton fill delivers them
+	for i := 1; i < len(chain.headers); i += 5 {
+		end := i + 5
+		if end > len(chain.headers) {
+			end = len(chain.headers)
+		}
+		if err := tester.downloader.syncCommits.verifyChain(chain.headers[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tests that checkpoint sync walks the epochs of a committed chain from a trusted
+// checkpoint, and refuses chains without valid commits.
+func TestCheckpointSyncCommits(t *testing.T) {
+	quorum := func(uint64) int { return 3 }
+	chain := newCommittedChain(t, 23, quorum)
+
+	genesis := SyncCheckpoint{Hash: chain.headers[0].Hash()}
+	if err := verifyCommits(t, chain, genesis, 13); err != nil {
+		t.Errorf("committed chain refused from genesis: %v", err)
+	}
+	if err := verifyCommits(t, chain, SyncCheckpoint{Number: 8, Hash: chain.headers[8].Hash()}, 16); err != nil {
+		t.Errorf("committed chain refused from checkpoint: %v", err)
+	}
+	if err := verifyCommits(t, chain, SyncCheckpoint{Number: 8, Hash: chain.headers[4].Hash()}, 16); err != errInvalidCheckpoint {
+		t.Errorf("checkpoint mismatch: have %v, want %v", err, errInvalidCheckpoint)
+	}
+	if err := verifyCommits(t, chain, SyncCheckpoint{Number: 24, Hash: common.Hash{0x01}}, 0); err != errUnsyncedPeer {
+		t.Errorf("head below checkpoint: have %v, want %v", err, errUnsyncedPeer)
+	}
+	// A chain with an epoch header committed by too few validators is refused
+	chain = newCommittedChain(t, 23, func(number uint64) int {
+		if number == 12 {
+			return 2
+		}
+		return 3
+	})
+	if err := verifyCommits(t, chain, SyncCheckpoint{Hash: chain.headers[0].Hash()}, 13); err != errInvalidCommit {
+		t.Errorf("uncommitted epoch header: have %v, want %v", err, errInvalidCommit)
+	}
+	// And one with a header the sampled verification skips committed by too few
+	chain = newCommittedChain(t, 23, func(number uint64) int {
+		if number == 18 {
+			return 2
+		}
+		return 3
+	})
+	if err := verifyCommits(t, chain, SyncCheckpoint{Hash: chain.headers[0].Hash()}, 13); err != errInvalidCommit {
+		t.Errorf("uncommitted intermediate header: have %v, want %v", err, errInvalidCommit)
+	}
+	// So is a chain whose epoch was committed by other validators
+	chain = newCommittedChain(t, 23, quorum)
+	other := newCommittedChain(t, 23, quorum)
+	chain.headers[22] = other.headers[22]
+	if err := verifyCommits(t, chain, SyncCheckpoint{Hash: chain.headers[0].Hash()}, 13); err != errInvalidCommit {
+		t.Errorf("head committed by other validators: have %v, want %v", err, errInvalidCommit)
+	}
+	if err := new(Downloader).SetSyncCheckpoint(SyncCheckpoint{Number: 6}, testCommitEpoch, nil); err == nil {
+		t.Errorf("checkpoint outside of epoch headers accepted")
+	}
+}
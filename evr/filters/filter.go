@@ -22,8 +22,10 @@ import (
 	"math/big"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/bitutil"
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/bloombits"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/evrdb"
@@ -44,6 +46,7 @@ type Backend interface {
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 
 	BloomStatus() (uint64, uint64)
+	LogIndexStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
@@ -142,18 +145,34 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
-	// Gather all indexed logs, and finish with non indexed ones
+	// Gather all indexed logs, and finish with non indexed ones. The log index
+	// only helps when filtering on addresses or topics.
 	var (
 		logs []*types.Log
 		err  error
 	)
+	if f.selective() {
+		size, sections := f.backend.LogIndexStatus()
+		if indexed := sections * size; indexed > uint64(f.begin) {
+			if indexed > end {
+				logs, err = f.logIndexedLogs(ctx, size, end)
+			} else {
+				logs, err = f.logIndexedLogs(ctx, size, indexed-1)
+			}
+			if err != nil {
+				return logs, err
+			}
+		}
+	}
 	size, sections := f.backend.BloomStatus()
-	if indexed := sections * size; indexed > uint64(f.begin) {
+	if indexed := sections * size; indexed > uint64(f.begin) && uint64(f.begin) <= end {
+		var found []*types.Log
 		if indexed > end {
-			logs, err = f.indexedLogs(ctx, end)
+			found, err = f.indexedLogs(ctx, end)
 		} else {
-			logs, err = f.indexedLogs(ctx, indexed-1)
+			found, err = f.indexedLogs(ctx, indexed-1)
 		}
+		logs = append(logs, found...)
 		if err != nil {
 			return logs, err
 		}
@@ -163,6 +182,111 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	return logs, err
 }
 
+// selective returns whether the filter criteria restrict the addresses or the
+// topics of the logs.
+func (f *Filter) selective() bool {
+	if len(f.addresses) > 0 {
+		return true
+	}
+	for _, topics := range f.topics {
+		if len(topics) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// logIndexedLogs returns the logs matching the filter criteria based on the log
+// index, only inspecting the blocks holding logs of the filtered addresses and
+// topics.
+func (f *Filter) logIndexedLogs(ctx context.Context, size uint64, end uint64) ([]*types.Log, error) {
+	var logs []*types.Log
+
+	for section := uint64(f.begin) / size; section <= end/size; section++ {
+		head := rawdb.ReadCanonicalHash(f.db, (section+1)*size-1)
+		bits, err := f.logIndexBits(section, size, head)
+		if err != nil {
+			return logs, err
+		}
+		for number := uint64(f.begin); number <= end && number < (section+1)*size; number++ {
+			f.begin = int64(number) + 1
+
+			offset := number - section*size
+			if bits[offset/8]&(1<<(7-offset%8)) == 0 {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return logs, ctx.Err()
+			default:
+			}
+			header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return logs, err
+			}
+			found, err := f.checkMatches(ctx, header)
+			if err != nil {
+				return logs, err
+			}
+			logs = append(logs, found...)
+		}
+	}
+	return logs, nil
+}
+
+// logIndexBits returns the bitset of the blocks of a log index section holding
+// logs of any of the filtered addresses, and of any of the filtered topics for
+// each position. Topics aren't indexed by position, the logs of the blocks are
+// matched against the exact criteria afterwards.
+func (f *Filter) logIndexBits(section uint64, size uint64, head common.Hash) ([]byte, error) {
+	union := func(values [][]byte) ([]byte, error) {
+		bits := make([]byte, size/8)
+		for _, value := range values {
+			comp := rawdb.ReadLogIndexBits(f.db, value, section, head)
+			if comp == nil {
+				continue
+			}
+			blob, err := bitutil.DecompressBytes(comp, int(size/8))
+			if err != nil {
+				return nil, err
+			}
+			bitutil.ORBytes(bits, bits, blob)
+		}
+		return bits, nil
+	}
+	var filters [][][]byte
+	if len(f.addresses) > 0 {
+		filter := make([][]byte, len(f.addresses))
+		for i, address := range f.addresses {
+			filter[i] = address.Bytes()
+		}
+		filters = append(filters, filter)
+	}
+	for _, topicList := range f.topics {
+		if len(topicList) == 0 {
+			continue
+		}
+		filter := make([][]byte, len(topicList))
+		for i, topic := range topicList {
+			filter[i] = topic.Bytes()
+		}
+		filters = append(filters, filter)
+	}
+	var bits []byte
+	for _, filter := range filters {
+		matches, err := union(filter)
+		if err != nil {
+			return nil, err
+		}
+		if bits == nil {
+			bits = matches
+		} else {
+			bitutil.ANDBytes(bits, bits, matches)
+		}
+	}
+	return bits, nil
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
@@ -18,12 +18,14 @@ package filters
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"os"
 	"testing"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/bitutil"
 	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
@@ -56,7 +58,7 @@ func BenchmarkFilters(b *testing.B) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
 		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr1      = crypto.PubkeyToAddress(key1.PublicKey)
 		addr2      = common.BytesToAddress([]byte("jeff"))
@@ -115,7 +117,7 @@ func TestFilters(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
 		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr       = crypto.PubkeyToAddress(key1.PublicKey)
 
@@ -234,3 +236,92 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+func TestLogIndexFilters(t *testing.T) {
+	var (
+		db         = rawdb.NewMemoryDatabase()
+		mux        = new(event.TypeMux)
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, 1, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		size       = params.LogIndexBlocks
+
+		addr   = common.BytesToAddress([]byte("contract"))
+		other  = common.BytesToAddress([]byte("other"))
+		topic1 = common.BytesToHash([]byte("topic1"))
+		topic2 = common.BytesToHash([]byte("topic2"))
+	)
+	emitted := map[int]*types.Log{
+		10:            {Address: addr, Topics: []common.Hash{topic1}},
+		20:            {Address: addr, Topics: []common.Hash{topic2}},
+		30:            {Address: other, Topics: []common.Hash{topic1}},
+		int(size):     {Address: addr, Topics: []common.Hash{topic1}},
+		int(size + 5): {Address: addr, Topics: []common.Hash{topic2}},
+	}
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, int(size)+10, func(i int, gen *core.BlockGen) {
+		if log, ok := emitted[i]; ok {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{log}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.Address{}, big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	// Index the first section, leaving out the second topic to check that only
+	// the blocks marked by the index are inspected
+	bits := make(map[string][]byte)
+	mark := func(value []byte, number uint64) {
+		if bits[string(value)] == nil {
+			bits[string(value)] = make([]byte, size/8)
+		}
+		bits[string(value)][number/8] |= 1 << (7 - number%8)
+	}
+	for i, log := range emitted {
+		if number := uint64(i) + 1; number < size {
+			mark(log.Address.Bytes(), number)
+			if log.Topics[0] != topic2 {
+				mark(log.Topics[0].Bytes(), number)
+			}
+		}
+	}
+	head := rawdb.ReadCanonicalHash(db, size-1)
+	for value, bitset := range bits {
+		rawdb.WriteLogIndexBits(db, []byte(value), 0, head, bitutil.CompressBytes(bitset))
+	}
+	tests := []struct {
+		begin, end int64
+		addresses  []common.Address
+		topics     [][]common.Hash
+		want       []uint64
+	}{
+		{0, -1, []common.Address{addr}, nil, []uint64{11, 21, size + 1, size + 6}},
+		{0, -1, []common.Address{addr}, [][]common.Hash{{topic1}}, []uint64{11, size + 1}},
+		{0, -1, nil, [][]common.Hash{{topic1}}, []uint64{11, 31, size + 1}},
+		{0, -1, nil, [][]common.Hash{{topic2}}, []uint64{size + 6}},
+		{0, -1, []common.Address{addr, other}, [][]common.Hash{{topic1, topic2}}, []uint64{11, 31, size + 1, size + 6}},
+		{12, -1, []common.Address{addr}, nil, []uint64{21, size + 1, size + 6}},
+		{0, 20, nil, [][]common.Hash{{topic1}}, []uint64{11}},
+		{0, -1, nil, nil, []uint64{11, 21, 31, size + 1, size + 6}},
+	}
+	for i, tt := range tests {
+		logs, err := NewRangeFilter(backend, tt.begin, tt.end, tt.addresses, tt.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: failed to filter logs: %v", i, err)
+		}
+		var have []uint64
+		for _, log := range logs {
+			have = append(have, log.BlockNumber)
+		}
+		if fmt.Sprint(have) != fmt.Sprint(tt.want) {
+			t.Errorf("test %d: log blocks mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"sync"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/internal/evrapi"
 	"github.com/Evrynetlabs/evrynet-node/params"
@@ -71,9 +72,14 @@ func NewOracle(backend evrapi.Backend, params Config) *Oracle {
 	}
 }
 
-// SuggestPrice returns the recommended gas price.
+// SuggestPrice returns the gas price scheduled for the next block, falling back
+// to the genesis gas price if the state of the head block is not available.
 func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
-	return gpo.fixedGasPrice, nil
+	statedb, header, err := gpo.backend.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if statedb == nil || err != nil {
+		return gpo.fixedGasPrice, nil
+	}
+	return core.GasPriceAt(statedb, gpo.backend.ChainConfig(), header.Number.Uint64()+1), nil
 }
 
 type getBlockPricesResult struct {
@@ -23,11 +23,18 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SyncMode                downloader.SyncMode
 		NoPruning               bool
 		NoPrefetch              bool
-		Whitelist               map[uint64]common.Hash `toml:"-"`
-		LightServ               int                    `toml:",omitempty"`
-		LightBandwidthIn        int                    `toml:",omitempty"`
-		LightBandwidthOut       int                    `toml:",omitempty"`
-		LightPeers              int                    `toml:",omitempty"`
+		PruneRecent             int
+		StateReexec             uint64
+		HistoryRetention        uint64                     `toml:",omitempty"`
+		TxLookupLimit           uint64                     `toml:",omitempty"`
+		LogIndex                bool                       `toml:",omitempty"`
+		AccountIndex            bool                       `toml:",omitempty"`
+		Whitelist               map[uint64]common.Hash     `toml:"-"`
+		SyncCheckpoint          *downloader.SyncCheckpoint `toml:",omitempty"`
+		LightServ               int                        `toml:",omitempty"`
+		LightBandwidthIn        int                        `toml:",omitempty"`
+		LightBandwidthOut       int                        `toml:",omitempty"`
+		LightPeers              int                        `toml:",omitempty"`
 		OnlyAnnounce            bool
 		ULC                     *ULCConfig `toml:",omitempty"`
 		SkipBcVersionCheck      bool       `toml:"-"`
@@ -36,6 +43,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TrieCleanCache          int
 		TrieDirtyCache          int
 		TrieTimeout             time.Duration
+		SnapshotCache           int
 		Miner                   miner.Config
 		Ethash                  ethash.Config
 		TxPool                  core.TxPoolConfig
@@ -53,7 +61,14 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SyncMode = c.SyncMode
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
+	enc.PruneRecent = c.PruneRecent
+	enc.StateReexec = c.StateReexec
+	enc.HistoryRetention = c.HistoryRetention
+	enc.TxLookupLimit = c.TxLookupLimit
+	enc.LogIndex = c.LogIndex
+	enc.AccountIndex = c.AccountIndex
 	enc.Whitelist = c.Whitelist
+	enc.SyncCheckpoint = c.SyncCheckpoint
 	enc.LightServ = c.LightServ
 	enc.LightBandwidthIn = c.LightBandwidthIn
 	enc.LightBandwidthOut = c.LightBandwidthOut
@@ -66,6 +81,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieDirtyCache = c.TrieDirtyCache
 	enc.TrieTimeout = c.TrieTimeout
+	enc.SnapshotCache = c.SnapshotCache
 	enc.Miner = c.Miner
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
@@ -87,11 +103,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SyncMode                *downloader.SyncMode
 		NoPruning               *bool
 		NoPrefetch              *bool
-		Whitelist               map[uint64]common.Hash `toml:"-"`
-		LightServ               *int                   `toml:",omitempty"`
-		LightBandwidthIn        *int                   `toml:",omitempty"`
-		LightBandwidthOut       *int                   `toml:",omitempty"`
-		LightPeers              *int                   `toml:",omitempty"`
+		PruneRecent             *int
+		StateReexec             *uint64
+		HistoryRetention        *uint64                    `toml:",omitempty"`
+		TxLookupLimit           *uint64                    `toml:",omitempty"`
+		LogIndex                *bool                      `toml:",omitempty"`
+		AccountIndex            *bool                      `toml:",omitempty"`
+		Whitelist               map[uint64]common.Hash     `toml:"-"`
+		SyncCheckpoint          *downloader.SyncCheckpoint `toml:",omitempty"`
+		LightServ               *int                       `toml:",omitempty"`
+		LightBandwidthIn        *int                       `toml:",omitempty"`
+		LightBandwidthOut       *int                       `toml:",omitempty"`
+		LightPeers              *int                       `toml:",omitempty"`
 		OnlyAnnounce            *bool
 		ULC                     *ULCConfig `toml:",omitempty"`
 		SkipBcVersionCheck      *bool      `toml:"-"`
@@ -100,6 +123,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TrieCleanCache          *int
 		TrieDirtyCache          *int
 		TrieTimeout             *time.Duration
+		SnapshotCache           *int
 		Miner                   *miner.Config
 		Ethash                  *ethash.Config
 		TxPool                  *core.TxPoolConfig
@@ -130,9 +154,30 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.NoPrefetch != nil {
 		c.NoPrefetch = *dec.NoPrefetch
 	}
+	if dec.PruneRecent != nil {
+		c.PruneRecent = *dec.PruneRecent
+	}
+	if dec.StateReexec != nil {
+		c.StateReexec = *dec.StateReexec
+	}
+	if dec.HistoryRetention != nil {
+		c.HistoryRetention = *dec.HistoryRetention
+	}
+	if dec.TxLookupLimit != nil {
+		c.TxLookupLimit = *dec.TxLookupLimit
+	}
+	if dec.LogIndex != nil {
+		c.LogIndex = *dec.LogIndex
+	}
+	if dec.AccountIndex != nil {
+		c.AccountIndex = *dec.AccountIndex
+	}
 	if dec.Whitelist != nil {
 		c.Whitelist = dec.Whitelist
 	}
+	if dec.SyncCheckpoint != nil {
+		c.SyncCheckpoint = dec.SyncCheckpoint
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -169,6 +214,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TrieTimeout != nil {
 		c.TrieTimeout = *dec.TrieTimeout
 	}
+	if dec.SnapshotCache != nil {
+		c.SnapshotCache = *dec.SnapshotCache
+	}
 	if dec.Miner != nil {
 		c.Miner = *dec.Miner
 	}
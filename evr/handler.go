@@ -74,6 +74,8 @@ type ProtocolManager struct {
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
+	fastSyncMode downloader.SyncMode // Mode to fast sync with, fast or checkpoint sync
+
 	checkpointNumber uint64      // Block number for the sync progress validator to cross reference
 	checkpointHash   common.Hash // Block hash for the sync progress validator to cross reference
 
@@ -130,7 +132,11 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		handler.SetBroadcaster(manager)
 	}
 	// If fast sync was requested and our database is empty, grant it
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() == 0 {
+	manager.fastSyncMode = downloader.FastSync
+	if mode == downloader.CheckpointSync {
+		manager.fastSyncMode = downloader.CheckpointSync
+	}
+	if mode == manager.fastSyncMode && blockchain.CurrentBlock().NumberU64() == 0 {
 		manager.fastSync = uint32(1)
 	}
 	// If we have trusted checkpoints, enforce them on the chain
@@ -0,0 +1,104 @@
+package evr
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/bitutil"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+)
+
+const (
+	// logIndexThrottling is the time to wait between processing two consecutive
+	// log index sections, preventing disk overload while indexing the history.
+	logIndexThrottling = 100 * time.Millisecond
+)
+
+// LogIndexer implements a core.ChainIndexer, building up an index of the blocks
+// holding the logs emitted by each address or carrying each topic, permitting
+// fast filtering of the logs of given contracts or events over long ranges.
+type LogIndexer struct {
+	size    uint64            // section size to generate the log index for
+	db      evrdb.Database    // database instance to write index data and metadata into
+	bits    map[string][]byte // Bitsets of the blocks of the section holding each address or topic
+	section uint64            // Section is the section number being processed currently
+	head    common.Hash       // Head is the hash of the last header processed
+}
+
+// NewLogIndexer returns a chain indexer that generates the log index for the
+// canonical chain.
+func NewLogIndexer(db evrdb.Database, size, confirms uint64) *core.ChainIndexer {
+	backend := &LogIndexer{
+		db:   db,
+		size: size,
+	}
+	table := rawdb.NewTable(db, string(rawdb.LogIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, confirms, logIndexThrottling, "logindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new log index section.
+func (l *LogIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	l.bits, l.section, l.head = make(map[string][]byte), section, common.Hash{}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the addresses and topics
+// of the logs of a new header's block into the index.
+func (l *LogIndexer) Process(ctx context.Context, header *types.Header) error {
+	l.head = header.Hash()
+	if header.Bloom == (types.Bloom{}) {
+		return nil
+	}
+	number := header.Number.Uint64()
+	receipts := rawdb.ReadRawReceipts(l.db, l.head, number)
+	if receipts == nil {
+		// The logs of expired blocks can't be filtered anyway
+		if rawdb.HistoryExpired(l.db, number) {
+			return nil
+		}
+		return fmt.Errorf("receipts of block #%d missing", number)
+	}
+	offset := number - l.section*l.size
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			l.mark(log.Address.Bytes(), offset)
+			for _, topic := range log.Topics {
+				l.mark(topic.Bytes(), offset)
+			}
+		}
+	}
+	return nil
+}
+
+// mark sets the bit of the block at the given offset within the section in the
+// bitset of the given address or topic.
+func (l *LogIndexer) mark(value []byte, offset uint64) {
+	bits, ok := l.bits[string(value)]
+	if !ok {
+		bits = make([]byte, l.size/8)
+		l.bits[string(value)] = bits
+	}
+	bits[offset/8] |= 1 << (7 - offset%8)
+}
+
+// Commit implements core.ChainIndexerBackend, finalizing the log index section
+// and writing it out into the database.
+func (l *LogIndexer) Commit() error {
+	batch := l.db.NewBatch()
+	for value, bits := range l.bits {
+		rawdb.WriteLogIndexBits(batch, []byte(value), l.section, l.head, bitutil.CompressBytes(bits))
+		if batch.ValueSize() >= evrdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
@@ -0,0 +1,68 @@
+package evr
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/bitutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestLogIndexer(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		size    = uint64(64)
+		addr    = common.Address{0x01}
+		topic   = common.Hash{0x02}
+		genesis = core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	)
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, int(2*size), func(i int, gen *core.BlockGen) {
+		if i%10 == 0 {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}}}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.Address{}, big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	// Index the second section, whose blocks are numbered from size
+	indexer := &LogIndexer{db: db, size: size}
+	if err := indexer.Reset(context.Background(), 1, common.Hash{}); err != nil {
+		t.Fatalf("failed to reset indexer: %v", err)
+	}
+	for number := size; number < 2*size; number++ {
+		if err := indexer.Process(context.Background(), chain[number-1].Header()); err != nil {
+			t.Fatalf("failed to process block %d: %v", number, err)
+		}
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatalf("failed to commit section: %v", err)
+	}
+	head := chain[2*size-2].Hash()
+	for _, value := range [][]byte{addr.Bytes(), topic.Bytes()} {
+		bits, err := bitutil.DecompressBytes(rawdb.ReadLogIndexBits(db, value, 1, head), int(size/8))
+		if err != nil {
+			t.Fatalf("failed to read index of %x: %v", value, err)
+		}
+		for number := size; number < 2*size; number++ {
+			offset := number - size
+			have := bits[offset/8]&(1<<(7-offset%8)) != 0
+			if want := (number-1)%10 == 0; have != want {
+				t.Errorf("index of %x: block %d mismatch: have %v, want %v", value, number, have, want)
+			}
+		}
+	}
+	if bits := rawdb.ReadLogIndexBits(db, common.Address{0xff}.Bytes(), 1, head); bits != nil {
+		t.Errorf("index of unknown address present")
+	}
+}
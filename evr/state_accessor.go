@@ -0,0 +1,215 @@
+package evr
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/trie"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// regenStateCacheSize is the number of regenerated historical states to keep
+	// around, sparing repeated queries of the same blocks the re-execution.
+	regenStateCacheSize = 8
+
+	// regenStateCacheMemory is the memory the in-memory trie databases of the
+	// cached regenerated states may hold at most.
+	regenStateCacheMemory = 256 * 1024 * 1024
+)
+
+// regenCache keeps recently regenerated historical states, bounded in number and
+// in the memory of their trie databases. It also tracks the regenerations in
+// flight, so that concurrent requests of a block wait for a single one.
+type regenCache struct {
+	states  *lru.Cache                    // Regenerated states by block hash
+	size    common.StorageSize            // Memory held by the trie databases of the cached states
+	limit   common.StorageSize            // Memory the cached states may hold at most
+	pending map[common.Hash]chan struct{} // Regenerations in flight, closed once done
+	lock    sync.Mutex
+}
+
+// regenState is a cached regenerated state, with the memory its trie database held.
+type regenState struct {
+	statedb *state.StateDB
+	size    common.StorageSize
+}
+
+// newRegenCache creates a cache of at most count regenerated states, holding
+// at most limit memory.
+func newRegenCache(count int, limit common.StorageSize) *regenCache {
+	c := &regenCache{
+		limit:   limit,
+		pending: make(map[common.Hash]chan struct{}),
+	}
+	// Evicted states are not dereferenced from their trie databases, as copies of
+	// them may still be in use. A database is released along with its last copy.
+	c.states, _ = lru.NewWithEvict(count, func(key, value interface{}) {
+		c.size -= value.(*regenState).size
+	})
+	return c
+}
+
+// get returns a copy of the cached state of a block.
+func (c *regenCache) get(hash common.Hash) (*state.StateDB, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if cached, ok := c.states.Get(hash); ok {
+		return cached.(*regenState).statedb.Copy(), true
+	}
+	return nil, false
+}
+
+// reserve returns a copy of the cached state of a block if any. Otherwise it
+// returns a channel closed once the regeneration of the block in flight is done,
+// or nil if there is none, registering the one of the caller which must release
+// it once done.
+func (c *regenCache) reserve(hash common.Hash) (*state.StateDB, chan struct{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if cached, ok := c.states.Get(hash); ok {
+		return cached.(*regenState).statedb.Copy(), nil
+	}
+	if done, ok := c.pending[hash]; ok {
+		return nil, done
+	}
+	c.pending[hash] = make(chan struct{})
+	return nil, nil
+}
+
+// release marks the regeneration of a block done, waking up the callers waiting
+// for it.
+func (c *regenCache) release(hash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	close(c.pending[hash])
+	delete(c.pending, hash)
+}
+
+// add caches the regenerated state of a block, evicting the least recently used
+// states beyond the memory limit.
+func (c *regenCache) add(hash common.Hash, statedb *state.StateDB) {
+	nodes, preimages := statedb.Database().TrieDB().Size()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.states.Contains(hash) {
+		return
+	}
+	c.size += nodes + preimages
+	c.states.Add(hash, &regenState{statedb: statedb.Copy(), size: nodes + preimages})
+	for c.size > c.limit && c.states.Len() > 1 {
+		c.states.RemoveOldest()
+	}
+}
+
+// stateAtBlock retrieves the state database associated with a certain block,
+// regenerating it if needed, see regenerateState.
+func (s *Evrynet) stateAtBlock(block *types.Block, reexec uint64) (*state.StateDB, error) {
+	return s.regenerateState(s.regenStates, block, reexec)
+}
+
+// regenerateState retrieves the state database associated with a certain block.
+// If no state is locally available for the given block, up to reexec blocks are
+// re-executed on top of the nearest persisted or previously regenerated state
+// to generate the desired one. Regenerated states are kept in the given cache,
+// the callers get their own copies to modify. Concurrent requests of the same
+// block wait for a single regeneration.
+func (s *Evrynet) regenerateState(cache *regenCache, block *types.Block, reexec uint64) (*state.StateDB, error) {
+	// If we have the state fully available, use that
+	statedb, err := s.blockchain.StateAt(block.Root())
+	if err == nil {
+		return statedb, nil
+	}
+	hash := block.Hash()
+	for {
+		cached, done := cache.reserve(hash)
+		if cached != nil {
+			return cached, nil
+		}
+		if done == nil {
+			break
+		}
+		<-done
+	}
+	defer cache.release(hash)
+
+	// Otherwise try to reexec blocks until we find a state or reach our limit,
+	// tracking the hashes of the blocks to reexec to stay on the block's chain
+	var (
+		origin   = block.NumberU64()
+		hashes   = []common.Hash{block.Hash()}
+		database = state.NewDatabaseWithCache(s.ChainDb(), 16)
+	)
+	for i := uint64(0); i < reexec; i++ {
+		if block = s.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1); block == nil {
+			break
+		}
+		if cached, ok := cache.get(block.Hash()); ok {
+			statedb, err = cached, nil
+			break
+		}
+		if statedb, err = state.New(block.Root(), database); err == nil {
+			break
+		}
+		hashes = append(hashes, block.Hash())
+	}
+	if err != nil {
+		switch err.(type) {
+		case *trie.MissingNodeError:
+			return nil, fmt.Errorf("required historical state unavailable (reexec=%d)", reexec)
+		default:
+			return nil, err
+		}
+	}
+	// State was available at historical point, regenerate
+	var (
+		start  = time.Now()
+		logged time.Time
+		proot  common.Hash
+	)
+	for block.NumberU64() < origin {
+		// Print progress logs if long enough time elapsed
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Regenerating historical state", "block", block.NumberU64()+1, "target", origin, "remaining", origin-block.NumberU64()-1, "elapsed", time.Since(start))
+			logged = time.Now()
+		}
+		// Retrieve the next block to regenerate and process it
+		next, hash := block.NumberU64()+1, hashes[origin-block.NumberU64()-1]
+		if block = s.blockchain.GetBlock(hash, next); block == nil {
+			return nil, fmt.Errorf("block #%d not found", next)
+		}
+		_, _, _, err := s.blockchain.Processor().Process(block, statedb, vm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("processing block %d failed: %v", block.NumberU64(), err)
+		}
+		// Finalize the state so any modifications are written to the trie
+		root, err := statedb.Commit(s.blockchain.Config().IsEIP158(block.Number()))
+		if err != nil {
+			return nil, err
+		}
+		if err := statedb.Reset(root); err != nil {
+			return nil, fmt.Errorf("state reset after block %d failed: %v", block.NumberU64(), err)
+		}
+		statedb.Database().TrieDB().Reference(root, common.Hash{})
+		if proot != (common.Hash{}) {
+			statedb.Database().TrieDB().Dereference(proot)
+		}
+		proot = root
+	}
+	nodes, imgs := statedb.Database().TrieDB().Size()
+	log.Info("Historical state regenerated", "block", block.NumberU64(), "elapsed", time.Since(start), "nodes", nodes, "preimages", imgs)
+
+	cache.add(block.Hash(), statedb)
+	return statedb, nil
+}
@@ -0,0 +1,124 @@
+package evr
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestStateAtBlock(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}}}
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+		db      = rawdb.NewMemoryDatabase()
+		gendb   = rawdb.NewMemoryDatabase()
+	)
+	gspec.MustCommit(db)
+	blocks, _ := core.GenerateChain(gspec.Config, gspec.MustCommit(gendb), ethash.NewFaker(), gendb, 10, func(i int, gen *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{byte(i)}, big.NewInt(1), params.TxGas, big.NewInt(params.GasPriceConfig), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	// Import the chain into a pruning node, persisting only the states of the
+	// genesis and the last blocks on shutdown
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	chain, _ = core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	if _, err := chain.StateAt(blocks[4].Root()); err == nil {
+		t.Fatalf("historical state present")
+	}
+	evr := &Evrynet{blockchain: chain, chainDb: db, regenStates: newRegenCache(regenStateCacheSize, regenStateCacheMemory)}
+
+	// The budget must cover the blocks since the nearest persisted state
+	if _, err := evr.stateAtBlock(blocks[4], 4); err == nil {
+		t.Fatalf("state regenerated beyond the reexec budget")
+	}
+	statedb, err := evr.stateAtBlock(blocks[4], 5)
+	if err != nil {
+		t.Fatalf("failed to regenerate state: %v", err)
+	}
+	if root := statedb.IntermediateRoot(true); root != blocks[4].Root() {
+		t.Fatalf("regenerated state root mismatch: have %x, want %x", root, blocks[4].Root())
+	}
+	if nonce := statedb.GetNonce(address); nonce != 5 {
+		t.Errorf("regenerated nonce mismatch: have %d, want 5", nonce)
+	}
+	// Modifications of served states don't leak into the cache
+	statedb.SetNonce(address, 100)
+	if statedb, err = evr.stateAtBlock(blocks[4], 0); err != nil {
+		t.Fatalf("cached state unavailable: %v", err)
+	}
+	if nonce := statedb.GetNonce(address); nonce != 5 {
+		t.Errorf("cached nonce mismatch: have %d, want 5", nonce)
+	}
+	// Regenerated states serve as the base of later ones
+	if statedb, err = evr.stateAtBlock(blocks[6], 2); err != nil {
+		t.Fatalf("failed to regenerate state from cache: %v", err)
+	}
+	if root := statedb.IntermediateRoot(true); root != blocks[6].Root() {
+		t.Fatalf("regenerated state root mismatch: have %x, want %x", root, blocks[6].Root())
+	}
+	// States beyond the memory limit are evicted, keeping the last one
+	evr.regenStates = newRegenCache(regenStateCacheSize, 1)
+	for _, block := range blocks[4:7] {
+		if _, err := evr.stateAtBlock(block, 7); err != nil {
+			t.Fatalf("failed to regenerate state: %v", err)
+		}
+	}
+	if n := evr.regenStates.states.Len(); n != 1 || !evr.regenStates.states.Contains(blocks[6].Hash()) {
+		t.Fatalf("cached states mismatch: have %d, want the last one", n)
+	}
+}
+
+func TestRegenCacheReserve(t *testing.T) {
+	var (
+		cache = newRegenCache(regenStateCacheSize, regenStateCacheMemory)
+		hash  = common.HexToHash("0x01")
+	)
+	// The first request regenerates the state, the next ones wait for it
+	if cached, done := cache.reserve(hash); cached != nil || done != nil {
+		t.Fatalf("regeneration not reserved")
+	}
+	cached, done := cache.reserve(hash)
+	if cached != nil || done == nil {
+		t.Fatalf("regeneration in flight not awaited")
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.SetNonce(common.HexToAddress("0x02"), 1)
+	cache.add(hash, statedb)
+	cache.release(hash)
+
+	select {
+	case <-done:
+	default:
+		t.Fatalf("waiting requests not released")
+	}
+	if cached, done = cache.reserve(hash); cached == nil || done != nil {
+		t.Fatalf("regenerated state not served")
+	}
+	if nonce := cached.GetNonce(common.HexToAddress("0x02")); nonce != 1 {
+		t.Errorf("cached nonce mismatch: have %d, want 1", nonce)
+	}
+	if len(cache.pending) != 0 {
+		t.Errorf("regenerations left in flight: %d", len(cache.pending))
+	}
+}
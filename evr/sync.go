@@ -178,7 +178,7 @@ func (pm *ProtocolManager) synchronise(peer *Peer) {
 	mode := downloader.FullSync
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
 		// Fast sync was explicitly requested, and explicitly granted
-		mode = downloader.FastSync
+		mode = pm.fastSyncMode
 	} else if currentBlock.NumberU64() == 0 && pm.blockchain.CurrentFastBlock().NumberU64() > 0 {
 		// The database seems empty as the current block is the genesis. Yet the fast
 		// block is ahead, so fast sync was enabled for this node at a certain point.
@@ -186,9 +186,9 @@ func (pm *ProtocolManager) synchronise(peer *Peer) {
 		// bad block) rolled back a fast sync node below the sync point. In this case
 		// however it's safe to reenable fast sync.
 		atomic.StoreUint32(&pm.fastSync, 1)
-		mode = downloader.FastSync
+		mode = pm.fastSyncMode
 	}
-	if mode == downloader.FastSync {
+	if mode != downloader.FullSync {
 		// Make sure the Peer's total difficulty we are synchronizing is higher.
 		if pm.blockchain.GetTdByHash(pm.blockchain.CurrentFastBlock().Hash()).Cmp(pTd) >= 0 {
 			return
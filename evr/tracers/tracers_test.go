@@ -175,7 +175,11 @@ func TestPrestateTracerCreate2(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create call tracer: %v", err)
 	}
-	evm := vm.NewEVM(context, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	// The transaction pays the gas price of the network it was signed for
+	config := *params.MainnetChainConfig
+	config.GasPrice = tx.GasPrice()
+
+	evm := vm.NewEVM(context, statedb, &config, vm.Config{Debug: true, Tracer: tracer})
 
 	msg, err := tx.AsMessage(signer)
 	if err != nil {
@@ -28,7 +28,6 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
-	"github.com/Evrynetlabs/evrynet-node/rlp"
 	"github.com/Evrynetlabs/evrynet-node/rpc"
 )
 
@@ -389,6 +388,99 @@ func (ec *Client) BalanceAt(ctx context.Context, account common.Address, blockNu
 	return (*big.Int)(&result), err
 }
 
+// OwnerAt returns the owner of the given account, nil if the account is not an enterprise contract.
+// The block number can be nil, in which case the owner is taken from the latest known block.
+func (ec *Client) OwnerAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*common.Address, error) {
+	var result *common.Address
+	err := ec.c.CallContext(ctx, &result, "eth_getOwner", account, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// ProvidersAt returns the providers of the given account, one of which must co-sign transactions to it.
+// The block number can be nil, in which case the providers are taken from the latest known block.
+func (ec *Client) ProvidersAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]common.Address, error) {
+	var result []common.Address
+	err := ec.c.CallContext(ctx, &result, "eth_getProviders", account, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// AccountResult is the Merkle proof of an account, along with the proofs of some
+// of its storage slots.
+type AccountResult struct {
+	Address      common.Address
+	AccountProof [][]byte
+	Balance      *big.Int
+	CodeHash     common.Hash
+	Nonce        uint64
+	StorageHash  common.Hash
+	Owner        *common.Address
+	Providers    []common.Address
+	StorageProof []StorageResult
+}
+
+// StorageResult is the Merkle proof of a storage slot in the account storage trie.
+type StorageResult struct {
+	Key   common.Hash
+	Value *big.Int
+	Proof [][]byte
+}
+
+// GetProof returns the Merkle proof of the given account and storage keys, to be
+// verified against the state root of the block with the light package.
+// The block number can be nil, in which case the proof is taken from the latest known block.
+func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountResult, error) {
+	type storageResult struct {
+		Key   string          `json:"key"`
+		Value *hexutil.Big    `json:"value"`
+		Proof []hexutil.Bytes `json:"proof"`
+	}
+	type accountResult struct {
+		Address      common.Address   `json:"address"`
+		AccountProof []hexutil.Bytes  `json:"accountProof"`
+		Balance      *hexutil.Big     `json:"balance"`
+		CodeHash     common.Hash      `json:"codeHash"`
+		Nonce        hexutil.Uint64   `json:"nonce"`
+		StorageHash  common.Hash      `json:"storageHash"`
+		Owner        *common.Address  `json:"owner"`
+		Providers    []common.Address `json:"providers"`
+		StorageProof []storageResult  `json:"storageProof"`
+	}
+	hexKeys := make([]string, len(keys))
+	for i, key := range keys {
+		hexKeys[i] = key.Hex()
+	}
+	var res accountResult
+	if err := ec.c.CallContext(ctx, &res, "eth_getProof", account, hexKeys, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	result := &AccountResult{
+		Address:      res.Address,
+		AccountProof: toByteSlices(res.AccountProof),
+		Balance:      (*big.Int)(res.Balance),
+		CodeHash:     res.CodeHash,
+		Nonce:        uint64(res.Nonce),
+		StorageHash:  res.StorageHash,
+		Owner:        res.Owner,
+		Providers:    res.Providers,
+	}
+	for _, slot := range res.StorageProof {
+		result.StorageProof = append(result.StorageProof, StorageResult{
+			Key:   common.HexToHash(slot.Key),
+			Value: (*big.Int)(slot.Value),
+			Proof: toByteSlices(slot.Proof),
+		})
+	}
+	return result, nil
+}
+
+func toByteSlices(list []hexutil.Bytes) [][]byte {
+	res := make([][]byte, len(list))
+	for i, b := range list {
+		res[i] = b
+	}
+	return res
+}
+
 // StorageAt returns the value of key in the contract storage of the given account.
 // The block number can be nil, in which case the value is taken from the latest known block.
 func (ec *Client) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
@@ -562,7 +654,7 @@ func (ec *Client) SendTx(ctx context.Context, args ethereum.SendTxArgs) (common.
 // If the transaction was a contract creation use the TransactionReceipt method to get the
 // contract address after the transaction has been mined.
 func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return err
 	}
@@ -575,7 +667,7 @@ func (ec *Client) ProviderSignTx(ctx context.Context, tx *types.Transaction, pro
 	if providerAddr == nil {
 		return nil, errors.New("Providers address is required")
 	}
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -30,9 +30,11 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/evr"
+	"github.com/Evrynetlabs/evrynet-node/light"
 	"github.com/Evrynetlabs/evrynet-node/node"
 	"github.com/Evrynetlabs/evrynet-node/params"
 )
@@ -303,6 +305,74 @@ func TestBalanceAt(t *testing.T) {
 	}
 }
 
+func TestOwnerAndProvidersAt(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+
+	ec := NewClient(client)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	owner, err := ec.OwnerAt(ctx, testAddr, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("OwnerAt(%x) error = %q", testAddr, err)
+	}
+	if owner != nil {
+		t.Fatalf("OwnerAt(%x) = %x, want nil", testAddr, *owner)
+	}
+	providers, err := ec.ProvidersAt(ctx, testAddr, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("ProvidersAt(%x) error = %q", testAddr, err)
+	}
+	if len(providers) != 0 {
+		t.Fatalf("ProvidersAt(%x) = %v, want none", testAddr, providers)
+	}
+	if _, err := ec.ProvidersAt(ctx, testAddr, big.NewInt(1000000000)); err == nil {
+		t.Fatal("ProvidersAt on future block should fail")
+	}
+}
+
+func TestGetProof(t *testing.T) {
+	backend, chain := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+
+	ec := NewClient(client)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	result, err := ec.GetProof(ctx, testAddr, []common.Hash{{0x01}}, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("GetProof(%x) error = %q", testAddr, err)
+	}
+	if result.Balance.Cmp(testBalance) != 0 {
+		t.Fatalf("GetProof(%x) balance = %v, want %v", testAddr, result.Balance, testBalance)
+	}
+	account := &state.Account{
+		Nonce:    result.Nonce,
+		Balance:  result.Balance,
+		Root:     result.StorageHash,
+		CodeHash: result.CodeHash[:],
+	}
+	if err := light.VerifyAccountProof(chain[1].Root(), testAddr, account, result.AccountProof); err != nil {
+		t.Fatalf("account proof verification failed: %v", err)
+	}
+	account.OwnerAddress = &common.Address{0x01}
+	if err := light.VerifyAccountProof(chain[1].Root(), testAddr, account, result.AccountProof); err != light.ErrAccountMismatch {
+		t.Fatalf("verification with forged owner = %v, want %v", err, light.ErrAccountMismatch)
+	}
+	if len(result.StorageProof) != 1 || result.StorageProof[0].Key != (common.Hash{0x01}) {
+		t.Fatalf("GetProof(%x) storage = %v, want one slot", testAddr, result.StorageProof)
+	}
+	slot := result.StorageProof[0]
+	if err := light.VerifyStorageProof(result.StorageHash, slot.Key, common.BigToHash(slot.Value), slot.Proof); err != nil {
+		t.Fatalf("storage proof verification failed: %v", err)
+	}
+}
+
 func TestTransactionInBlockInterrupted(t *testing.T) {
 	backend, _ := newTestBackend(t)
 	client, _ := backend.Attach()
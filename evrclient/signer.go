@@ -58,6 +58,9 @@ func (s *senderFromServer) Provider(tx *types.Transaction) (common.Address, erro
 func (s *senderFromServer) Hash(tx *types.Transaction) common.Hash {
 	panic("can't sign with senderFromServer")
 }
+func (s *senderFromServer) ProviderHash(tx *types.Transaction) common.Hash {
+	panic("can't sign with senderFromServer")
+}
 func (s *senderFromServer) SignatureValues(tx *types.Transaction, sig []byte) (R, S, V *big.Int, err error) {
 	panic("can't sign with senderFromServer")
 }
@@ -0,0 +1,359 @@
+// +build !js
+
+// Package boltdb implements the key-value database layer based on bbolt, an
+// embedded B+tree store keeping the whole database in a single memory mapped
+// file.
+package boltdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// FileName is the name of the database file within a database directory.
+	FileName = "bolt.db"
+
+	// openTimeout is the time to wait for the file lock of a database used by
+	// another process before failing.
+	openTimeout = time.Second
+
+	// iteratorChunk is the number of entries an iterator loads per read
+	// transaction. Iterators don't keep transactions open, as bbolt can't grow
+	// the file while any is, deadlocking writes done during an iteration.
+	iteratorChunk = 1024
+)
+
+var (
+	// errNotFound is returned if a key is requested that is not found in the
+	// database.
+	errNotFound = errors.New("not found")
+
+	// bucket is the single bucket all the entries are stored in.
+	bucket = []byte("evrdb")
+)
+
+// Database is a persistent key-value store. Apart from basic data storage
+// functionality it also supports batch writes and iterating over the keyspace in
+// binary-alphabetical order.
+type Database struct {
+	fn string   // filename for reporting
+	db *bolt.DB // bbolt instance
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// New returns a wrapped bbolt object, creating the database file if it doesn't
+// exist yet.
+func New(file string) (*Database, error) {
+	logger := log.New("database", file)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(file, 0600, &bolt.Options{
+		Timeout:        openTimeout,
+		NoFreelistSync: true,
+		FreelistType:   bolt.FreelistMapType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	logger.Info("Opened bbolt database")
+
+	return &Database{
+		fn:  file,
+		db:  db,
+		log: logger,
+	}, nil
+}
+
+// Close flushes any pending data to disk and closes all io accesses to the
+// underlying key-value store.
+func (db *Database) Close() error {
+	return db.db.Close()
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *Database) Has(key []byte) (bool, error) {
+	var found bool
+	err := db.db.View(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(bucket).Cursor().Seek(key)
+		found = k != nil && bytes.Equal(k, key)
+		return nil
+	})
+	return found, err
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *Database) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := db.db.View(func(tx *bolt.Tx) error {
+		k, v := tx.Bucket(bucket).Cursor().Seek(key)
+		if k == nil || !bytes.Equal(k, key) {
+			return errNotFound
+		}
+		// Values are only valid during the transaction, and empty ones may be nil
+		value = append([]byte{}, v...)
+		return nil
+	})
+	return value, err
+}
+
+// Put inserts the given value into the key-value store.
+func (db *Database) Put(key []byte, value []byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, value)
+	})
+}
+
+// Delete removes the key from the key-value store.
+func (db *Database) Delete(key []byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to its host
+// database until a final write is called.
+func (db *Database) NewBatch() evrdb.Batch {
+	return &batch{
+		db: db.db,
+	}
+}
+
+// NewIterator creates a binary-alphabetical iterator over the entire keyspace
+// contained within the bbolt database.
+func (db *Database) NewIterator() evrdb.Iterator {
+	return db.NewIteratorWithStart(nil)
+}
+
+// NewIteratorWithStart creates a binary-alphabetical iterator over a subset of
+// database content starting at a particular initial key (or after, if it does
+// not exist).
+func (db *Database) NewIteratorWithStart(start []byte) evrdb.Iterator {
+	return &iterator{
+		db:   db.db,
+		next: append([]byte{}, start...),
+		pos:  -1,
+	}
+}
+
+// NewIteratorWithPrefix creates a binary-alphabetical iterator over a subset
+// of database content with a particular key prefix.
+func (db *Database) NewIteratorWithPrefix(prefix []byte) evrdb.Iterator {
+	return &iterator{
+		db:     db.db,
+		prefix: common.CopyBytes(prefix),
+		next:   append([]byte{}, prefix...),
+		pos:    -1,
+	}
+}
+
+// Stat returns the internal stats of the database. As bbolt has a single set of
+// stats, the requested property is ignored.
+func (db *Database) Stat(property string) (string, error) {
+	var buckets bolt.BucketStats
+	err := db.db.View(func(tx *bolt.Tx) error {
+		buckets = tx.Bucket(bucket).Stats()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	stats := db.db.Stats()
+
+	var size int64
+	if info, err := os.Stat(db.fn); err == nil {
+		size = info.Size()
+	}
+	return fmt.Sprintf("Entries: %d\nDepth: %d\nFile size: %v\nFree pages: %d\nPending pages: %d\nRead transactions: %d\nWrite transactions: %d\nWrite time: %v\n",
+		buckets.KeyN, buckets.Depth, common.StorageSize(size), stats.FreePageN, stats.PendingPageN,
+		stats.TxN, stats.TxStats.Write, stats.TxStats.WriteTime), nil
+}
+
+// Compact is a no-op on a bbolt database, which reuses the pages freed by
+// deletions and overwrites instead of keeping stale versions around.
+func (db *Database) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// Path returns the path to the database file.
+func (db *Database) Path() string {
+	return db.fn
+}
+
+// keyvalue is a key-value tuple tagged with a deletion field to allow creating
+// bbolt write batches.
+type keyvalue struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// batch is a write-only bbolt batch that commits changes to its host database
+// in a single transaction when Write is called. A batch cannot be used
+// concurrently.
+type batch struct {
+	db     *bolt.DB
+	writes []keyvalue
+	size   int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.writes = append(b.writes, keyvalue{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+// Delete inserts the a key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.writes = append(b.writes, keyvalue{common.CopyBytes(key), nil, true})
+	b.size += 1
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to disk.
+func (b *batch) Write() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucket)
+		for _, keyvalue := range b.writes {
+			if keyvalue.delete {
+				if err := bucket.Delete(keyvalue.key); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := bucket.Put(keyvalue.key, keyvalue.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w evrdb.KeyValueWriter) error {
+	for _, keyvalue := range b.writes {
+		if keyvalue.delete {
+			if err := w.Delete(keyvalue.key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(keyvalue.key, keyvalue.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterator can walk over the (potentially partial) keyspace of a bbolt
+// database. Entries are loaded in chunks, each from its own read transaction,
+// so unlike a LevelDB iterator it isn't a snapshot of the database, but it
+// observes the writes done after the current chunk was loaded.
+type iterator struct {
+	db     *bolt.DB
+	prefix []byte // Prefix all the iterated keys share
+	next   []byte // Key to load the next chunk from, nil if exhausted
+
+	keys   [][]byte // Keys of the current chunk
+	values [][]byte // Values of the current chunk
+	pos    int      // Position of the iterator within the current chunk
+	err    error    // Error of the last chunk loading, if any
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	it.pos++
+	if it.pos >= len(it.keys) && it.next != nil && it.err == nil {
+		it.load()
+	}
+	return it.pos < len(it.keys)
+}
+
+// load reads the next chunk of entries from the database.
+func (it *iterator) load() {
+	it.keys, it.values, it.pos = nil, nil, 0
+
+	it.err = it.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+
+		k, v := c.Seek(it.next)
+		for ; k != nil && len(it.keys) < iteratorChunk; k, v = c.Next() {
+			if !bytes.HasPrefix(k, it.prefix) {
+				k = nil
+				break
+			}
+			it.keys = append(it.keys, common.CopyBytes(k))
+			it.values = append(it.values, append([]byte{}, v...))
+		}
+		it.next = common.CopyBytes(k)
+		return nil
+	})
+	if it.err != nil {
+		it.keys, it.values, it.next = nil, nil, nil
+	}
+}
+
+// Error returns any accumulated error. Exhausting all the key/value pairs
+// is not considered to be an error.
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done. The caller
+// should not modify the contents of the returned slice, and its contents may
+// change on the next call to Next.
+func (it *iterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.pos]
+}
+
+// Value returns the value of the current key/value pair, or nil if done. The
+// caller should not modify the contents of the returned slice, and its contents
+// may change on the next call to Next.
+func (it *iterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.values) {
+		return nil
+	}
+	return it.values[it.pos]
+}
+
+// Release releases associated resources. Release should always succeed and can
+// be called multiple times without causing error.
+func (it *iterator) Release() {
+	it.keys, it.values, it.next = nil, nil, nil
+}
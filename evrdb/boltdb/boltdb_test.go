@@ -0,0 +1,33 @@
+// +build !js
+
+package boltdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb/dbtest"
+)
+
+// Tests that the bbolt database passes the key-value store conformance suite.
+func TestBoltDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boltdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var count int
+	dbtest.TestDatabaseSuite(t, func() evrdb.KeyValueStore {
+		count++
+		db, err := New(filepath.Join(dir, fmt.Sprintf("db-%d", count), FileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
@@ -92,6 +92,10 @@ type AncientWriter interface {
 	// TruncateAncients discards all but the first n ancient data from the ancient store.
 	TruncateAncients(n uint64) error
 
+	// ExpireAncients discards the bodies and receipts of the ancient blocks below
+	// n, keeping their headers, hashes and total difficulties.
+	ExpireAncients(n uint64) error
+
 	// Sync flushes all in-memory ancient store data to disk.
 	Sync() error
 }
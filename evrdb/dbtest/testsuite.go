@@ -0,0 +1,340 @@
+// Package dbtest implements the conformance tests every key-value store backing
+// the database must pass.
+package dbtest
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+)
+
+// TestDatabaseSuite runs a suite of tests against a key-value database
+// implementation. New is called to create a fresh, empty database for each test.
+func TestDatabaseSuite(t *testing.T, New func() evrdb.KeyValueStore) {
+	t.Run("KeyValueOperations", func(t *testing.T) { testKeyValueOperations(t, New) })
+	t.Run("Iterator", func(t *testing.T) { testIterator(t, New) })
+	t.Run("IteratorLarge", func(t *testing.T) { testIteratorLarge(t, New) })
+	t.Run("IteratorWrites", func(t *testing.T) { testIteratorWrites(t, New) })
+	t.Run("Batch", func(t *testing.T) { testBatch(t, New) })
+	t.Run("BatchReplay", func(t *testing.T) { testBatchReplay(t, New) })
+	t.Run("Close", func(t *testing.T) { testClose(t, New) })
+}
+
+// testKeyValueOperations tests the single key reads and writes.
+func testKeyValueOperations(t *testing.T, New func() evrdb.KeyValueStore) {
+	db := New()
+	defer db.Close()
+
+	key := []byte("key")
+	if ok, err := db.Has(key); err != nil || ok {
+		t.Fatalf("missing key: has %v, err %v", ok, err)
+	}
+	if _, err := db.Get(key); err == nil {
+		t.Fatalf("missing key retrieved")
+	}
+	for _, value := range [][]byte{[]byte("value"), []byte("overwritten"), {}} {
+		if err := db.Put(key, value); err != nil {
+			t.Fatalf("failed to put %q: %v", value, err)
+		}
+		if ok, err := db.Has(key); err != nil || !ok {
+			t.Fatalf("stored key: has %v, err %v", ok, err)
+		}
+		have, err := db.Get(key)
+		if err != nil {
+			t.Fatalf("failed to get %q: %v", value, err)
+		}
+		if !bytes.Equal(have, value) {
+			t.Fatalf("value mismatch: have %q, want %q", have, value)
+		}
+	}
+	// Returned values must not alias the stored ones
+	if err := db.Put(key, []byte("value")); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	have, _ := db.Get(key)
+	have[0] = 'x'
+	if have, _ := db.Get(key); !bytes.Equal(have, []byte("value")) {
+		t.Fatalf("stored value modified through a retrieved one: %q", have)
+	}
+	if err := db.Delete(key); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if ok, err := db.Has(key); err != nil || ok {
+		t.Fatalf("deleted key: has %v, err %v", ok, err)
+	}
+	if _, err := db.Get(key); err == nil {
+		t.Fatalf("deleted key retrieved")
+	}
+	// Deleting missing keys isn't an error
+	if err := db.Delete(key); err != nil {
+		t.Fatalf("failed to delete missing key: %v", err)
+	}
+}
+
+// testIterator tests the iteration of the keyspace from a start key and with a
+// prefix.
+func testIterator(t *testing.T, New func() evrdb.KeyValueStore) {
+	content := map[string]string{
+		"ka1": "va1", "ka5": "va5", "ka2": "va2", "ka4": "va4", "ka3": "va3",
+		"kb1": "vb1", "kb5": "vb5", "kb2": "vb2", "kb4": "vb4", "kb3": "vb3",
+		"l": "vl",
+	}
+	tests := []struct {
+		content map[string]string
+		start   string
+		prefix  string
+		order   []string
+	}{
+		// Empty databases should be iterable
+		{map[string]string{}, "", "", nil},
+		{map[string]string{}, "non-existent-start", "", nil},
+		{map[string]string{}, "", "non-existent-prefix", nil},
+
+		// Single-item databases should be iterable
+		{map[string]string{"key": "val"}, "", "", []string{"key"}},
+		{map[string]string{"key": "val"}, "k", "", []string{"key"}},
+		{map[string]string{"key": "val"}, "key", "", []string{"key"}},
+		{map[string]string{"key": "val"}, "l", "", nil},
+		{map[string]string{"key": "val"}, "", "k", []string{"key"}},
+		{map[string]string{"key": "val"}, "", "l", nil},
+
+		// Multi-item databases should be iterable from any start
+		{content, "", "", []string{"ka1", "ka2", "ka3", "ka4", "ka5", "kb1", "kb2", "kb3", "kb4", "kb5", "l"}},
+		{content, "ka4", "", []string{"ka4", "ka5", "kb1", "kb2", "kb3", "kb4", "kb5", "l"}},
+		{content, "kb", "", []string{"kb1", "kb2", "kb3", "kb4", "kb5", "l"}},
+		{content, "m", "", nil},
+
+		// Multi-item databases should be prefix-iterable
+		{content, "", "k", []string{"ka1", "ka2", "ka3", "ka4", "ka5", "kb1", "kb2", "kb3", "kb4", "kb5"}},
+		{content, "", "ka", []string{"ka1", "ka2", "ka3", "ka4", "ka5"}},
+		{content, "", "kb", []string{"kb1", "kb2", "kb3", "kb4", "kb5"}},
+		{content, "", "kc", nil},
+	}
+	for i, tt := range tests {
+		db := New()
+		for key, val := range tt.content {
+			if err := db.Put([]byte(key), []byte(val)); err != nil {
+				t.Fatalf("test %d: failed to insert item %s:%s into database: %v", i, key, val, err)
+			}
+		}
+		var it evrdb.Iterator
+		if tt.prefix != "" {
+			it = db.NewIteratorWithPrefix([]byte(tt.prefix))
+		} else {
+			it = db.NewIteratorWithStart([]byte(tt.start))
+		}
+		idx := 0
+		for it.Next() {
+			if idx >= len(tt.order) {
+				t.Errorf("test %d: iteration past the expected items: key %s", i, string(it.Key()))
+				break
+			}
+			if !bytes.Equal(it.Key(), []byte(tt.order[idx])) {
+				t.Errorf("test %d: item %d: key mismatch: have %s, want %s", i, idx, string(it.Key()), tt.order[idx])
+			}
+			if !bytes.Equal(it.Value(), []byte(tt.content[tt.order[idx]])) {
+				t.Errorf("test %d: item %d: value mismatch: have %s, want %s", i, idx, string(it.Value()), tt.content[tt.order[idx]])
+			}
+			idx++
+		}
+		if err := it.Error(); err != nil {
+			t.Errorf("test %d: iteration failed: %v", i, err)
+		}
+		if idx < len(tt.order) {
+			t.Errorf("test %d: iteration terminated prematurely: have %d, want %d", i, idx, len(tt.order))
+		}
+		it.Release()
+		db.Close()
+	}
+}
+
+// testIteratorLarge tests the iteration of more entries than backends may load
+// or buffer at once.
+func testIteratorLarge(t *testing.T, New func() evrdb.KeyValueStore) {
+	db := New()
+	defer db.Close()
+
+	var keys []string
+	batch := db.NewBatch()
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key-%06d", i*7%5000)
+		keys = append(keys, key)
+		batch.Put([]byte(key), []byte(key))
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	sort.Strings(keys)
+
+	it := db.NewIterator()
+	defer it.Release()
+
+	idx := 0
+	for it.Next() {
+		if idx >= len(keys) {
+			t.Fatalf("iteration past the expected items: key %s", it.Key())
+		}
+		if string(it.Key()) != keys[idx] || string(it.Value()) != keys[idx] {
+			t.Fatalf("item %d: have %s:%s, want %s:%s", idx, it.Key(), it.Value(), keys[idx], keys[idx])
+		}
+		idx++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if idx != len(keys) {
+		t.Fatalf("iteration terminated prematurely: have %d, want %d", idx, len(keys))
+	}
+}
+
+// testIteratorWrites tests that the database can be written to while iterated,
+// with the iteration yielding every entry which isn't modified.
+func testIteratorWrites(t *testing.T, New func() evrdb.KeyValueStore) {
+	db := New()
+	defer db.Close()
+
+	for i := 0; i < 3000; i++ {
+		key := []byte(fmt.Sprintf("a-%06d", i))
+		if err := db.Put(key, key); err != nil {
+			t.Fatalf("failed to put %s: %v", key, err)
+		}
+	}
+	it := db.NewIterator()
+	defer it.Release()
+
+	idx := 0
+	for it.Next() {
+		want := fmt.Sprintf("a-%06d", idx)
+		if string(it.Key()) != want {
+			t.Fatalf("item %d: key mismatch: have %s, want %s", idx, it.Key(), want)
+		}
+		// Delete the visited entries and add some after the iterated ones, the
+		// latter may or may not be iterated depending on the backend
+		if err := db.Delete(it.Key()); err != nil {
+			t.Fatalf("failed to delete %s: %v", it.Key(), err)
+		}
+		if err := db.Put([]byte(fmt.Sprintf("b-%06d", idx)), []byte{0x01}); err != nil {
+			t.Fatalf("failed to put: %v", err)
+		}
+		if idx++; idx == 3000 {
+			break
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if idx != 3000 {
+		t.Fatalf("iteration terminated prematurely: have %d, want %d", idx, 3000)
+	}
+	if ok, _ := db.Has([]byte("a-000000")); ok {
+		t.Fatalf("deleted key present")
+	}
+	if ok, _ := db.Has([]byte("b-002999")); !ok {
+		t.Fatalf("written key missing")
+	}
+}
+
+// testBatch tests that batched writes are only applied once written, and that a
+// batch can be reused after a reset.
+func testBatch(t *testing.T, New func() evrdb.KeyValueStore) {
+	db := New()
+	defer db.Close()
+
+	if err := db.Put([]byte("deleted"), []byte("value")); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	batch := db.NewBatch()
+	if batch.ValueSize() != 0 {
+		t.Fatalf("new batch has size %d", batch.ValueSize())
+	}
+	batch.Put([]byte("k1"), []byte("v1"))
+	batch.Put([]byte("k2"), []byte("v2"))
+	batch.Put([]byte("k1"), []byte("v3"))
+	batch.Delete([]byte("deleted"))
+	if batch.ValueSize() == 0 {
+		t.Fatalf("filled batch has no size")
+	}
+	if ok, _ := db.Has([]byte("k1")); ok {
+		t.Fatalf("batched write applied before writing the batch")
+	}
+	if ok, _ := db.Has([]byte("deleted")); !ok {
+		t.Fatalf("batched deletion applied before writing the batch")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	for key, want := range map[string]string{"k1": "v3", "k2": "v2"} {
+		if have, err := db.Get([]byte(key)); err != nil || string(have) != want {
+			t.Fatalf("key %s: have %q (err %v), want %q", key, have, err, want)
+		}
+	}
+	if ok, _ := db.Has([]byte("deleted")); ok {
+		t.Fatalf("batched deletion not applied")
+	}
+	// A reset batch must not rewrite the previous content
+	batch.Reset()
+	if batch.ValueSize() != 0 {
+		t.Fatalf("reset batch has size %d", batch.ValueSize())
+	}
+	if err := db.Delete([]byte("k2")); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	batch.Put([]byte("k3"), []byte("v3"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write reset batch: %v", err)
+	}
+	if ok, _ := db.Has([]byte("k2")); ok {
+		t.Fatalf("reset batch rewrote its previous content")
+	}
+	if ok, _ := db.Has([]byte("k3")); !ok {
+		t.Fatalf("reset batch not written")
+	}
+}
+
+// testBatchReplay tests that a batch replays its writes in order.
+func testBatchReplay(t *testing.T, New func() evrdb.KeyValueStore) {
+	db := New()
+	defer db.Close()
+
+	target := New()
+	defer target.Close()
+
+	batch := db.NewBatch()
+	batch.Put([]byte("k1"), []byte("v1"))
+	batch.Put([]byte("k2"), []byte("v2"))
+	batch.Delete([]byte("k1"))
+	batch.Put([]byte("k2"), []byte("v3"))
+
+	if err := batch.Replay(target); err != nil {
+		t.Fatalf("failed to replay batch: %v", err)
+	}
+	if ok, _ := target.Has([]byte("k1")); ok {
+		t.Fatalf("replayed deletion not applied")
+	}
+	if have, err := target.Get([]byte("k2")); err != nil || string(have) != "v3" {
+		t.Fatalf("replayed key: have %q (err %v), want %q", have, err, "v3")
+	}
+	if ok, _ := db.Has([]byte("k2")); ok {
+		t.Fatalf("replayed batch written to its database")
+	}
+}
+
+// testClose tests that a closed database can't be accessed anymore.
+func testClose(t *testing.T, New func() evrdb.KeyValueStore) {
+	db := New()
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	if _, err := db.Get([]byte("key")); err == nil {
+		t.Fatalf("closed database read")
+	}
+	if err := db.Put([]byte("key"), []byte("value")); err == nil {
+		t.Fatalf("closed database written")
+	}
+}
@@ -0,0 +1,23 @@
+// +build !js
+
+package leveldb
+
+import (
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb/dbtest"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// Tests that the LevelDB database passes the key-value store conformance suite.
+func TestLevelDB(t *testing.T) {
+	dbtest.TestDatabaseSuite(t, func() evrdb.KeyValueStore {
+		db, err := leveldb.Open(storage.NewMemStorage(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Database{db: db}
+	})
+}
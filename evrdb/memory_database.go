@@ -81,6 +81,10 @@ func (db *MemDatabase) TruncateAncients(n uint64) error {
 	panic("implement me")
 }
 
+func (db *MemDatabase) ExpireAncients(n uint64) error {
+	panic("implement me")
+}
+
 func (db *MemDatabase) Sync() error {
 	panic("implement me")
 }
@@ -366,6 +366,10 @@ func (db *Database) TruncateAncients(n uint64) error {
 	panic("implement me")
 }
 
+func (db *Database) ExpireAncients(n uint64) error {
+	panic("implement me")
+}
+
 func (db *Database) Sync() error {
 	panic("implement me")
 }
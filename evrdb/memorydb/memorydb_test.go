@@ -19,8 +19,18 @@ package memorydb
 import (
 	"bytes"
 	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb/dbtest"
 )
 
+// Tests that the memory database passes the key-value store conformance suite.
+func TestMemoryDB(t *testing.T) {
+	dbtest.TestDatabaseSuite(t, func() evrdb.KeyValueStore {
+		return New()
+	})
+}
+
 // Tests that key-value iteration on top of a memory database works.
 func TestMemoryDBIterator(t *testing.T) {
 	tests := []struct {
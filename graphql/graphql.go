@@ -38,7 +38,6 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/node"
 	"github.com/Evrynetlabs/evrynet-node/p2p"
-	"github.com/Evrynetlabs/evrynet-node/rlp"
 	"github.com/Evrynetlabs/evrynet-node/rpc"
 	graphqlgo "github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
@@ -100,6 +99,39 @@ func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash })
 	return state.GetState(a.address, args.Slot), nil
 }
 
+func (a *Account) Owner(ctx context.Context) (*Account, error) {
+	state, err := a.getState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owner := state.GetOwner(a.address)
+	if owner == nil {
+		return nil, nil
+	}
+	return &Account{
+		backend:     a.backend,
+		address:     *owner,
+		blockNumber: a.blockNumber,
+	}, nil
+}
+
+func (a *Account) Providers(ctx context.Context) ([]*Account, error) {
+	state, err := a.getState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	providers := state.GetProviders(a.address)
+	ret := make([]*Account, 0, len(providers))
+	for _, provider := range providers {
+		ret = append(ret, &Account{
+			backend:     a.backend,
+			address:     *provider,
+			blockNumber: a.blockNumber,
+		})
+	}
+	return ret, nil
+}
+
 // Log represents an individual log message. All arguments are mandatory.
 type Log struct {
 	backend     *evr.EvrAPIBackend
@@ -241,6 +273,36 @@ func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account,
 	}, nil
 }
 
+func (t *Transaction) Type(ctx context.Context) (int32, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return 0, err
+	}
+	return int32(tx.Type()), nil
+}
+
+func (t *Transaction) Provider(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+
+	var signer types.Signer = types.FrontierSigner{}
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+	provider, _ := types.Provider(signer, tx)
+	if provider == nil {
+		return nil, nil
+	}
+
+	return &Account{
+		backend:     t.backend,
+		address:     *provider,
+		blockNumber: args.Number(),
+	}, nil
+}
+
 func (t *Transaction) Block(ctx context.Context) (*Block, error) {
 	if _, err := t.resolve(ctx); err != nil {
 		return nil, err
@@ -1038,7 +1100,7 @@ func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Has
 
 func (r *Resolver) SendRawTransaction(ctx context.Context, args struct{ Data hexutil.Bytes }) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(args.Data, tx); err != nil {
+	if err := tx.UnmarshalBinary(args.Data); err != nil {
 		return common.Hash{}, err
 	}
 	hash, err := evrapi.SubmitTransaction(ctx, r.backend, tx)
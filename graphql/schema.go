@@ -52,6 +52,12 @@ const schema string = `
         # Storage provides access to the storage of a contract account, indexed
         # by its 32 byte slot identifier.
         storage(slot: Bytes32!): Bytes32!
+        # Owner is the account allowed to manage the providers of an enterprise
+        # contract, or null if the account has no owner.
+        owner: Account
+        # Providers are the accounts paying the fees of transactions sent to an
+        # enterprise contract. One of them must co-sign such transactions.
+        providers: [Account!]!
     }
 
     # Log is an Evrynet event log.
@@ -73,6 +79,9 @@ const schema string = `
     type Transaction {
         # Hash is the hash of this transaction.
         hash: Bytes32!
+        # Type is the envelope type of this transaction, 0 for legacy
+        # transactions and 1 for sponsored ones.
+        type: Int!
         # Nonce is the nonce of the account this transaction was generated with.
         nonce: Long!
         # Index is the index of this transaction in the parent block. This will
@@ -84,6 +93,9 @@ const schema string = `
         # To is the account the transaction was sent to. This is null for
         # contract-creating transactions.
         to(block: Long): Account
+        # Provider is the account that co-signed this transaction and pays its
+        # gas. This is null for transactions not sponsored by a provider.
+        provider(block: Long): Account
         # Value is the value, in wei, sent along with this transaction.
         value: BigInt!
         # GasPrice is the price offered to miners for gas, in wei per unit.
@@ -0,0 +1,284 @@
+// Package era implements the archive files the expired chain history is exported
+// to. An archive holds the headers, bodies, receipts and total difficulties of a
+// range of consecutive blocks, followed by an index of the blocks.
+//
+// The archives are stored as a list of entries, each made of a header and some
+// data. The header is the type of the entry (2 bytes, little endian), the length
+// of its data (4 bytes, little endian) and 2 reserved zero bytes:
+//
+//   archive    = Version | block* | BlockIndex
+//   block      = Header | Body | Receipts | TotalDifficulty
+//   BlockIndex = start-number | offset* | count
+//
+// Headers, bodies and receipts hold snappy compressed RLP, receipts being in
+// their storage form. Total difficulties are RLP encoded. The index offsets are
+// the positions of the headers of the blocks, relative to the index entry. All
+// the index fields are 8 bytes, little endian.
+package era
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/golang/snappy"
+)
+
+// MaxEraBlocks is the maximum number of blocks in an archive, whose block range
+// is aligned on a multiple of it.
+const MaxEraBlocks = 8192
+
+// Types of the archive entries.
+const (
+	typeVersion         uint16 = 0x3265
+	typeHeader          uint16 = 0x03
+	typeBody            uint16 = 0x04
+	typeReceipts        uint16 = 0x05
+	typeTotalDifficulty uint16 = 0x06
+	typeBlockIndex      uint16 = 0x3266
+
+	headerSize = 8
+)
+
+var (
+	// ErrOutOfRange is returned if a block outside of its range is requested from
+	// an archive.
+	ErrOutOfRange = errors.New("block out of archive range")
+
+	// errFull is returned if a block is added to an archive holding MaxEraBlocks.
+	errFull = errors.New("archive full")
+
+	// errNotContiguous is returned if a block added to an archive doesn't follow
+	// the previous one.
+	errNotContiguous = errors.New("block not contiguous")
+)
+
+// Filename returns the name of the archive holding the given era of the given
+// network, whose last block has the given hash.
+func Filename(network string, era uint64, last common.Hash) string {
+	return fmt.Sprintf("%s-%05d-%x.era", network, era, last[:4])
+}
+
+// Block is the raw content of a block stored in an archive.
+type Block struct {
+	Number   uint64
+	Header   rlp.RawValue
+	Body     rlp.RawValue
+	Receipts rlp.RawValue // Receipts in their storage form
+	Td       rlp.RawValue
+}
+
+// Builder writes an archive of consecutive blocks.
+type Builder struct {
+	w       io.Writer
+	written int64   // Number of bytes written so far
+	start   uint64  // Number of the first block of the archive
+	offsets []int64 // Positions of the headers of the blocks
+}
+
+// NewBuilder creates a builder writing an archive to w.
+func NewBuilder(w io.Writer) *Builder {
+	return &Builder{w: w}
+}
+
+// Add appends a block to the archive, following the previously added one.
+func (b *Builder) Add(block *Block) error {
+	if len(b.offsets) == 0 {
+		if err := b.write(typeVersion, nil); err != nil {
+			return err
+		}
+		b.start = block.Number
+	}
+	if len(b.offsets) == MaxEraBlocks {
+		return errFull
+	}
+	if block.Number != b.start+uint64(len(b.offsets)) {
+		return errNotContiguous
+	}
+	b.offsets = append(b.offsets, b.written)
+
+	for _, entry := range []struct {
+		typ  uint16
+		data []byte
+	}{
+		{typeHeader, snappy.Encode(nil, block.Header)},
+		{typeBody, snappy.Encode(nil, block.Body)},
+		{typeReceipts, snappy.Encode(nil, block.Receipts)},
+		{typeTotalDifficulty, block.Td},
+	} {
+		if err := b.write(entry.typ, entry.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finalize writes the index of the added blocks, completing the archive.
+func (b *Builder) Finalize() error {
+	if len(b.offsets) == 0 {
+		return errors.New("empty archive")
+	}
+	index := make([]byte, 8*(len(b.offsets)+2))
+	binary.LittleEndian.PutUint64(index, b.start)
+	for i, offset := range b.offsets {
+		binary.LittleEndian.PutUint64(index[8*(i+1):], uint64(offset-b.written))
+	}
+	binary.LittleEndian.PutUint64(index[8*(len(b.offsets)+1):], uint64(len(b.offsets)))
+	return b.write(typeBlockIndex, index)
+}
+
+// write appends an entry to the archive.
+func (b *Builder) write(typ uint16, data []byte) error {
+	header := make([]byte, headerSize)
+	binary.LittleEndian.PutUint16(header, typ)
+	binary.LittleEndian.PutUint32(header[2:], uint32(len(data)))
+
+	n, err := b.w.Write(append(header, data...))
+	b.written += int64(n)
+	return err
+}
+
+// Archive is a reader of the blocks of an archive.
+type Archive struct {
+	r       io.ReaderAt
+	closer  io.Closer
+	start   uint64  // Number of the first block of the archive
+	offsets []int64 // Positions of the headers of the blocks
+	index   int64   // Position of the index, ending the block entries
+}
+
+// Open opens the archive file at the given path.
+func Open(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	archive, err := NewArchive(f, stat.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	archive.closer = f
+	return archive, nil
+}
+
+// NewArchive creates a reader of the archive of the given size stored in r.
+func NewArchive(r io.ReaderAt, size int64) (*Archive, error) {
+	if size < headerSize+24 {
+		return nil, errors.New("archive too short")
+	}
+	buf := make([]byte, 8)
+	if _, err := r.ReadAt(buf, size-8); err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint64(buf)
+	if count == 0 || count > MaxEraBlocks {
+		return nil, fmt.Errorf("invalid archive block count %d", count)
+	}
+	// Locate the index from the block count at its end, and check its header
+	length := int64(8 * (count + 2))
+	if size < length+headerSize {
+		return nil, errors.New("archive too short")
+	}
+	pos := size - length - headerSize
+	typ, data, err := readEntry(r, pos, size)
+	if err != nil {
+		return nil, err
+	}
+	if typ != typeBlockIndex || int64(len(data)) != length {
+		return nil, errors.New("archive index missing")
+	}
+	archive := &Archive{
+		r:       r,
+		start:   binary.LittleEndian.Uint64(data),
+		offsets: make([]int64, count),
+		index:   pos,
+	}
+	for i := range archive.offsets {
+		archive.offsets[i] = pos + int64(binary.LittleEndian.Uint64(data[8*(i+1):]))
+		if archive.offsets[i] < 0 || archive.offsets[i] >= pos {
+			return nil, fmt.Errorf("invalid archive offset of block %d", archive.start+uint64(i))
+		}
+	}
+	return archive, nil
+}
+
+// Start returns the number of the first block of the archive.
+func (a *Archive) Start() uint64 {
+	return a.start
+}
+
+// Count returns the number of blocks in the archive.
+func (a *Archive) Count() uint64 {
+	return uint64(len(a.offsets))
+}
+
+// Block reads the block with the given number from the archive.
+func (a *Archive) Block(number uint64) (*Block, error) {
+	if number < a.start || number >= a.start+a.Count() {
+		return nil, ErrOutOfRange
+	}
+	block := &Block{Number: number}
+	pos := a.offsets[number-a.start]
+	for _, entry := range []struct {
+		typ        uint16
+		field      *rlp.RawValue
+		compressed bool
+	}{
+		{typeHeader, &block.Header, true},
+		{typeBody, &block.Body, true},
+		{typeReceipts, &block.Receipts, true},
+		{typeTotalDifficulty, &block.Td, false},
+	} {
+		typ, data, err := readEntry(a.r, pos, a.index)
+		if err != nil {
+			return nil, err
+		}
+		if typ != entry.typ {
+			return nil, fmt.Errorf("block %d: unexpected entry type %#x, want %#x", number, typ, entry.typ)
+		}
+		pos += headerSize + int64(len(data))
+		if entry.compressed {
+			if data, err = snappy.Decode(nil, data); err != nil {
+				return nil, fmt.Errorf("block %d: %v", number, err)
+			}
+		}
+		*entry.field = data
+	}
+	return block, nil
+}
+
+// Close closes the archive file, if the archive was opened from one.
+func (a *Archive) Close() error {
+	if a.closer != nil {
+		return a.closer.Close()
+	}
+	return nil
+}
+
+// readEntry reads the type and data of the entry at the given position, which
+// must end before the given limit.
+func readEntry(r io.ReaderAt, pos int64, limit int64) (uint16, []byte, error) {
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, pos); err != nil {
+		return 0, nil, err
+	}
+	length := int64(binary.LittleEndian.Uint32(header[2:]))
+	if pos+headerSize+length > limit {
+		return 0, nil, fmt.Errorf("archive entry at %d overflows", pos)
+	}
+	data := make([]byte, length)
+	if _, err := r.ReadAt(data, pos+headerSize); err != nil {
+		return 0, nil, err
+	}
+	return binary.LittleEndian.Uint16(header), data, nil
+}
@@ -0,0 +1,61 @@
+package era
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	var (
+		buf    = new(bytes.Buffer)
+		b      = NewBuilder(buf)
+		start  = uint64(MaxEraBlocks)
+		blocks []*Block
+	)
+	for i := uint64(0); i < 100; i++ {
+		block := &Block{
+			Number:   start + i,
+			Header:   []byte(fmt.Sprintf("header %d", i)),
+			Body:     bytes.Repeat([]byte{byte(i)}, int(i)),
+			Receipts: []byte(fmt.Sprintf("receipts %d", i)),
+			Td:       []byte{byte(i)},
+		}
+		if err := b.Add(block); err != nil {
+			t.Fatalf("failed to add block %d: %v", block.Number, err)
+		}
+		blocks = append(blocks, block)
+	}
+	if err := b.Add(&Block{Number: start + 101}); err != errNotContiguous {
+		t.Fatalf("gapped block: have %v, want %v", err, errNotContiguous)
+	}
+	if err := b.Finalize(); err != nil {
+		t.Fatalf("failed to finalize archive: %v", err)
+	}
+	archive, err := NewArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	if archive.Start() != start || archive.Count() != uint64(len(blocks)) {
+		t.Fatalf("range mismatch: have %d+%d, want %d+%d", archive.Start(), archive.Count(), start, len(blocks))
+	}
+	for _, want := range blocks {
+		have, err := archive.Block(want.Number)
+		if err != nil {
+			t.Fatalf("failed to read block %d: %v", want.Number, err)
+		}
+		if have.Number != want.Number || !bytes.Equal(have.Header, want.Header) || !bytes.Equal(have.Body, want.Body) ||
+			!bytes.Equal(have.Receipts, want.Receipts) || !bytes.Equal(have.Td, want.Td) {
+			t.Errorf("block %d mismatch: have %+v, want %+v", want.Number, have, want)
+		}
+	}
+	for _, number := range []uint64{start - 1, start + uint64(len(blocks))} {
+		if _, err := archive.Block(number); err != ErrOutOfRange {
+			t.Errorf("block %d: have %v, want %v", number, err, ErrOutOfRange)
+		}
+	}
+	// Truncated archives are rejected
+	if _, err := NewArchive(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), int64(buf.Len()-1)); err == nil {
+		t.Errorf("truncated archive opened")
+	}
+}
@@ -111,12 +111,13 @@ func NewPublicTxPoolAPI(b Backend) *PublicTxPoolAPI {
 	return &PublicTxPoolAPI{b}
 }
 
-// Content returns the transactions contained within the transaction pool.
-func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransaction {
-	content := map[string]map[string]map[string]*RPCTransaction{
-		"pending": make(map[string]map[string]*RPCTransaction),
-		"queued":  make(map[string]map[string]*RPCTransaction),
-	}
+// Content returns the transactions contained within the transaction pool,
+// along with the fees each provider committed to pay for them.
+func (s *PublicTxPoolAPI) Content() map[string]interface{} {
+	var (
+		pendingDump = make(map[string]map[string]*RPCTransaction)
+		queuedDump  = make(map[string]map[string]*RPCTransaction)
+	)
 	pending, queue := s.b.TxPoolContent()
 
 	// Flatten the pending transactions
@@ -125,7 +126,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 		for _, tx := range txs {
 			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
 		}
-		content["pending"][account.Hex()] = dump
+		pendingDump[account.Hex()] = dump
 	}
 	// Flatten the queued transactions
 	for account, txs := range queue {
@@ -133,9 +134,17 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 		for _, tx := range txs {
 			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
 		}
-		content["queued"][account.Hex()] = dump
+		queuedDump[account.Hex()] = dump
+	}
+	providers := make(map[string]*hexutil.Big)
+	for provider, spend := range s.b.TxPoolProviderSpends() {
+		providers[provider.Hex()] = (*hexutil.Big)(spend)
+	}
+	return map[string]interface{}{
+		"pending":   pendingDump,
+		"queued":    queuedDump,
+		"providers": providers,
 	}
-	return content
 }
 
 // Status returns the number of pending and queued transaction in the pool.
@@ -149,11 +158,11 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
-func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
-	content := map[string]map[string]map[string]string{
-		"pending": make(map[string]map[string]string),
-		"queued":  make(map[string]map[string]string),
-	}
+func (s *PublicTxPoolAPI) Inspect() map[string]interface{} {
+	var (
+		pendingDump = make(map[string]map[string]string)
+		queuedDump  = make(map[string]map[string]string)
+	)
 	pending, queue := s.b.TxPoolContent()
 
 	// Define a formatter to flatten a transaction into a string
@@ -169,7 +178,7 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 		for _, tx := range txs {
 			dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
 		}
-		content["pending"][account.Hex()] = dump
+		pendingDump[account.Hex()] = dump
 	}
 	// Flatten the queued transactions
 	for account, txs := range queue {
@@ -177,9 +186,17 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 		for _, tx := range txs {
 			dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
 		}
-		content["queued"][account.Hex()] = dump
+		queuedDump[account.Hex()] = dump
+	}
+	providers := make(map[string]string)
+	for provider, spend := range s.b.TxPoolProviderSpends() {
+		providers[provider.Hex()] = fmt.Sprintf("%v wei", spend)
+	}
+	return map[string]interface{}{
+		"pending":   pendingDump,
+		"queued":    queuedDump,
+		"providers": providers,
 	}
-	return content
 }
 
 // PublicAccountAPI provides an API to access accounts managed by this node.
@@ -415,7 +432,7 @@ func (s *PrivateAccountAPI) ProviderSignTransaction(ctx context.Context, tx *typ
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(signed)
+	data, err := signed.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -444,7 +461,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 		return nil, err
 	}
 
-	data, err := rlp.EncodeToBytes(signed)
+	data, err := signed.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -585,15 +602,92 @@ func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Add
 	return (*hexutil.Big)(state.GetBalance(address)), state.Error()
 }
 
+// GetOwner returns the owner of the given address at the given block number, nil if the account has no owner.
+// The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetOwner(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*common.Address, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	return state.GetOwner(address), state.Error()
+}
+
+// GetProviders returns the providers of the given address at the given block number.
+// A transaction to an enterprise contract must be co-signed by one of them.
+// The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetProviders(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) ([]common.Address, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	providers := make([]common.Address, 0, len(state.GetProviders(address)))
+	for _, provider := range state.GetProviders(address) {
+		providers = append(providers, *provider)
+	}
+	return providers, state.Error()
+}
+
+// ProviderBudgetResult is the spending policy of a provider on an enterprise contract and
+// its consumption in the budget period of the block following the requested one.
+type ProviderBudgetResult struct {
+	Policy          *RPCProviderPolicy `json:"policy"`
+	Period          hexutil.Uint64     `json:"period"`
+	Spent           *hexutil.Big       `json:"spent"`
+	Remaining       *hexutil.Big       `json:"remaining"` // nil if unlimited
+	SenderSpent     *hexutil.Big       `json:"senderSpent,omitempty"`
+	SenderRemaining *hexutil.Big       `json:"senderRemaining,omitempty"` // nil if unlimited
+}
+
+// GetProviderBudget returns the spending policy of provider on contract and the remaining budget for
+// transactions included after the given block. If sender is set, its remaining quota is returned as well.
+func (s *PublicBlockChainAPI) GetProviderBudget(ctx context.Context, provider common.Address, contract common.Address, sender *common.Address, blockNr rpc.BlockNumber) (*ProviderBudgetResult, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	policy := core.GetProviderPolicy(state, provider, contract)
+	if policy == nil {
+		return &ProviderBudgetResult{}, state.Error()
+	}
+	var (
+		number    = header.Number.Uint64() + 1
+		remaining = func(limit, spent *big.Int) *hexutil.Big {
+			if limit == nil || limit.Sign() == 0 {
+				return nil
+			}
+			left := new(big.Int).Sub(limit, spent)
+			if left.Sign() < 0 {
+				left.SetUint64(0)
+			}
+			return (*hexutil.Big)(left)
+		}
+		spent  = core.GetProviderSpending(state, provider, contract, nil, number)
+		result = &ProviderBudgetResult{
+			Policy:    newRPCProviderPolicy(policy),
+			Period:    hexutil.Uint64(policy.PeriodOf(number)),
+			Spent:     (*hexutil.Big)(spent),
+			Remaining: remaining(policy.MaxFeePerPeriod, spent),
+		}
+	)
+	if sender != nil {
+		senderSpent := core.GetProviderSpending(state, provider, contract, sender, number)
+		result.SenderSpent = (*hexutil.Big)(senderSpent)
+		result.SenderRemaining = remaining(policy.MaxFeePerSender, senderSpent)
+	}
+	return result, state.Error()
+}
+
 // Result structs for GetProof
 type AccountResult struct {
-	Address      common.Address  `json:"address"`
-	AccountProof []string        `json:"accountProof"`
-	Balance      *hexutil.Big    `json:"balance"`
-	CodeHash     common.Hash     `json:"codeHash"`
-	Nonce        hexutil.Uint64  `json:"nonce"`
-	StorageHash  common.Hash     `json:"storageHash"`
-	StorageProof []StorageResult `json:"storageProof"`
+	Address      common.Address   `json:"address"`
+	AccountProof []string         `json:"accountProof"`
+	Balance      *hexutil.Big     `json:"balance"`
+	CodeHash     common.Hash      `json:"codeHash"`
+	Nonce        hexutil.Uint64   `json:"nonce"`
+	StorageHash  common.Hash      `json:"storageHash"`
+	Owner        *common.Address  `json:"owner"`
+	Providers    []common.Address `json:"providers"`
+	StorageProof []StorageResult  `json:"storageProof"`
 }
 type StorageResult struct {
 	Key   string       `json:"key"`
@@ -639,6 +733,11 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	if proofErr != nil {
 		return nil, proofErr
 	}
+	// the owner and providers are part of the account leaf, needed to verify the proof
+	providers := make([]common.Address, 0, len(state.GetProviders(address)))
+	for _, provider := range state.GetProviders(address) {
+		providers = append(providers, *provider)
+	}
 
 	return &AccountResult{
 		Address:      address,
@@ -647,6 +746,8 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 		CodeHash:     codeHash,
 		Nonce:        hexutil.Uint64(state.GetNonce(address)),
 		StorageHash:  storageHash,
+		Owner:        state.GetOwner(address),
+		Providers:    providers,
 		StorageProof: storageProof,
 	}, state.Error()
 }
@@ -683,6 +784,9 @@ func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.
 		}
 		return response, err
 	}
+	if err == nil && blockNr >= 0 {
+		err = checkHistory(s.b.ChainDb(), uint64(blockNr))
+	}
 	return nil, err
 }
 
@@ -693,6 +797,9 @@ func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash comm
 	if block != nil {
 		return s.rpcOutputBlock(block, true, fullTx)
 	}
+	if err == nil {
+		err = checkBlockHistory(s.b.ChainDb(), blockHash)
+	}
 	return nil, err
 }
 
@@ -1130,8 +1237,14 @@ type RPCTransaction struct {
 	TransactionIndex hexutil.Uint    `json:"transactionIndex"`
 	Value            *hexutil.Big    `json:"value"`
 
-	Owner    common.Address `json:"owner"`
-	Provider common.Address `json:"provider"`
+	Type             hexutil.Uint64    `json:"type"`
+	ChainID          *hexutil.Big      `json:"chainId,omitempty"`
+	Calls            []types.BatchCall `json:"calls,omitempty"`
+	ValidAfterBlock  *hexutil.Uint64   `json:"validAfterBlock,omitempty"`
+	ValidBeforeBlock *hexutil.Uint64   `json:"validBeforeBlock,omitempty"`
+	Auth             hexutil.Bytes     `json:"auth,omitempty"`
+	Owner            common.Address    `json:"owner"`
+	Provider         common.Address    `json:"provider"`
 
 	V *hexutil.Big `json:"v"`
 	R *hexutil.Big `json:"r"`
@@ -1171,6 +1284,18 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		PR: (*hexutil.Big)(PR),
 		PS: (*hexutil.Big)(PS),
 	}
+	if tx.Type() != types.LegacyTxType {
+		result.Type = hexutil.Uint64(tx.Type())
+		result.ChainID = (*hexutil.Big)(tx.ChainId())
+		result.Calls = tx.Calls()
+	}
+	if tx.IsScheduled() {
+		validAfter, validBefore := hexutil.Uint64(tx.ValidAfter()), hexutil.Uint64(tx.ValidBefore())
+		result.ValidAfterBlock, result.ValidBeforeBlock = &validAfter, &validBefore
+	}
+	if auth := tx.SenderAuth(); auth != nil {
+		result.Auth = auth.Data
+	}
 
 	ownerAddr := tx.Owner()
 	if ownerAddr != nil {
@@ -1210,7 +1335,7 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	blob, _ := rlp.EncodeToBytes(txs[index])
+	blob, _ := txs[index].MarshalBinary()
 	return blob
 }
 
@@ -1319,8 +1444,8 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 		return newRPCPendingTransaction(tx), nil
 	}
 
-	// Transaction unknown, return as such
-	return nil, nil
+	// Transaction unknown, return as such unless its block history was expired
+	return nil, checkTransactionHistory(s.b.ChainDb(), hash)
 }
 
 // GetRawTransactionByHash returns the bytes of the transaction for the given hash.
@@ -1333,18 +1458,18 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 	if tx == nil {
 		if tx = s.b.GetPoolTransaction(hash); tx == nil {
 			// Transaction not found anywhere, abort
-			return nil, nil
+			return nil, checkTransactionHistory(s.b.ChainDb(), hash)
 		}
 	}
-	// Serialize to RLP and return
-	return rlp.EncodeToBytes(tx)
+	// Serialize to the canonical encoding and return
+	return tx.MarshalBinary()
 }
 
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(s.b.ChainDb(), hash)
 	if tx == nil {
-		return nil, nil
+		return nil, checkTransactionHistory(s.b.ChainDb(), hash)
 	}
 	receipts, err := s.b.GetReceipts(ctx, blockHash)
 	if err != nil {
@@ -1392,6 +1517,9 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.GasPayer != (common.Address{}) {
 		fields["gasPayer"] = receipt.GasPayer
 	}
+	if len(receipt.CallResults) > 0 {
+		fields["callResults"] = receipt.CallResults
+	}
 	return fields, nil
 }
 
@@ -1567,11 +1695,162 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	return SubmitTransaction(ctx, s.b, signed)
 }
 
+// EnterpriseOpArgs represents the arguments of an owner or provider operation on an enterprise contract.
+type EnterpriseOpArgs struct {
+	From     common.Address     `json:"from"`
+	Contract common.Address     `json:"contract"`
+	Address  *common.Address    `json:"address"` // provider to add/remove or new owner
+	Policy   *RPCProviderPolicy `json:"policy"`  // provider policy to set, nil removes it
+	Gas      *hexutil.Uint64    `json:"gas"`
+	GasPrice *hexutil.Big       `json:"gasPrice"`
+	Nonce    *hexutil.Uint64    `json:"nonce"`
+}
+
+// RPCProviderPolicy represents the spending policy of a provider on an enterprise contract.
+type RPCProviderPolicy struct {
+	MaxGasPerTx     hexutil.Uint64   `json:"maxGasPerTx"`
+	MaxFeePerPeriod *hexutil.Big     `json:"maxFeePerPeriod"`
+	MaxFeePerSender *hexutil.Big     `json:"maxFeePerSender"`
+	Period          hexutil.Uint64   `json:"period"`
+	AllowedSenders  []common.Address `json:"allowedSenders"`
+	AllowedMethods  []hexutil.Bytes  `json:"allowedMethods"`
+}
+
+func newRPCProviderPolicy(policy *types.ProviderPolicy) *RPCProviderPolicy {
+	if policy == nil {
+		return nil
+	}
+	result := &RPCProviderPolicy{
+		MaxGasPerTx:     hexutil.Uint64(policy.MaxGasPerTx),
+		MaxFeePerPeriod: (*hexutil.Big)(policy.MaxFeePerPeriod),
+		MaxFeePerSender: (*hexutil.Big)(policy.MaxFeePerSender),
+		Period:          hexutil.Uint64(policy.Period),
+		AllowedSenders:  policy.AllowedSenders,
+		AllowedMethods:  make([]hexutil.Bytes, 0, len(policy.AllowedMethods)),
+	}
+	for _, method := range policy.AllowedMethods {
+		result.AllowedMethods = append(result.AllowedMethods, common.CopyBytes(method[:]))
+	}
+	return result
+}
+
+func (p *RPCProviderPolicy) toPolicy() (*types.ProviderPolicy, error) {
+	if p == nil {
+		return nil, nil
+	}
+	policy := &types.ProviderPolicy{
+		MaxGasPerTx:     uint64(p.MaxGasPerTx),
+		MaxFeePerPeriod: (*big.Int)(p.MaxFeePerPeriod),
+		MaxFeePerSender: (*big.Int)(p.MaxFeePerSender),
+		Period:          uint64(p.Period),
+		AllowedSenders:  p.AllowedSenders,
+	}
+	for _, method := range p.AllowedMethods {
+		if len(method) != 4 {
+			return nil, fmt.Errorf("invalid method selector %s", method)
+		}
+		var selector [4]byte
+		copy(selector[:], method)
+		policy.AllowedMethods = append(policy.AllowedMethods, selector)
+	}
+	return policy, nil
+}
+
+// sendEnterpriseOp signs the given operation with the owner account and submits it to the transaction pool.
+func (s *PublicTransactionPoolAPI) sendEnterpriseOp(ctx context.Context, opType types.EnterpriseOpType, args EnterpriseOpArgs) (common.Hash, error) {
+	var op *types.EnterpriseOp
+	switch {
+	case opType == types.EnterpriseOpSetPolicy:
+		policy, err := args.Policy.toPolicy()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		op = types.NewSetPolicyOp(args.Contract, policy)
+	case args.Address != nil:
+		op = types.NewEnterpriseOp(opType, args.Contract, *args.Address)
+	case opType == types.EnterpriseOpRenounceOwnership:
+		op = types.NewEnterpriseOp(opType, args.Contract, common.Address{})
+	default:
+		return common.Hash{}, fmt.Errorf("address not specified for %s", opType)
+	}
+	data, err := op.Encode()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	to := types.EnterpriseManagerAddress
+	return s.SendTransaction(ctx, SendTxArgs{
+		From:     args.From,
+		To:       &to,
+		Gas:      args.Gas,
+		GasPrice: args.GasPrice,
+		Nonce:    args.Nonce,
+		Data:     (*hexutil.Bytes)(&data),
+	})
+}
+
+// AddProvider submits a transaction signed by the contract owner which adds a provider to an enterprise contract.
+func (s *PublicTransactionPoolAPI) AddProvider(ctx context.Context, args EnterpriseOpArgs) (common.Hash, error) {
+	return s.sendEnterpriseOp(ctx, types.EnterpriseOpAddProvider, args)
+}
+
+// RemoveProvider submits a transaction signed by the contract owner which removes a provider from an enterprise contract.
+func (s *PublicTransactionPoolAPI) RemoveProvider(ctx context.Context, args EnterpriseOpArgs) (common.Hash, error) {
+	return s.sendEnterpriseOp(ctx, types.EnterpriseOpRemoveProvider, args)
+}
+
+// TransferOwnership submits a transaction signed by the contract owner which hands an enterprise contract over to a new owner.
+func (s *PublicTransactionPoolAPI) TransferOwnership(ctx context.Context, args EnterpriseOpArgs) (common.Hash, error) {
+	return s.sendEnterpriseOp(ctx, types.EnterpriseOpTransferOwnership, args)
+}
+
+// RenounceOwnership submits a transaction signed by the contract owner which leaves an enterprise contract without owner.
+// Its providers can not be changed anymore afterwards.
+func (s *PublicTransactionPoolAPI) RenounceOwnership(ctx context.Context, args EnterpriseOpArgs) (common.Hash, error) {
+	return s.sendEnterpriseOp(ctx, types.EnterpriseOpRenounceOwnership, args)
+}
+
+// SetProviderPolicy submits a transaction signed by a provider of an enterprise contract which limits
+// what it pays for the transactions it co-signs to that contract. A nil policy removes the limits.
+func (s *PublicTransactionPoolAPI) SetProviderPolicy(ctx context.Context, args EnterpriseOpArgs) (common.Hash, error) {
+	return s.sendEnterpriseOp(ctx, types.EnterpriseOpSetPolicy, args)
+}
+
+// GasPriceChangeArgs represents the arguments of a gas price change scheduled by the staking admin.
+type GasPriceChangeArgs struct {
+	From       common.Address  `json:"from"`
+	Price      *hexutil.Big    `json:"price"`      // gas price of every transaction from Activation on
+	Activation hexutil.Uint64  `json:"activation"` // first block paying Price, must be a future block
+	Gas        *hexutil.Uint64 `json:"gas"`
+	GasPrice   *hexutil.Big    `json:"gasPrice"`
+	Nonce      *hexutil.Uint64 `json:"nonce"`
+}
+
+// ScheduleGasPrice submits a transaction signed by the staking admin which changes the gas price
+// of every transaction from a future block on.
+func (s *PublicTransactionPoolAPI) ScheduleGasPrice(ctx context.Context, args GasPriceChangeArgs) (common.Hash, error) {
+	if args.Price == nil {
+		return common.Hash{}, errors.New("price not specified")
+	}
+	data, err := types.NewGasPriceChange(args.Price.ToInt(), uint64(args.Activation)).Encode()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	to := types.GasPriceManagerAddress
+	return s.SendTransaction(ctx, SendTxArgs{
+		From:     args.From,
+		To:       &to,
+		Gas:      args.Gas,
+		GasPrice: args.GasPrice,
+		Nonce:    args.Nonce,
+		Data:     (*hexutil.Bytes)(&data),
+	})
+}
+
 // SendRawTransaction will add the signed transaction to the transaction pool.
 // The sender is responsible for signing the transaction and using the correct nonce.
 func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
 		return common.Hash{}, err
 	}
 	return SubmitTransaction(ctx, s.b, tx)
@@ -1602,7 +1881,8 @@ func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes)
 	return signature, err
 }
 
-// SignTransactionResult represents a RLP encoded signed transaction.
+// SignTransactionResult represents a signed transaction in its canonical encoding,
+// which is RLP for legacy transactions and the typed envelope otherwise.
 type SignTransactionResult struct {
 	Raw hexutil.Bytes      `json:"raw"`
 	Tx  *types.Transaction `json:"tx"`
@@ -1628,7 +1908,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1640,7 +1920,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 // the given from address and it needs to be unlocked.
 func (s *PublicTransactionPoolAPI) ProviderSignTransaction(ctx context.Context, encodedTx hexutil.Bytes, providerAddr common.Address) (*RPCTransaction, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
 		return nil, err
 	}
 	txSigned, err := s.providerSign(providerAddr, tx)
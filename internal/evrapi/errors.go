@@ -0,0 +1,54 @@
+package evrapi
+
+import (
+	"fmt"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+)
+
+// PrunedHistoryErrorCode is the error code of the requests for blocks, transactions
+// or receipts whose history was expired from the ancient store of the node.
+const PrunedHistoryErrorCode = 4444
+
+// prunedHistoryError is returned if the body and receipts of the requested block
+// were expired by the history retention of the node.
+type prunedHistoryError struct {
+	number uint64
+}
+
+func (e *prunedHistoryError) Error() string {
+	return fmt.Sprintf("pruned history unavailable for block %d", e.number)
+}
+
+func (e *prunedHistoryError) ErrorCode() int { return PrunedHistoryErrorCode }
+
+// checkHistory returns a pruned history error if the body and receipts of the
+// canonical block with the given number were expired.
+func checkHistory(db evrdb.Reader, number uint64) error {
+	if rawdb.HistoryExpired(db, number) {
+		return &prunedHistoryError{number: number}
+	}
+	return nil
+}
+
+// checkBlockHistory returns a pruned history error if the body and receipts of
+// the block with the given hash were expired.
+func checkBlockHistory(db evrdb.Reader, hash common.Hash) error {
+	number := rawdb.ReadHeaderNumber(db, hash)
+	if number == nil || rawdb.ReadCanonicalHash(db, *number) != hash {
+		return nil
+	}
+	return checkHistory(db, *number)
+}
+
+// checkTransactionHistory returns a pruned history error if the transaction with
+// the given hash is indexed, but the body of its block was expired.
+func checkTransactionHistory(db evrdb.Reader, hash common.Hash) error {
+	number := rawdb.ReadTxLookupEntry(db, hash)
+	if number == nil {
+		return nil
+	}
+	return checkHistory(db, *number)
+}
@@ -483,6 +483,54 @@ web3._extend({
 			params: 2,
     		inputFormatter: [null, web3._extend.formatters.inputAddressFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getOwner',
+			call: 'eth_getOwner',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getProviders',
+			call: 'eth_getProviders',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getProviderBudget',
+			call: 'eth_getProviderBudget',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'setProviderPolicy',
+			call: 'eth_setProviderPolicy',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'scheduleGasPrice',
+			call: 'eth_scheduleGasPrice',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addProvider',
+			call: 'eth_addProvider',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeProvider',
+			call: 'eth_removeProvider',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'transferOwnership',
+			call: 'eth_transferOwnership',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'renounceOwnership',
+			call: 'eth_renounceOwnership',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getBlockSignerByHash',
 			call: 'eth_getBlockSignerByHash',
@@ -520,6 +568,17 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'indexStatus',
+			call: 'eth_indexStatus',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionsByAddress',
+			call: 'eth_getTransactionsByAddress',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -148,6 +148,11 @@ func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.evr.txPool.Content()
 }
 
+// TxPoolProviderSpends returns no provider commitments as the light pool does not track provider balances
+func (b *LesApiBackend) TxPoolProviderSpends() map[common.Address]*big.Int {
+	return make(map[common.Address]*big.Int)
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.evr.txPool.SubscribeNewTxsEvent(ch)
 }
@@ -212,6 +217,10 @@ func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocksClient, sections
 }
 
+func (b *LesApiBackend) LogIndexStatus() (uint64, uint64) {
+	return params.LogIndexBlocks, 0
+}
+
 func (b *LesApiBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	for i := 0; i < bloomFilterThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.evr.bloomRequests)
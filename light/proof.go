@@ -0,0 +1,100 @@
+package light
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/trie"
+)
+
+var (
+	// ErrAccountMismatch is returned if the account leaf of a proof isn't the
+	// encoding of the claimed account.
+	ErrAccountMismatch = errors.New("account doesn't match proof")
+
+	// ErrStorageMismatch is returned if the storage leaf of a proof isn't the
+	// encoding of the claimed slot value.
+	ErrStorageMismatch = errors.New("storage value doesn't match proof")
+)
+
+// emptyCodeHash is the code hash of accounts without code.
+var emptyCodeHash = crypto.Keccak256(nil)
+
+// VerifyAccountProof checks that the proof holds the given account at address in
+// the state with the given root. As accounts are only re-encoded with their owner
+// and providers once modified, the leaf may also use the legacy encoding without
+// them, provided the account has neither. An account missing from the proven
+// trie must be empty.
+func VerifyAccountProof(root common.Hash, address common.Address, account *state.Account, proof [][]byte) error {
+	leaf, err := trie.VerifyProofList(root, crypto.Keccak256(address[:]), proof)
+	if err != nil {
+		return err
+	}
+	if leaf == nil {
+		if !emptyAccount(account) {
+			return fmt.Errorf("%v: account %x missing", ErrAccountMismatch, address)
+		}
+		return nil
+	}
+	enc, err := rlp.EncodeToBytes(account)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(leaf, enc) {
+		return nil
+	}
+	if account.OwnerAddress == nil && len(account.ProviderAddresses) == 0 {
+		legacy, err := rlp.EncodeToBytes(&state.AccountWithoutProvider{
+			Nonce:    account.Nonce,
+			Balance:  account.Balance,
+			Root:     account.Root,
+			CodeHash: account.CodeHash,
+		})
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(leaf, legacy) {
+			return nil
+		}
+	}
+	return ErrAccountMismatch
+}
+
+// VerifyStorageProof checks that the proof holds value at key in the storage trie
+// with the given root. A zero value is proven by the absence of the key, which
+// needs no proof nodes if the storage is empty.
+func VerifyStorageProof(root common.Hash, key common.Hash, value common.Hash, proof [][]byte) error {
+	var (
+		leaf []byte
+		err  error
+	)
+	if root != types.EmptyRootHash {
+		if leaf, err = trie.VerifyProofList(root, crypto.Keccak256(key[:]), proof); err != nil {
+			return err
+		}
+	}
+	var want []byte
+	if value != (common.Hash{}) {
+		if want, err = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00")); err != nil {
+			return err
+		}
+	}
+	if !bytes.Equal(leaf, want) {
+		return ErrStorageMismatch
+	}
+	return nil
+}
+
+// emptyAccount returns whether the account is the one reported for an address
+// missing from the state.
+func emptyAccount(account *state.Account) bool {
+	return account.Nonce == 0 && (account.Balance == nil || account.Balance.Sign() == 0) &&
+		account.Root == types.EmptyRootHash && bytes.Equal(account.CodeHash, emptyCodeHash) &&
+		account.OwnerAddress == nil && len(account.ProviderAddresses) == 0
+}
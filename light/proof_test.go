@@ -0,0 +1,109 @@
+package light
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestVerifyAccountProof(t *testing.T) {
+	var (
+		db       = state.NewDatabase(rawdb.NewMemoryDatabase())
+		legacy   = common.Address{0x01}
+		modern   = common.Address{0x02}
+		missing  = common.Address{0x03}
+		owner    = common.Address{0x04}
+		provider = common.Address{0x05}
+		slot     = common.Hash{0x06}
+		value    = common.Hash{0x07}
+	)
+	statedb, _ := state.New(common.Hash{}, db)
+	statedb.SetBalance(modern, big.NewInt(2))
+	statedb.SetOwner(modern, &owner)
+	statedb.AddProvider(modern, provider)
+	statedb.SetState(modern, slot, value)
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	// Write an account with the encoding predating owners and providers
+	tr, _ := db.OpenTrie(root)
+	legacyAccount := state.AccountWithoutProvider{Nonce: 1, Balance: big.NewInt(1), Root: types.EmptyRootHash, CodeHash: crypto.Keccak256(nil)}
+	enc, _ := rlp.EncodeToBytes(&legacyAccount)
+	if err := tr.TryUpdate(legacy[:], enc); err != nil {
+		t.Fatalf("failed to write legacy account: %v", err)
+	}
+	if root, err = tr.Commit(nil); err != nil {
+		t.Fatalf("failed to commit legacy account: %v", err)
+	}
+	statedb, _ = state.New(root, db)
+
+	prove := func(addr common.Address) [][]byte {
+		proof, err := statedb.GetProof(addr)
+		if err != nil {
+			t.Fatalf("failed to prove %x: %v", addr, err)
+		}
+		return proof
+	}
+	account := func(addr common.Address) *state.Account {
+		account := &state.Account{
+			Nonce:    statedb.GetNonce(addr),
+			Balance:  statedb.GetBalance(addr),
+			Root:     types.EmptyRootHash,
+			CodeHash: crypto.Keccak256(nil),
+		}
+		if tr := statedb.StorageTrie(addr); tr != nil {
+			account.Root = tr.Hash()
+		}
+		account.OwnerAddress = statedb.GetOwner(addr)
+		account.ProviderAddresses = statedb.GetProviders(addr)
+		return account
+	}
+	for _, addr := range []common.Address{legacy, modern, missing} {
+		if err := VerifyAccountProof(root, addr, account(addr), prove(addr)); err != nil {
+			t.Errorf("account %x: verification failed: %v", addr, err)
+		}
+	}
+	// Forged fields don't match the leaf, whatever its encoding
+	forged := account(legacy)
+	forged.OwnerAddress = &owner
+	if err := VerifyAccountProof(root, legacy, forged, prove(legacy)); err != ErrAccountMismatch {
+		t.Errorf("legacy account with owner: have %v, want %v", err, ErrAccountMismatch)
+	}
+	forged = account(modern)
+	forged.ProviderAddresses = nil
+	if err := VerifyAccountProof(root, modern, forged, prove(modern)); err != ErrAccountMismatch {
+		t.Errorf("account without providers: have %v, want %v", err, ErrAccountMismatch)
+	}
+	forged = account(missing)
+	forged.Balance = big.NewInt(1)
+	if err := VerifyAccountProof(root, missing, forged, prove(missing)); err == nil {
+		t.Errorf("missing account with balance verified")
+	}
+	if err := VerifyAccountProof(root, modern, account(modern), prove(legacy)); err == nil {
+		t.Errorf("account verified with the proof of another")
+	}
+	// Storage slots are proven against the storage root of the account
+	storageRoot := account(modern).Root
+	for key, want := range map[common.Hash]common.Hash{slot: value, {0x08}: {}} {
+		proof, err := statedb.GetStorageProof(modern, key)
+		if err != nil {
+			t.Fatalf("failed to prove slot %x: %v", key, err)
+		}
+		if err := VerifyStorageProof(storageRoot, key, want, proof); err != nil {
+			t.Errorf("slot %x: verification failed: %v", key, err)
+		}
+		if err := VerifyStorageProof(storageRoot, key, common.Hash{0xff}, proof); err != ErrStorageMismatch {
+			t.Errorf("slot %x with forged value: have %v, want %v", key, err, ErrStorageMismatch)
+		}
+	}
+	if err := VerifyStorageProof(types.EmptyRootHash, slot, common.Hash{}, nil); err != nil {
+		t.Errorf("empty storage: verification failed: %v", err)
+	}
+}
@@ -363,6 +363,10 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	if header.GasLimit < tx.Gas() {
 		return core.ErrGasLimit
 	}
+	// Scheduled transactions can't be included once their window ended
+	if tx.Expired(header.Number.Uint64() + 1) {
+		return core.ErrTxExpired
+	}
 
 	// Transactions can't be negative. This may never happen
 	// using RLP decoded transactions but may occur if you create
@@ -382,6 +386,13 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	if err != nil {
 		return err
 	}
+	if tx.IsBatch() {
+		callsGas, err := core.BatchCallsGas(tx.Calls())
+		if err != nil {
+			return err
+		}
+		gas += callsGas
+	}
 	if tx.Gas() < gas {
 		return core.ErrIntrinsicGas
 	}
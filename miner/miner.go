@@ -49,6 +49,8 @@ type Config struct {
 	GasCeil   uint64         // Target gas ceiling for mined blocks.
 	Recommit  time.Duration  // The time interval for miner to re-create mining work.
 	Noverify  bool           // Disable remote mining solution verification(only useful in ethash).
+
+	MaxAccountTxs int // Maximum number of transactions of a remote or priority account in a block (0 = unlimited)
 }
 
 // Miner creates blocks and searches for proof-of-work values.
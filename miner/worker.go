@@ -91,6 +91,8 @@ type environment struct {
 	header   *types.Header
 	txs      []*types.Transaction
 	receipts []*types.Receipt
+
+	deadline time.Time // time after which no more transactions are added, zero if unbounded
 }
 
 // task contains all information for consensus engine sealing and result submitting.
@@ -484,7 +486,7 @@ func (w *worker) mainLoop() {
 					acc, _ := types.Sender(w.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs)
+				txset := types.NewTransactionsByFairShare(w.current.signer, txs, w.config.MaxAccountTxs)
 				w.commitTransactions(txset, coinbase, nil)
 				w.updateSnapshot()
 			} else {
@@ -729,7 +731,14 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coinbase common.Address, interrupt *int32) bool {
+// txSet is an ordered set of pending transactions committed into a block.
+type txSet interface {
+	Peek() *types.Transaction
+	Shift()
+	Pop()
+}
+
+func (w *worker) commitTransactions(txs txSet, coinbase common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
 		return true
@@ -762,6 +771,12 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			}
 			return atomic.LoadInt32(interrupt) == commitInterruptNewHead
 		}
+		// Stop filling the block once the building deadline is reached, so that a large
+		// block never makes the proposer miss its propose timeout.
+		if !w.current.deadline.IsZero() && time.Now().After(w.current.deadline) {
+			log.Debug("Block building deadline reached", "number", w.current.header.Number, "txs", w.current.tcount)
+			break
+		}
 		// If we don't have enough gas for any further transactions then we're done
 		if w.current.gasPool.Gas() < params.TxGas {
 			log.Trace("Not enough gas for further transactions", "have", w.current.gasPool, "want", params.TxGas)
@@ -805,6 +820,11 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			log.Trace("Skipping account with hight nonce", "sender", from, "nonce", tx.Nonce())
 			txs.Pop()
 
+		case core.ErrTxNotYetValid, core.ErrTxExpired:
+			// Scheduled transaction outside of its window, the next ones of the account can't run either
+			log.Trace("Skipping account with scheduled transaction", "sender", from, "nonce", tx.Nonce(), "err", err)
+			txs.Pop()
+
 		case nil:
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
@@ -902,6 +922,11 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	}
 	// Create the current work task and check any fork transitions needed
 	env := w.current
+	if tendermint, ok := w.engine.(consensus.Tendermint); ok && w.isRunning() {
+		if deadline := tendermint.BlockBuildDeadline(); deadline > 0 {
+			env.deadline = tstart.Add(deadline)
+		}
+	}
 	if w.chainConfig.DAOForkSupport && w.chainConfig.DAOForkBlock != nil && w.chainConfig.DAOForkBlock.Cmp(header.Number) == 0 {
 		misc.ApplyDAOHardFork(env.state)
 	}
@@ -946,22 +971,35 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		}
 		return
 	}
-	// Split the pending transactions into locals and remotes
-	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
+	// Split the pending transactions into locals, priority accounts and remotes
+	localTxs, priorityTxs, remoteTxs := make(map[common.Address]types.Transactions), make(map[common.Address]types.Transactions), pending
 	for _, account := range w.evr.TxPool().Locals() {
 		if txs := remoteTxs[account]; len(txs) > 0 {
 			delete(remoteTxs, account)
 			localTxs[account] = txs
 		}
 	}
+	for _, account := range w.evr.TxPool().Priorities() {
+		if txs := remoteTxs[account]; len(txs) > 0 {
+			delete(remoteTxs, account)
+			priorityTxs[account] = txs
+		}
+	}
+	// Each lane is shared fairly among its senders, only local accounts are not limited
 	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs)
+		txs := types.NewTransactionsByFairShare(w.current.signer, localTxs, 0)
+		if w.commitTransactions(txs, w.coinbase, interrupt) {
+			return
+		}
+	}
+	if len(priorityTxs) > 0 {
+		txs := types.NewTransactionsByFairShare(w.current.signer, priorityTxs, w.config.MaxAccountTxs)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
 			return
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, remoteTxs)
+		txs := types.NewTransactionsByFairShare(w.current.signer, remoteTxs, w.config.MaxAccountTxs)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
 			return
 		}
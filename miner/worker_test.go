@@ -357,6 +357,57 @@ func testRegenerateMiningBlock(t *testing.T, chainConfig *params.ChainConfig, en
 	}
 }
 
+func TestBlockBuildDeadline(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, 0)
+	defer w.close()
+
+	// Ensure worker has finished initialization
+	for {
+		b := w.pendingBlock()
+		if b != nil && b.NumberU64() == 1 {
+			break
+		}
+	}
+	// build fills a fresh block with the pending transactions until the given
+	// deadline, returning the number of transactions included.
+	build := func(deadline time.Time) int {
+		parent := b.chain.CurrentBlock()
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			GasLimit:   core.CalcGasLimit(parent, testConfig.GasFloor, testConfig.GasCeil),
+			Time:       parent.Time() + 1,
+			Coinbase:   testBankAddress,
+		}
+		if err := engine.Prepare(b.chain, header); err != nil {
+			t.Fatalf("failed to prepare header: %v", err)
+		}
+		if err := w.makeCurrent(parent, header); err != nil {
+			t.Fatalf("failed to create mining context: %v", err)
+		}
+		w.current.deadline = deadline
+
+		pending, err := b.txPool.Pending()
+		if err != nil {
+			t.Fatalf("failed to fetch pending transactions: %v", err)
+		}
+		w.commitTransactions(types.NewTransactionsByFairShare(w.current.signer, pending, 0), testBankAddress, nil)
+		return w.current.tcount
+	}
+	if have := build(time.Now().Add(-time.Second)); have != 0 {
+		t.Errorf("transactions included past the deadline: have %d, want 0", have)
+	}
+	if have := build(time.Now().Add(time.Minute)); have != len(pendingTxs) {
+		t.Errorf("transactions included before the deadline: have %d, want %d", have, len(pendingTxs))
+	}
+	if have := build(time.Time{}); have != len(pendingTxs) {
+		t.Errorf("transactions included without a deadline: have %d, want %d", have, len(pendingTxs))
+	}
+}
+
 func TestAdjustIntervalEthash(t *testing.T) {
 	testAdjustInterval(t, ethashChainConfig, ethash.NewFaker())
 }
@@ -127,6 +127,19 @@ func (ks *KeyStore) SignTx(account *Account, tx *Transaction, chainID *BigInt) (
 	return &Transaction{signed}, nil
 }
 
+// ProviderSignTx co-signs the given transaction with the requested provider account,
+// which must be unlocked.
+func (ks *KeyStore) ProviderSignTx(account *Account, tx *Transaction, chainID *BigInt) (*Transaction, error) {
+	if chainID == nil { // Null passed from mobile app
+		chainID = new(BigInt)
+	}
+	signed, err := ks.keystore.ProviderSignTx(account.account, tx.tx, chainID.bigint)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{signed}, nil
+}
+
 // SignHashPassphrase signs hash if the private key matching the given address can
 // be decrypted with the given passphrase. The produced signature is in the
 // [R || S || V] format where V is 0 or 1.
@@ -21,6 +21,7 @@ package geth
 import (
 	"math/big"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/evrclient"
 )
@@ -53,6 +54,51 @@ func (ec *EvrynetClient) GetBlockByNumber(ctx *Context, number int64) (block *Bl
 	return &Block{rawBlock}, err
 }
 
+// BlockSigner contains the proposer and the commit signers of a block.
+type BlockSigner struct {
+	details *evrclient.ExtraDataDetails
+}
+
+// GetProposer returns the validator who proposed the block.
+func (s *BlockSigner) GetProposer() *Address { return optionalAddress(s.details.BlockProposer) }
+
+// GetCommitSigners returns the validators who committed the block.
+func (s *BlockSigner) GetCommitSigners() *Addresses {
+	signers := make([]common.Address, 0, len(s.details.CommitSigners))
+	for _, signer := range s.details.CommitSigners {
+		if signer != nil {
+			signers = append(signers, *signer)
+		}
+	}
+	return &Addresses{signers}
+}
+
+// GetRawData returns the raw extra data of the block.
+func (s *BlockSigner) GetRawData() []byte { return s.details.RawData }
+
+// GetBlockSignerByHash returns the proposer and commit signers of the block with the given hash.
+func (ec *EvrynetClient) GetBlockSignerByHash(ctx *Context, hash *Hash) (signer *BlockSigner, _ error) {
+	details, err := ec.client.GetBlockSignerByHash(ctx.context, hash.hash)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockSigner{details}, nil
+}
+
+// GetBlockSignerByNumber returns the proposer and commit signers of the block with the given number.
+// If number is <0, the latest known block is used.
+func (ec *EvrynetClient) GetBlockSignerByNumber(ctx *Context, number int64) (signer *BlockSigner, _ error) {
+	var rawNumber *big.Int
+	if number >= 0 {
+		rawNumber = big.NewInt(number)
+	}
+	details, err := ec.client.GetBlockSignerByNumber(ctx.context, rawNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockSigner{details}, nil
+}
+
 // GetHeaderByHash returns the block header with the given hash.
 func (ec *EvrynetClient) GetHeaderByHash(ctx *Context, hash *Hash) (header *Header, _ error) {
 	rawHeader, err := ec.client.HeaderByHash(ctx.context, hash.hash)
@@ -190,6 +236,35 @@ func (ec *EvrynetClient) GetNonceAt(ctx *Context, account *Address, number int64
 	return int64(rawNonce), err
 }
 
+// GetOwnerAt returns the owner of the given enterprise contract, or nil if the
+// account is not an enterprise contract.
+// The block number can be <0, in which case the owner is taken from the latest known block.
+func (ec *EvrynetClient) GetOwnerAt(ctx *Context, account *Address, number int64) (owner *Address, _ error) {
+	var rawNumber *big.Int
+	if number >= 0 {
+		rawNumber = big.NewInt(number)
+	}
+	rawOwner, err := ec.client.OwnerAt(ctx.context, account.address, rawNumber)
+	if err != nil {
+		return nil, err
+	}
+	return optionalAddress(rawOwner), nil
+}
+
+// GetProvidersAt returns the providers paying the gas of the given enterprise contract.
+// The block number can be <0, in which case the providers are taken from the latest known block.
+func (ec *EvrynetClient) GetProvidersAt(ctx *Context, account *Address, number int64) (providers *Addresses, _ error) {
+	var rawNumber *big.Int
+	if number >= 0 {
+		rawNumber = big.NewInt(number)
+	}
+	rawProviders, err := ec.client.ProvidersAt(ctx.context, account.address, rawNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &Addresses{rawProviders}, nil
+}
+
 // Filters
 
 // FilterLogs executes a filter query.
@@ -314,3 +389,26 @@ func (ec *EvrynetClient) EstimateGas(ctx *Context, msg *CallMsg) (gas int64, _ e
 func (ec *EvrynetClient) SendTransaction(ctx *Context, tx *Transaction) error {
 	return ec.client.SendTransaction(ctx.context, tx.tx)
 }
+
+// SendTx asks the node to sign the transaction described by args with an unlocked
+// account and inject it into the pending pool, returning the transaction hash.
+//
+// If the transaction was a contract creation use the TransactionReceipt method to get the
+// contract address after the transaction has been mined.
+func (ec *EvrynetClient) SendTx(ctx *Context, args *SendTxArgs) (hash *Hash, _ error) {
+	rawHash, err := ec.client.SendTx(ctx.context, args.args)
+	if err != nil {
+		return nil, err
+	}
+	return &Hash{rawHash}, nil
+}
+
+// ProviderSignTx asks the node to co-sign the sender-signed transaction with the
+// given provider account, which must be unlocked on the node.
+func (ec *EvrynetClient) ProviderSignTx(ctx *Context, tx *Transaction, provider *Address) (signedTx *Transaction, _ error) {
+	rawTx, err := ec.client.ProviderSignTx(ctx.context, tx.tx, &provider.address)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
@@ -20,9 +20,11 @@ package geth
 
 import (
 	"errors"
+	"math/big"
 
 	ethereum "github.com/Evrynetlabs/evrynet-node"
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 )
 
 // Subscription represents an event subscription where events are
@@ -72,6 +74,94 @@ func (msg *CallMsg) SetTo(address *Address) {
 	msg.msg.To = &address.address
 }
 
+// SendTxArgs contains the parameters of a transaction signed and sent by the node,
+// including the owner and provider of enterprise contracts.
+type SendTxArgs struct {
+	args ethereum.SendTxArgs
+}
+
+// NewSendTxArgs creates an empty transaction parameter list.
+func NewSendTxArgs() *SendTxArgs {
+	return new(SendTxArgs)
+}
+
+func (args *SendTxArgs) GetFrom() *Address { return &Address{args.args.From} }
+func (args *SendTxArgs) GetGas() int64 {
+	if args.args.Gas == nil {
+		return 0
+	}
+	return int64(*args.args.Gas)
+}
+func (args *SendTxArgs) GetGasPrice() *BigInt {
+	if args.args.GasPrice == nil {
+		return nil
+	}
+	return &BigInt{args.args.GasPrice.ToInt()}
+}
+func (args *SendTxArgs) GetValue() *BigInt {
+	if args.args.Value == nil {
+		return nil
+	}
+	return &BigInt{args.args.Value.ToInt()}
+}
+func (args *SendTxArgs) GetNonce() int64 {
+	if args.args.Nonce == nil {
+		return -1
+	}
+	return int64(*args.args.Nonce)
+}
+func (args *SendTxArgs) GetData() []byte {
+	if args.args.Data == nil {
+		return nil
+	}
+	return *args.args.Data
+}
+func (args *SendTxArgs) GetTo() *Address       { return optionalAddress(args.args.To) }
+func (args *SendTxArgs) GetOwner() *Address    { return optionalAddress(args.args.Owner) }
+func (args *SendTxArgs) GetProvider() *Address { return optionalAddress(args.args.Provider) }
+
+func (args *SendTxArgs) SetFrom(address *Address) { args.args.From = address.address }
+func (args *SendTxArgs) SetGas(gas int64) {
+	rawGas := hexutil.Uint64(gas)
+	args.args.Gas = &rawGas
+}
+func (args *SendTxArgs) SetGasPrice(price *BigInt) {
+	args.args.GasPrice = (*hexutil.Big)(new(big.Int).Set(price.bigint))
+}
+func (args *SendTxArgs) SetValue(value *BigInt) {
+	args.args.Value = (*hexutil.Big)(new(big.Int).Set(value.bigint))
+}
+func (args *SendTxArgs) SetNonce(nonce int64) {
+	rawNonce := hexutil.Uint64(nonce)
+	args.args.Nonce = &rawNonce
+}
+func (args *SendTxArgs) SetData(data []byte) {
+	input := hexutil.Bytes(common.CopyBytes(data))
+	args.args.Data = &input
+}
+func (args *SendTxArgs) SetTo(address *Address)    { args.args.To = optionalCommonAddress(address) }
+func (args *SendTxArgs) SetOwner(address *Address) { args.args.Owner = optionalCommonAddress(address) }
+func (args *SendTxArgs) SetProvider(address *Address) {
+	args.args.Provider = optionalCommonAddress(address)
+}
+
+// optionalAddress wraps an optional address, returning nil if it is not set.
+func optionalAddress(address *common.Address) *Address {
+	if address == nil {
+		return nil
+	}
+	return &Address{*address}
+}
+
+// optionalCommonAddress unwraps an optional address, returning nil if it is not set.
+func optionalCommonAddress(address *Address) *common.Address {
+	if address == nil {
+		return nil
+	}
+	addr := address.address
+	return &addr
+}
+
 // SyncProgress gives progress indications when the node is synchronising with
 // the Evrynet network.
 type SyncProgress struct {
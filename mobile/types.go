@@ -203,6 +203,20 @@ func NewContractCreation(nonce int64, amount *BigInt, gasLimit int64, gasPrice *
 	return &Transaction{types.NewContractCreation(uint64(nonce), amount.bigint, uint64(gasLimit), gasPrice.bigint, common.CopyBytes(data))}
 }
 
+// NewEnterpriseContractCreation creates a new transaction for deploying a new
+// enterprise contract with the given owner and properties. If provider is not
+// nil, it pays the gas of the transactions to the contract. The owner is required.
+func NewEnterpriseContractCreation(nonce int64, amount *BigInt, gasLimit int64, gasPrice *BigInt, data []byte, owner *Address, provider *Address) (*Transaction, error) {
+	if owner == nil {
+		return nil, errors.New("owner is required")
+	}
+	opts := types.CreateAccountOption{OwnerAddress: &owner.address}
+	if provider != nil {
+		opts.ProviderAddress = &provider.address
+	}
+	return &Transaction{types.NewContractCreation(uint64(nonce), amount.bigint, uint64(gasLimit), gasPrice.bigint, common.CopyBytes(data), opts)}, nil
+}
+
 // NewTransaction creates a new transaction with the given properties. Contracts
 // can be created by transacting with a nil recipient.
 func NewTransaction(nonce int64, to *Address, amount *BigInt, gasLimit int64, gasPrice *BigInt, data []byte) *Transaction {
@@ -283,6 +297,49 @@ func (tx *Transaction) WithSignature(sig []byte, chainID *BigInt) (signedTx *Tra
 	return &Transaction{rawTx}, err
 }
 
+// GetOwner returns the owner of the enterprise contract created by the transaction,
+// or nil if the transaction does not create an enterprise contract.
+func (tx *Transaction) GetOwner() *Address {
+	if owner := tx.tx.Owner(); owner != nil {
+		return &Address{*owner}
+	}
+	return nil
+}
+
+// GetProvider returns the provider of the enterprise contract created by the
+// transaction, or nil if the transaction does not set one.
+func (tx *Transaction) GetProvider() *Address {
+	if provider := tx.tx.Provider(); provider != nil {
+		return &Address{*provider}
+	}
+	return nil
+}
+
+// GetSignedProvider returns the provider who co-signed the transaction to pay its
+// gas, or nil if the transaction is not co-signed.
+func (tx *Transaction) GetSignedProvider(chainID *BigInt) (provider *Address, _ error) {
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID.bigint)
+	}
+	rawProvider, err := types.Provider(signer, tx.tx)
+	if err != nil || rawProvider == nil {
+		return nil, err
+	}
+	return &Address{*rawProvider}, nil
+}
+
+// WithProviderSignature returns a copy of the transaction co-signed by a provider
+// with the given signature, in the [R || S || V] format where V is 0 or 1.
+func (tx *Transaction) WithProviderSignature(sig []byte, chainID *BigInt) (signedTx *Transaction, _ error) {
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID.bigint)
+	}
+	rawTx, err := tx.tx.WithProviderSignature(signer, common.CopyBytes(sig))
+	return &Transaction{rawTx}, err
+}
+
 // Transactions represents a slice of transactions.
 type Transactions struct{ txs types.Transactions }
 
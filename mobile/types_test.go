@@ -0,0 +1,64 @@
+package geth
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEnterpriseTransactions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mobile-keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := NewKeyStore(dir, LightScryptN, LightScryptP)
+	sender, err := ks.NewAccount("sender")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := ks.NewAccount("provider")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := NewBigInt(15)
+
+	// Enterprise contract creations carry the owner and the provider
+	creation, err := NewEnterpriseContractCreation(0, NewBigInt(0), 100000, NewBigInt(1), []byte{0x60}, sender.GetAddress(), provider.GetAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner := creation.GetOwner(); owner == nil || owner.GetHex() != sender.GetAddress().GetHex() {
+		t.Fatalf("owner mismatch: have %v, want %s", owner, sender.GetAddress().GetHex())
+	}
+	if have := creation.GetProvider(); have == nil || have.GetHex() != provider.GetAddress().GetHex() {
+		t.Fatalf("provider mismatch: have %v, want %s", have, provider.GetAddress().GetHex())
+	}
+	if creation, err = NewEnterpriseContractCreation(0, NewBigInt(0), 100000, NewBigInt(1), nil, sender.GetAddress(), nil); err != nil || creation.GetProvider() != nil {
+		t.Fatalf("unexpected provider: %v", err)
+	}
+	if _, err := NewEnterpriseContractCreation(0, NewBigInt(0), 100000, NewBigInt(1), nil, nil, nil); err == nil {
+		t.Fatal("creation without owner accepted")
+	}
+	// Transactions to enterprise contracts are signed by the sender and co-signed by the provider
+	contract, _ := NewAddressFromHex("0x0000000000000000000000000000000000001000")
+	tx, err := ks.SignTxPassphrase(sender, "sender", NewTransaction(0, contract, NewBigInt(0), 50000, NewBigInt(1), nil), chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer, err := tx.GetSignedProvider(chainID); err != nil || signer != nil {
+		t.Fatalf("unexpected provider signature: %v, %v", signer, err)
+	}
+	tx, err = ks.ProviderSignTxPassphrase(provider, "provider", tx, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := tx.GetSignedProvider(chainID)
+	if err != nil || signer == nil || signer.GetHex() != provider.GetAddress().GetHex() {
+		t.Fatalf("provider signature mismatch: have %v (%v), want %s", signer, err, provider.GetAddress().GetHex())
+	}
+	if from, err := tx.GetFrom(chainID); err != nil || from.GetHex() != sender.GetAddress().GetHex() {
+		t.Fatalf("sender mismatch: have %v (%v), want %s", from, err, sender.GetAddress().GetHex())
+	}
+}
@@ -70,6 +70,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the key-value store engine of the databases created in DataDir,
+	// either "leveldb" or "bolt". Existing databases are opened with their own
+	// engine if it's empty, and new ones use LevelDB.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -157,17 +157,17 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Evrynet core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig           = &ChainConfig{big.NewInt(1), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
-	TendermintTestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(TendermintConfig)}
+	TestChainConfig           = &ChainConfig{big.NewInt(1), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil, nil}
+	TendermintTestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, new(TendermintConfig)}
 	TestRules                 = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -209,6 +209,12 @@ type ChainConfig struct {
 	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
 	PetersburgBlock     *big.Int `json:"petersburgBlock,omitempty"`     // Petersburg switch block (nil = same as Constantinople)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
+	EnterpriseBlock     *big.Int `json:"enterpriseBlock,omitempty"`     // Enterprise manager operations switch block (nil = no fork, 0 = already activated)
+	SponsoredBlock      *big.Int `json:"sponsoredBlock,omitempty"`      // Sponsored transactions switch block (nil = no fork, 0 = already activated)
+	BatchBlock          *big.Int `json:"batchBlock,omitempty"`          // Batch transactions switch block (nil = no fork, 0 = already activated)
+	ScheduledBlock      *big.Int `json:"scheduledBlock,omitempty"`      // Scheduled transactions switch block (nil = no fork, 0 = already activated)
+	ContractSenderBlock *big.Int `json:"contractSenderBlock,omitempty"` // Contract sender transactions switch block (nil = no fork, 0 = already activated)
+	GasPriceBlock       *big.Int `json:"gasPriceBlock,omitempty"`       // Gas price manager operations switch block (nil = no fork, 0 = already activated)
 
 	// Various consensus engines
 	Ethash     *EthashConfig     `json:"ethash,omitempty"`
@@ -262,7 +268,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v GasPrice:%v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v  PetersburgBlock: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v GasPrice:%v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v  PetersburgBlock: %v Enterprise: %v Sponsored: %v Batch: %v Scheduled: %v ContractSender: %v GasPrice: %v Engine: %v}",
 		c.ChainID,
 		c.GasPrice,
 		c.HomesteadBlock,
@@ -274,6 +280,12 @@ func (c *ChainConfig) String() string {
 		c.ByzantiumBlock,
 		c.ConstantinopleBlock,
 		c.PetersburgBlock,
+		c.EnterpriseBlock,
+		c.SponsoredBlock,
+		c.BatchBlock,
+		c.ScheduledBlock,
+		c.ContractSenderBlock,
+		c.GasPriceBlock,
 		engine,
 	)
 }
@@ -325,6 +337,43 @@ func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
 }
 
+// IsEnterprise returns whether num represents a block number after the enterprise
+// fork, from which on the enterprise manager operations are executed natively.
+func (c *ChainConfig) IsEnterprise(num *big.Int) bool {
+	return isForked(c.EnterpriseBlock, num)
+}
+
+// IsSponsored returns whether num represents a block number after the sponsored
+// transactions fork, from which on sponsored transactions are accepted.
+func (c *ChainConfig) IsSponsored(num *big.Int) bool {
+	return isForked(c.SponsoredBlock, num)
+}
+
+// IsBatch returns whether num represents a block number after the batch fork,
+// from which on batch transactions are accepted and their calls executed.
+func (c *ChainConfig) IsBatch(num *big.Int) bool {
+	return isForked(c.BatchBlock, num)
+}
+
+// IsScheduled returns whether num represents a block number after the scheduled
+// transactions fork, from which on transactions may carry a validity window.
+func (c *ChainConfig) IsScheduled(num *big.Int) bool {
+	return isForked(c.ScheduledBlock, num)
+}
+
+// IsContractSender returns whether num represents a block number after the
+// contract sender fork, from which on contract accounts may send transactions.
+func (c *ChainConfig) IsContractSender(num *big.Int) bool {
+	return isForked(c.ContractSenderBlock, num)
+}
+
+// IsGasPrice returns whether num represents a block number after the gas price
+// fork, from which on gas price changes are executed natively and transactions
+// must pay the scheduled gas price.
+func (c *ChainConfig) IsGasPrice(num *big.Int) bool {
+	return isForked(c.GasPriceBlock, num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -396,6 +445,24 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.EnterpriseBlock, newcfg.EnterpriseBlock, head) {
+		return newCompatError("enterprise fork block", c.EnterpriseBlock, newcfg.EnterpriseBlock)
+	}
+	if isForkIncompatible(c.SponsoredBlock, newcfg.SponsoredBlock, head) {
+		return newCompatError("sponsored fork block", c.SponsoredBlock, newcfg.SponsoredBlock)
+	}
+	if isForkIncompatible(c.BatchBlock, newcfg.BatchBlock, head) {
+		return newCompatError("batch fork block", c.BatchBlock, newcfg.BatchBlock)
+	}
+	if isForkIncompatible(c.ScheduledBlock, newcfg.ScheduledBlock, head) {
+		return newCompatError("scheduled fork block", c.ScheduledBlock, newcfg.ScheduledBlock)
+	}
+	if isForkIncompatible(c.ContractSenderBlock, newcfg.ContractSenderBlock, head) {
+		return newCompatError("contract sender fork block", c.ContractSenderBlock, newcfg.ContractSenderBlock)
+	}
+	if isForkIncompatible(c.GasPriceBlock, newcfg.GasPriceBlock, head) {
+		return newCompatError("gas price fork block", c.GasPriceBlock, newcfg.GasPriceBlock)
+	}
 	return nil
 }
 
@@ -32,6 +32,22 @@ const (
 	// considered probably final and its rotated bits are calculated.
 	BloomConfirms = 256
 
+	// LogIndexBlocks is the number of blocks a single log index section covers.
+	LogIndexBlocks uint64 = 4096
+
+	// LogIndexConfirms is the number of confirmation blocks before a log index
+	// section is considered probably final and indexed.
+	LogIndexConfirms = 256
+
+	// AccountIndexBlocks is the number of blocks a single account index section
+	// covers. Sections are short, the internal transfers of their blocks are
+	// found by re-executing them on top of the still available parent states.
+	AccountIndexBlocks uint64 = 64
+
+	// AccountIndexConfirms is the number of confirmation blocks before an account
+	// index section is considered probably final and indexed.
+	AccountIndexConfirms = 16
+
 	// CHTFrequency is the block frequency for creating CHTs
 	CHTFrequency = 32768
 
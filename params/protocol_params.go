@@ -30,6 +30,9 @@ const (
 	CallNewAccountGas     uint64 = 25000 // Paid for CALL when the destination address didn't exist prior.
 	TxGas                 uint64 = 21000 // Per transaction not creating a contract. NOTE: Not payable on data of calls between transactions.
 	TxGasContractCreation uint64 = 53000 // Per transaction that creates a contract. NOTE: Not payable on data of calls between transactions.
+	EnterpriseOpGas       uint64 = 20000 // Per enterprise contract owner/provider management operation, on top of TxGas.
+	GasPriceChangeGas     uint64 = 20000 // Per gas price change scheduled by the staking admin, on top of TxGas.
+	TxBatchCallGas        uint64 = 5000  // Per call of a batch transaction, on top of TxGas once per batch.
 	TxDataZeroGas         uint64 = 4     // Per byte of data attached to a transaction that equals zero. NOTE: Not payable on data of calls between transactions.
 	QuadCoeffDiv          uint64 = 512   // Divisor for the quadratic particle of the memory cost equation.
 	LogDataGas            uint64 = 8     // Per byte in a LOG* operation's data.
@@ -69,7 +72,10 @@ const (
 	MemoryGas        uint64 = 3     // Times the address of the (highest referenced byte in memory + 1). NOTE: referencing happens on read, write and in instructions such as RETURN and CALL.
 	TxDataNonZeroGas uint64 = 68    // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
 
-	MaxCodeSize = 24576 // Maximum bytecode to permit for a contract
+	MaxCodeSize   = 24576 // Maximum bytecode to permit for a contract
+	MaxBatchCalls = 256   // Maximum number of calls of a batch transaction
+
+	SenderValidationGas uint64 = 200000 // Maximum gas the validation function of a contract sender may use to authorize a transaction
 
 	// Precompiled contract gas prices
 
@@ -25,6 +25,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/Evrynetlabs/evrynet-node/accounts"
 	"github.com/Evrynetlabs/evrynet-node/accounts/keystore"
@@ -45,7 +46,7 @@ const (
 	// ExternalAPIVersion -- see extapi_changelog.md
 	ExternalAPIVersion = "6.0.0"
 	// InternalAPIVersion -- see intapi_changelog.md
-	InternalAPIVersion = "6.0.0"
+	InternalAPIVersion = "6.1.0"
 )
 
 // ExternalAPI defines the external API through which signing requests are made.
@@ -73,6 +74,8 @@ type ExternalAPI interface {
 type UIClientAPI interface {
 	// ApproveTx prompt the user for confirmation to request to sign Transaction
 	ApproveTx(request *SignTxRequest) (SignTxResponse, error)
+	// ApproveProviderTx prompt the user for confirmation to co-sign a Transaction as its provider
+	ApproveProviderTx(request *ProviderSignTxRequest) (ProviderSignTxResponse, error)
 	// ApproveSignData prompt the user for confirmation to request to sign data
 	ApproveSignData(request *SignDataRequest) (SignDataResponse, error)
 	// ApproveListing prompt the user for confirmation to list accounts
@@ -118,6 +121,8 @@ type SignerAPI struct {
 	validator   Validator
 	rejectMode  bool
 	credentials storage.Storage
+
+	providerLock sync.Mutex // Serializes provider approvals with their co-signing
 }
 
 // Metadata about a request
@@ -233,6 +238,19 @@ type (
 		Transaction SendTxArgs `json:"transaction"`
 		Approved    bool       `json:"approved"`
 	}
+	// ProviderSignTxRequest contains info about a sender-signed Transaction to co-sign as provider
+	ProviderSignTxRequest struct {
+		Transaction SendTxArgs       `json:"transaction"`
+		Hash        common.Hash      `json:"hash"`
+		Fee         hexutil.Big      `json:"fee"` // The maximum fee paid by the provider, gas * gasPrice
+		Callinfo    []ValidationInfo `json:"call_info"`
+		Meta        Metadata         `json:"meta"`
+	}
+	// ProviderSignTxResponse result from ProviderSignTxRequest
+	ProviderSignTxResponse struct {
+		// The transaction is already signed by the sender, the UI can not modify it
+		Approved bool `json:"approved"`
+	}
 	SignDataRequest struct {
 		ContentType string                  `json:"content_type"`
 		Address     common.MixedcaseAddress `json:"address"`
@@ -275,6 +293,14 @@ type (
 
 var ErrRequestDenied = errors.New("Request denied")
 
+// ErrProviderContractCreation is returned if a provider is asked to co-sign a contract creation,
+// which can not be paid for by a provider.
+var ErrProviderContractCreation = errors.New("provider can not co-sign a contract creation")
+
+// ErrProviderMismatch is returned if a provider is asked to co-sign a transaction which
+// names another provider.
+var ErrProviderMismatch = errors.New("transaction names a different provider")
+
 // NewSignerAPI creates a new API that can be used for Account management.
 // ksLocation specifies the directory where to store the password protected private
 // key that is generated when a new Account is created.
@@ -284,7 +310,14 @@ func NewSignerAPI(am *accounts.Manager, chainID int64, noUSB bool, ui UIClientAP
 	if advancedMode {
 		log.Info("Clef is in advanced mode: will warn instead of reject")
 	}
-	signer := &SignerAPI{big.NewInt(chainID), am, ui, validator, !advancedMode, credentials}
+	signer := &SignerAPI{
+		chainID:     big.NewInt(chainID),
+		am:          am,
+		UI:          ui,
+		validator:   validator,
+		rejectMode:  !advancedMode,
+		credentials: credentials,
+	}
 	if !noUSB {
 		signer.startUSBListener()
 	}
@@ -563,10 +596,69 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 
 }
 
-// ProviderSignTransaction signs the given Transaction and returns it both as json and rlp-encoded form
+// ProviderSignTransaction co-signs the given sender-signed Transaction as its provider, and returns it both
+// as json and rlp-encoded form
 func (api *SignerAPI) ProviderSignTransaction(ctx context.Context, tx *types.Transaction, providerAddr common.Address, methodSelector *string) (*evrapi.SignTransactionResult, error) {
 	var (
 		err    error
+		result ProviderSignTxResponse
+	)
+	from, err := types.Sender(types.NewEIP155Signer(api.chainID), tx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.To() == nil {
+		return nil, ErrProviderContractCreation
+	}
+	if named := tx.Provider(); named != nil && *named != providerAddr {
+		return nil, ErrProviderMismatch
+	}
+	var (
+		to   = common.NewMixedcaseAddress(*tx.To())
+		data = hexutil.Bytes(tx.Data())
+	)
+	args := SendTxArgs{
+		From:     common.NewMixedcaseAddress(from),
+		To:       &to,
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     &data,
+		Provider: &providerAddr,
+	}
+
+	msgs, err := api.validator.ValidateTransaction(methodSelector, &args)
+	if err != nil {
+		return nil, err
+	}
+	// If we are in 'rejectMode', then reject rather than show the user warnings
+	if api.rejectMode {
+		if err := msgs.getWarnings(); err != nil {
+			return nil, err
+		}
+	}
+	req := ProviderSignTxRequest{
+		Transaction: args,
+		Hash:        tx.Hash(),
+		Fee:         hexutil.Big(*new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))),
+		Meta:        MetadataFromContext(ctx),
+		Callinfo:    msgs.Messages,
+	}
+	// The daily spend of a provider is only updated once the transaction is co-signed,
+	// so no other approval may happen in between
+	api.providerLock.Lock()
+	defer api.providerLock.Unlock()
+
+	// Process approval
+	result, err = api.UI.ApproveProviderTx(&req)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Approved {
+		return nil, ErrRequestDenied
+	}
+	var (
 		acc    accounts.Account
 		wallet accounts.Wallet
 	)
@@ -581,7 +673,7 @@ func (api *SignerAPI) ProviderSignTransaction(ctx context.Context, tx *types.Tra
 	if err != nil {
 		return nil, err
 	}
-	// The one to sign is the one that was returned from the UI
+	// The one to sign is the one that was approved, the UI can not modify it
 	signedTx, err := wallet.ProviderSignTxWithPassphrase(acc, pw, tx, api.chainID)
 	if err != nil {
 		api.UI.ShowError(err.Error())
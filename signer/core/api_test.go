@@ -32,6 +32,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/internal/evrapi"
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
@@ -71,6 +72,10 @@ func (ui *headlessUi) ApproveTx(request *core.SignTxRequest) (core.SignTxRespons
 	}
 }
 
+func (ui *headlessUi) ApproveProviderTx(request *core.ProviderSignTxRequest) (core.ProviderSignTxResponse, error) {
+	return core.ProviderSignTxResponse{Approved: "Y" == <-ui.approveCh}, nil
+}
+
 func (ui *headlessUi) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
 	approved := "Y" == <-ui.approveCh
 	return core.SignDataResponse{approved}, nil
@@ -374,4 +379,23 @@ func TestProviderSignTx(t *testing.T) {
 	if pv == nil || pr == nil || ps == nil {
 		t.Errorf("Expected pv,pr,ps not null")
 	}
+
+	// Provider co-signing must be approved by the UI
+	control.approveCh <- "N"
+	res2, err = api.ProviderSignTransaction(context.Background(), parsedTx, providerAddr, &methodSig)
+	if res2 != nil || err != core.ErrRequestDenied {
+		t.Errorf("Expected denial, got %v", err)
+	}
+
+	// A provider can not co-sign a transaction naming another provider
+	key, _ := crypto.GenerateKey()
+	sponsored := types.NewSponsoredTransaction(big.NewInt(1337), 0, common.HexToAddress("0xdead"), common.HexToAddress("0xbeef"), new(big.Int), 21000, big.NewInt(1), nil)
+	sponsored, err = types.SignTx(sponsored, types.NewEIP155Signer(big.NewInt(1337)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res2, err = api.ProviderSignTransaction(context.Background(), sponsored, providerAddr, &methodSig)
+	if res2 != nil || err != core.ErrProviderMismatch {
+		t.Errorf("Expected provider mismatch, got %v", err)
+	}
 }
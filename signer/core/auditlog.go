@@ -19,6 +19,7 @@ package core
 import (
 	"context"
 	"encoding/hex"
+	"math/big"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
@@ -68,8 +69,17 @@ func (l *AuditLogger) ProviderSignTransaction(ctx context.Context, tx *types.Tra
 	if methodSelector != nil {
 		sel = *methodSelector
 	}
+	to := "<contract creation>"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
 	l.log.Info("ProviderSignTransaction", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"tx", hex.EncodeToString(tx.Data()),
+		"hash", tx.Hash().Hex(),
+		"to", to,
+		"gas", tx.Gas(),
+		"gasPrice", tx.GasPrice(),
+		"fee", new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas())),
 		"providerAddr", providerAddr.Hex(),
 		"methodSelector", sel)
 
@@ -162,6 +162,38 @@ func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 	return SignTxResponse{request.Transaction, true}, nil
 }
 
+// ApproveProviderTx prompt the user for confirmation to co-sign a Transaction as its provider
+func (ui *CommandlineUI) ApproveProviderTx(request *ProviderSignTxRequest) (ProviderSignTxResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	fmt.Printf("--------- Provider co-signing request-------------\n")
+	fmt.Printf("provider: %v\n", request.Transaction.Provider.Hex())
+	fmt.Printf("sender:   %v\n", request.Transaction.From.String())
+	fmt.Printf("contract: %v\n", request.Transaction.To.Original())
+	fmt.Printf("value:    %v wei\n", request.Transaction.Value.ToInt())
+	fmt.Printf("gas:      %v (%v)\n", request.Transaction.Gas, uint64(request.Transaction.Gas))
+	fmt.Printf("gasprice: %v wei\n", request.Transaction.GasPrice.ToInt())
+	fmt.Printf("max fee:  %v wei\n", request.Fee.ToInt())
+	fmt.Printf("nonce:    %v (%v)\n", request.Transaction.Nonce, uint64(request.Transaction.Nonce))
+	fmt.Printf("hash:     %v\n", request.Hash.Hex())
+	if request.Transaction.Data != nil {
+		if d := *request.Transaction.Data; len(d) > 0 {
+			fmt.Printf("data:     %v\n", hexutil.Encode(d))
+		}
+	}
+	if request.Callinfo != nil {
+		fmt.Printf("\nTransaction validation:\n")
+		for _, m := range request.Callinfo {
+			fmt.Printf("  * %s : %s\n", m.Typ, m.Message)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("\n")
+	showMetadata(request.Meta)
+	fmt.Printf("-------------------------------------------\n")
+	return ProviderSignTxResponse{ui.confirm()}, nil
+}
+
 // ApproveSignData prompt the user for confirmation to request to sign data
 func (ui *CommandlineUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
 	ui.mu.Lock()
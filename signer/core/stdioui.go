@@ -69,6 +69,12 @@ func (ui *StdIOUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
 	return result, err
 }
 
+func (ui *StdIOUI) ApproveProviderTx(request *ProviderSignTxRequest) (ProviderSignTxResponse, error) {
+	var result ProviderSignTxResponse
+	err := ui.dispatch("ui_approveProviderTx", request, &result)
+	return result, err
+}
+
 func (ui *StdIOUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
 	var result SignDataResponse
 	err := ui.dispatch("ui_approveSignData", request, &result)
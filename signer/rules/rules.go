@@ -19,9 +19,15 @@ package rules
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/internal/evrapi"
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/signer/core"
@@ -51,6 +57,19 @@ type rulesetUI struct {
 	next    core.UIClientAPI // The next handler, for manual processing
 	storage storage.Storage
 	jsRules string // The rules to use
+
+	providerLock    sync.Mutex     // Protects the daily provider spend
+	providerPending *providerTxFee // Approved provider request awaiting its co-signature
+}
+
+// providerTxFee is the fee of an approved provider request, added to the daily spend
+// of the provider once the transaction is co-signed.
+type providerTxFee struct {
+	provider common.Address
+	day      string
+	nonce    uint64
+	to       common.Address
+	fee      *big.Int
 }
 
 func NewRuleEvaluator(next core.UIClientAPI, jsbackend storage.Storage) (*rulesetUI, error) {
@@ -156,6 +175,97 @@ func (r *rulesetUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse,
 	return core.SignTxResponse{Approved: false}, err
 }
 
+// providerTxRequest is the request passed to the ApproveProviderTx rule, with the fees
+// the provider already approved on the current day.
+type providerTxRequest struct {
+	*core.ProviderSignTxRequest
+	SpentToday *hexutil.Big `json:"spent_today"`
+}
+
+// ApproveProviderTx evaluates the ApproveProviderTx rule. The fees approved for the
+// provider on the current (UTC) day are passed as spent_today, so rules can enforce a
+// daily fee budget. The fee of an approved request is added to the daily spend kept in
+// the rules storage once the transaction is co-signed, see OnApprovedTx.
+func (r *rulesetUI) ApproveProviderTx(request *core.ProviderSignTxRequest) (core.ProviderSignTxResponse, error) {
+	r.providerLock.Lock()
+	defer r.providerLock.Unlock()
+
+	// A request still pending was never co-signed, its fee is not spent
+	r.providerPending = nil
+
+	var (
+		provider = *request.Transaction.Provider
+		day      = time.Now().UTC().Format("2006-01-02")
+		spent    = r.providerSpend(provider, day)
+	)
+	jsonreq, err := json.Marshal(&providerTxRequest{request, (*hexutil.Big)(spent)})
+	approved, err := r.checkApproval("ApproveProviderTx", jsonreq, err)
+	if err != nil {
+		log.Info("Rule-based approval error, going to manual", "error", err)
+		result, err := r.next.ApproveProviderTx(request)
+		if err == nil && result.Approved {
+			r.providerPending = newProviderTxFee(request, day)
+		}
+		return result, err
+	}
+	if approved {
+		r.providerPending = newProviderTxFee(request, day)
+		return core.ProviderSignTxResponse{Approved: true}, nil
+	}
+	return core.ProviderSignTxResponse{Approved: false}, err
+}
+
+// newProviderTxFee returns the fee of the approved provider request on day.
+func newProviderTxFee(request *core.ProviderSignTxRequest, day string) *providerTxFee {
+	return &providerTxFee{
+		provider: *request.Transaction.Provider,
+		day:      day,
+		nonce:    uint64(request.Transaction.Nonce),
+		to:       request.Transaction.To.Address(),
+		fee:      request.Fee.ToInt(),
+	}
+}
+
+// recordProviderSpend adds the fee of the pending provider request to the daily spend
+// of its provider, if tx is the co-signed transaction of that request.
+func (r *rulesetUI) recordProviderSpend(tx *types.Transaction) {
+	r.providerLock.Lock()
+	defer r.providerLock.Unlock()
+
+	pending := r.providerPending
+	if pending == nil || tx == nil || tx.To() == nil {
+		return
+	}
+	if _, pr, _ := tx.RawProviderSignatureValues(); pr == nil || pr.Sign() == 0 {
+		return
+	}
+	if tx.Nonce() != pending.nonce || *tx.To() != pending.to {
+		return
+	}
+	spent := r.providerSpend(pending.provider, pending.day)
+	r.setProviderSpend(pending.provider, pending.day, spent.Add(spent, pending.fee))
+	r.providerPending = nil
+}
+
+// providerSpendKey is the storage key of the fees approved for provider on day.
+func providerSpendKey(provider common.Address, day string) string {
+	return fmt.Sprintf("providerspend/%s/%s", strings.ToLower(provider.Hex()), day)
+}
+
+// providerSpend returns the fees approved for provider on day.
+func (r *rulesetUI) providerSpend(provider common.Address, day string) *big.Int {
+	spent, ok := new(big.Int).SetString(r.storage.Get(providerSpendKey(provider, day)), 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return spent
+}
+
+// setProviderSpend stores the fees approved for provider on day.
+func (r *rulesetUI) setProviderSpend(provider common.Address, day string, spent *big.Int) {
+	r.storage.Put(providerSpendKey(provider, day), spent.String())
+}
+
 func (r *rulesetUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
 	jsonreq, err := json.Marshal(request)
 	approved, err := r.checkApproval("ApproveSignData", jsonreq, err)
@@ -217,6 +327,8 @@ func (r *rulesetUI) OnSignerStartup(info core.StartupInfo) {
 }
 
 func (r *rulesetUI) OnApprovedTx(tx evrapi.SignTransactionResult) {
+	r.recordProviderSpend(tx.Tx)
+
 	jsonTx, err := json.Marshal(tx)
 	if err != nil {
 		log.Warn("failed marshalling transaction", "tx", tx)
@@ -21,11 +21,13 @@ import (
 	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/accounts"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/internal/evrapi"
 	"github.com/Evrynetlabs/evrynet-node/signer/core"
 	"github.com/Evrynetlabs/evrynet-node/signer/storage"
@@ -87,6 +89,10 @@ func (alwaysDenyUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse,
 	return core.SignTxResponse{Transaction: request.Transaction, Approved: false}, nil
 }
 
+func (alwaysDenyUI) ApproveProviderTx(request *core.ProviderSignTxRequest) (core.ProviderSignTxResponse, error) {
+	return core.ProviderSignTxResponse{Approved: false}, nil
+}
+
 func (alwaysDenyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
 	return core.SignDataResponse{Approved: false}, nil
 }
@@ -212,6 +218,11 @@ func (d *dummyUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, e
 	return core.SignTxResponse{}, core.ErrRequestDenied
 }
 
+func (d *dummyUI) ApproveProviderTx(request *core.ProviderSignTxRequest) (core.ProviderSignTxResponse, error) {
+	d.calls = append(d.calls, "ApproveProviderTx")
+	return core.ProviderSignTxResponse{}, core.ErrRequestDenied
+}
+
 func (d *dummyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
 	d.calls = append(d.calls, "ApproveSignData")
 	return core.SignDataResponse{}, core.ErrRequestDenied
@@ -519,6 +530,11 @@ func (d *dontCallMe) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse
 	return core.SignTxResponse{}, core.ErrRequestDenied
 }
 
+func (d *dontCallMe) ApproveProviderTx(request *core.ProviderSignTxRequest) (core.ProviderSignTxResponse, error) {
+	d.t.Fatalf("Did not expect next-handler to be called")
+	return core.ProviderSignTxResponse{}, core.ErrRequestDenied
+}
+
 func (d *dontCallMe) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
 	d.t.Fatalf("Did not expect next-handler to be called")
 	return core.SignDataResponse{}, core.ErrRequestDenied
@@ -581,6 +597,125 @@ func TestContextIsCleared(t *testing.T) {
 	}
 }
 
+const ExampleProviderBudget = `
+	function big(str){
+		if(str.slice(0,2) == "0x"){ return new BigNumber(str.slice(2),16)}
+		return new BigNumber(str)
+	}
+
+	// Daily budget : 1e12 wei
+	var budget = new BigNumber("1e12");
+
+	function ApproveProviderTx(r){
+		// Only sponsor our own contract
+		if (r.transaction.to.toLowerCase() != "0x000000000000000000000000000000000000dead"){
+			return "Reject"
+		}
+		if (big(r.spent_today).plus(big(r.fee)).lte(budget)){
+			return "Approve"
+		}
+		return "Reject"
+	}
+`
+
+func dummyProviderTx(to string) *core.ProviderSignTxRequest {
+	unsigned := dummyTx(hexutil.Big(*big.NewInt(0)))
+	unsigned.Transaction.To, _ = mixAddr(to)
+	provider := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	unsigned.Transaction.Provider = &provider
+
+	// 21000 gas at 2000000 wei is 4.2e10 wei
+	return &core.ProviderSignTxRequest{
+		Transaction: unsigned.Transaction,
+		Fee:         hexutil.Big(*big.NewInt(21000 * 2000000)),
+		Meta:        unsigned.Meta,
+	}
+}
+
+// providerSigned returns the transaction of dummyProviderTx(to), co-signed by a provider.
+func providerSigned(t *testing.T, to string) evrapi.SignTransactionResult {
+	key, _ := crypto.GenerateKey()
+	tx := types.NewTransaction(3, common.HexToAddress(to), new(big.Int), 21000, big.NewInt(2000000), nil)
+	tx, err := types.ProviderSignTx(tx, types.NewEIP155Signer(big.NewInt(1)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return evrapi.SignTransactionResult{Tx: tx}
+}
+
+func TestProviderBudget(t *testing.T) {
+	r, err := initRuleEngine(ExampleProviderBudget)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	resp, err := r.ApproveProviderTx(dummyProviderTx("000000000000000000000000000000000000babe"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if resp.Approved {
+		t.Fatalf("Expected other contracts to be rejected")
+	}
+	// 23 requests fit into the budget, the 24th exceeds it
+	for i := 0; i < 23; i++ {
+		resp, err := r.ApproveProviderTx(dummyProviderTx("000000000000000000000000000000000000dead"))
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if !resp.Approved {
+			t.Fatalf("Expected request %d to be approved", i)
+		}
+		r.OnApprovedTx(providerSigned(t, "000000000000000000000000000000000000dead"))
+	}
+	resp, err = r.ApproveProviderTx(dummyProviderTx("000000000000000000000000000000000000dead"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if resp.Approved {
+		t.Fatalf("Expected request over the budget to be rejected")
+	}
+	// The spend is kept in the storage, per provider and day
+	provider := common.HexToAddress("0x000000000000000000000000000000000000beef")
+	if spent := r.providerSpend(provider, time.Now().UTC().Format("2006-01-02")); spent.Cmp(big.NewInt(23*21000*2000000)) != 0 {
+		t.Fatalf("Unexpected daily spend %v", spent)
+	}
+}
+
+func TestProviderBudgetUnsigned(t *testing.T) {
+	r, err := initRuleEngine(ExampleProviderBudget)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	var (
+		provider = common.HexToAddress("0x000000000000000000000000000000000000beef")
+		day      = time.Now().UTC().Format("2006-01-02")
+	)
+	// An approved request which is never co-signed, e.g. on a wrong password, is not spent
+	if resp, err := r.ApproveProviderTx(dummyProviderTx("000000000000000000000000000000000000dead")); err != nil || !resp.Approved {
+		t.Fatalf("Expected request to be approved, got %v", err)
+	}
+	if spent := r.providerSpend(provider, day); spent.Sign() != 0 {
+		t.Fatalf("Unexpected daily spend %v before co-signing", spent)
+	}
+	if resp, err := r.ApproveProviderTx(dummyProviderTx("000000000000000000000000000000000000dead")); err != nil || !resp.Approved {
+		t.Fatalf("Expected request to be approved, got %v", err)
+	}
+	// Transactions without a provider signature or of other requests are not spent
+	r.OnApprovedTx(evrapi.SignTransactionResult{Tx: dummySigned(new(big.Int))})
+	r.OnApprovedTx(providerSigned(t, "000000000000000000000000000000000000babe"))
+	if spent := r.providerSpend(provider, day); spent.Sign() != 0 {
+		t.Fatalf("Unexpected daily spend %v for other transactions", spent)
+	}
+	r.OnApprovedTx(providerSigned(t, "000000000000000000000000000000000000dead"))
+	if spent := r.providerSpend(provider, day); spent.Cmp(big.NewInt(21000*2000000)) != 0 {
+		t.Fatalf("Unexpected daily spend %v", spent)
+	}
+	// The fee is only spent once
+	r.OnApprovedTx(providerSigned(t, "000000000000000000000000000000000000dead"))
+	if spent := r.providerSpend(provider, day); spent.Cmp(big.NewInt(21000*2000000)) != 0 {
+		t.Fatalf("Unexpected daily spend %v", spent)
+	}
+}
+
 func TestSignData(t *testing.T) {
 
 	js := `function ApproveListing(){
@@ -90,6 +90,8 @@ type Database struct {
 	childrenSize  common.StorageSize // Storage size of the external children tracking
 	preimagesSize common.StorageSize // Storage size of the preimages cache
 
+	onWrite func(hash common.Hash) // Hook called before a node is written to disk
+
 	lock sync.RWMutex
 }
 
@@ -321,6 +323,15 @@ func NewDatabaseWithCache(diskdb evrdb.KeyValueStore, cache int) *Database {
 	}
 }
 
+// SetWriteHook installs a callback invoked with the hash of every node right
+// before it is flushed to disk, allowing a concurrent pruner to retain it.
+//
+// Note, this method is a non-synchronized mutator. It is unsafe to call this
+// concurrently with other mutators.
+func (db *Database) SetWriteHook(hook func(hash common.Hash)) {
+	db.onWrite = hook
+}
+
 // DiskDB retrieves the persistent storage backing the trie database.
 func (db *Database) DiskDB() evrdb.KeyValueReader {
 	return db.diskdb
@@ -632,6 +643,9 @@ func (db *Database) Cap(limit common.StorageSize) error {
 	for size > limit && oldest != (common.Hash{}) {
 		// Fetch the oldest referenced node and push into the batch
 		node := db.dirties[oldest]
+		if db.onWrite != nil {
+			db.onWrite(oldest)
+		}
 		if err := batch.Put(oldest[:], node.rlp()); err != nil {
 			return err
 		}
@@ -781,6 +795,9 @@ func (db *Database) commit(hash common.Hash, batch evrdb.Batch, uncacher *cleane
 			return err
 		}
 	}
+	if db.onWrite != nil {
+		db.onWrite(hash)
+	}
 	if err := batch.Put(hash[:], node.rlp()); err != nil {
 		return err
 	}
@@ -21,7 +21,9 @@ import (
 	"fmt"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/evrdb/memorydb"
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
@@ -129,6 +131,20 @@ func VerifyProof(rootHash common.Hash, key []byte, proofDb evrdb.KeyValueReader)
 	}
 }
 
+// VerifyProofList checks a merkle proof given as the list of its RLP encoded
+// nodes, the form returned by the proof RPC endpoints. It returns the value for
+// key, or nil if the proof shows that the trie doesn't contain the key.
+func VerifyProofList(rootHash common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	proofDb := memorydb.New()
+	for _, node := range proof {
+		if err := proofDb.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	value, _, err := VerifyProof(rootHash, key, proofDb)
+	return value, err
+}
+
 func get(tn node, key []byte) ([]byte, node) {
 	for {
 		switch n := tn.(type) {